@@ -0,0 +1,110 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// unaliased tells whether the Define call in progress does not alias the input struct path.
+func (s *scope) unaliased(path string) bool {
+	for _, p := range s.withoutAliases {
+		if p == "" || path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasStructPath tells whether the input type has a field at the input struct path (ie., the lowercase names of the nested fields, joined by dots).
+//
+// The empty path stands for the whole type.
+func hasStructPath(t reflect.Type, path string) bool {
+	if path == "" {
+		return true
+	}
+	_, ok := structFieldByPath(t, path)
+
+	return ok
+}
+
+// structFieldByPath returns the field of the input type at the input (non-empty) struct path.
+func structFieldByPath(t reflect.Type, path string) (reflect.StructField, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	head, rest, _ := strings.Cut(path, ".")
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() && strings.ToLower(f.Name) == head {
+			if rest == "" {
+				return f, true
+			}
+
+			return structFieldByPath(f.Type, rest)
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// checkAliasCollision errors when the flag of the input field clashes with the alias of the struct path of another flag, or the other way round.
+//
+// Viper resolves an alias before anything else, so that the config key and the env variable of such flags would be ambiguous.
+func checkAliasCollision(c *cobra.Command, path, name string) error {
+	s := getScope(c)
+	if other, ok := s.aliases[name]; ok {
+		return fmt.Errorf("flag --%s of %s clashes with the alias of the struct path of --%s", name, path, other)
+	}
+	if path == name || s.unaliased(path) {
+		return nil
+	}
+	if f := c.Flags().Lookup(path); f != nil {
+		return fmt.Errorf("the alias of %s to --%s clashes with flag --%s", path, name, f.Name)
+	}
+
+	return nil
+}
+
+// unaliasSettings copies the values of the renamed flags that have no alias to the struct paths of their fields, for the decoding.
+//
+// Without the alias, viper keys such values by flag name only, and the struct paths could hold unrelated keys of the config file.
+func unaliasSettings(c *cobra.Command, settings map[string]interface{}) {
+	s := getScope(c)
+	// Read all the values before writing any, since a struct path can be the flag name of another field
+	paths := []string{}
+	values := map[string]interface{}{}
+	for path, name := range s.paths {
+		if path == name || s.aliases[path] == name {
+			continue
+		}
+		paths = append(paths, path)
+		if val, ok := lookupSetting(settings, name, true); ok {
+			values[path] = val
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		parts := strings.Split(path, ".")
+		m := settings
+		for _, part := range parts[:len(parts)-1] {
+			next, isMap := m[part].(map[string]interface{})
+			if !isMap {
+				next = map[string]interface{}{}
+				m[part] = next
+			}
+			m = next
+		}
+		if val, ok := values[path]; ok {
+			m[parts[len(parts)-1]] = val
+		} else {
+			delete(m, parts[len(parts)-1])
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestWithoutAliases() {
+	suite.useConfigFile("config.yaml", "a:\n  b: for-c\nx: for-b\n")
+
+	root := &cobra.Command{Use: "root"}
+	c := &cobra.Command{Use: "aliases"}
+	root.AddCommand(c)
+	opts := &lintOptions{}
+	suite.Require().Nil(Define(c, opts, WithoutAliases("a")))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("for-b", opts.A.B)
+	suite.Equal("for-c", opts.C)
+	suite.Equal([]KeyAliases{
+		{Command: "root aliases", Key: "a.b", Aliases: []string{"c"}},
+		{Command: "root aliases", Key: "other", Aliases: []string{}},
+		{Command: "root aliases", Key: "x", Aliases: []string{}},
+	}, ConfigKeyAliases(root))
+	errs := Lint(root)
+	suite.Require().Len(errs, 1)
+	suite.EqualError(errs[0], "root aliases: env A_B binds to --x but it already binds to --a.b")
+	suite.True(ValidateConfig(root).OK())
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--x", "from-cli"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("from-cli", opts.A.B)
+
+	err := Define(&cobra.Command{Use: "invalid"}, &lintOptions{}, WithoutAliases("b"))
+	suite.EqualError(err, "couldn't disable the aliases of b: no such struct path")
+
+	// Without any alias, the struct path of A.B is the flag name of C: the outcome must not depend on the map iteration order
+	all := &cobra.Command{Use: "all"}
+	root.AddCommand(all)
+	allOpts := &lintOptions{}
+	suite.Require().Nil(Define(all, allOpts, WithoutAliases()))
+	for i := 0; i < 20; i++ {
+		suite.Require().Nil(Unmarshal(all, allOpts))
+		suite.Equal("for-b", allOpts.A.B)
+		suite.Equal("for-c", allOpts.C)
+	}
+}
@@ -0,0 +1,343 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// specField is a field of the config documents a command accepts, for generating API definitions from the options.
+type specField struct {
+	// key is the config key of the field, relative to its parent
+	key string
+	// name is the name of the struct field
+	name string
+	typ  reflect.Type
+	flag *pflag.Flag
+	// fields are the fields of the nested structs
+	fields []specField
+}
+
+// specFields collects the fields of the options attached to the input command, in declaration order.
+func specFields(c *cobra.Command) ([]specField, error) {
+	s, ok := scopes[c]
+	if !ok {
+		return nil, fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	res := []specField{}
+	for _, o := range s.options {
+		res = append(res, collectSpecFields(c, s, reflect.TypeOf(target(o)), "")...)
+	}
+
+	return res, nil
+}
+
+func collectSpecFields(c *cobra.Command, s *scope, typ reflect.Type, structPath string) []specField {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	res := []specField{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key := strings.ToLower(f.Name)
+		path := key
+		if structPath != "" {
+			path = structPath + "." + key
+		}
+		if name, ok := s.paths[path]; ok {
+			if flag := c.Flags().Lookup(name); flag != nil {
+				res = append(res, specField{key: key, name: f.Name, typ: f.Type, flag: flag})
+			}
+
+			continue
+		}
+		fieldType := f.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() != reflect.Struct {
+			continue
+		}
+		if nested := collectSpecFields(c, s, fieldType, path); len(nested) > 0 {
+			res = append(res, specField{key: key, name: f.Name, typ: f.Type, fields: nested})
+		}
+	}
+
+	return res
+}
+
+// ProtoMessage renders the config documents the input command accepts as a protobuf (proto3) message with the given name.
+//
+// It lets services accepting the same settings via API and via command line generate both definitions from the options.
+// Fields follow the struct paths of the options, with nested structs becoming nested messages.
+// Field numbers follow the declaration order, so append the new fields at the end of their structs to keep them stable.
+// Durations and the types parsing themselves from strings (eg., zapcore.Level) are strings.
+func ProtoMessage(c *cobra.Command, name string) (string, error) {
+	fields, err := specFields(c)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := writeProtoMessage(&b, name, fields, ""); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func writeProtoMessage(b *strings.Builder, name string, fields []specField, indent string) error {
+	fmt.Fprintf(b, "%smessage %s {\n", indent, name)
+	for i, f := range fields {
+		if f.flag == nil {
+			if err := writeProtoMessage(b, f.name, f.fields, indent+"  "); err != nil {
+				return err
+			}
+			fmt.Fprintf(b, "%s  %s %s = %d;\n", indent, f.name, f.key, i+1)
+
+			continue
+		}
+		typ, err := protoType(f.typ)
+		if err != nil {
+			return fmt.Errorf("flag --%s: %w", f.flag.Name, err)
+		}
+		if comment := specComment(f.flag); comment != "" {
+			fmt.Fprintf(b, "%s  // %s\n", indent, comment)
+		}
+		fmt.Fprintf(b, "%s  %s %s = %d;\n", indent, typ, f.key, i+1)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+
+	return nil
+}
+
+// protoType returns the protobuf type of the input Go type.
+func protoType(typ reflect.Type) (string, error) {
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if typ == ipType {
+			break
+		}
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			return "bytes", nil
+		}
+		elem, err := protoType(typ.Elem())
+		if err != nil || strings.HasPrefix(elem, "repeated ") || strings.HasPrefix(elem, "map<") {
+			return "", fmt.Errorf("unsupported type %s", typ)
+		}
+
+		return "repeated " + elem, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return "", fmt.Errorf("unsupported type %s", typ)
+		}
+		elem, err := protoType(typ.Elem())
+		if err != nil || strings.HasPrefix(elem, "repeated ") || strings.HasPrefix(elem, "map<") {
+			return "", fmt.Errorf("unsupported type %s", typ)
+		}
+
+		return fmt.Sprintf("map<string, %s>", elem), nil
+	}
+	if scalar, ok := specScalar(typ); ok {
+		return scalar.proto, nil
+	}
+
+	return "", fmt.Errorf("unsupported type %s", typ)
+}
+
+// OpenAPISchema is an OpenAPI (3.0) schema object.
+type OpenAPISchema struct {
+	Type                 string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string                    `json:"description,omitempty" yaml:"description,omitempty"`
+	Default              interface{}               `json:"default,omitempty" yaml:"default,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	WriteOnly            bool                      `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+}
+
+// OpenAPIComponent returns the schema of the config documents the input command accepts, for the components section of an OpenAPI document.
+//
+// Properties follow the struct paths of the options, as ProtoMessage does.
+// Required flags are required properties, and secret flags are write-only.
+func OpenAPIComponent(c *cobra.Command) (*OpenAPISchema, error) {
+	fields, err := specFields(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return openAPIObject(fields)
+}
+
+func openAPIObject(fields []specField) (*OpenAPISchema, error) {
+	res := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	for _, f := range fields {
+		if f.flag == nil {
+			nested, err := openAPIObject(f.fields)
+			if err != nil {
+				return nil, err
+			}
+			res.Properties[f.key] = nested
+
+			continue
+		}
+		prop, err := openAPIType(f.typ)
+		if err != nil {
+			return nil, fmt.Errorf("flag --%s: %w", f.flag.Name, err)
+		}
+		prop.Description = f.flag.Usage
+		prop.Default = openAPIDefault(f.flag, f.typ)
+		if isSecretFlag(f.flag) {
+			prop.WriteOnly = true
+			prop.Default = nil
+		}
+		if _, required := f.flag.Annotations[cobra.BashCompOneRequiredFlag]; required {
+			res.Required = append(res.Required, f.key)
+		}
+		res.Properties[f.key] = prop
+	}
+
+	return res, nil
+}
+
+// openAPIType returns the schema of the input Go type.
+func openAPIType(typ reflect.Type) (*OpenAPISchema, error) {
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if typ == ipType {
+			break
+		}
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			return &OpenAPISchema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := openAPIType(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &OpenAPISchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported type %s", typ)
+		}
+		values, err := openAPIType(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &OpenAPISchema{Type: "object", AdditionalProperties: values}, nil
+	}
+	if scalar, ok := specScalar(typ); ok {
+		return &OpenAPISchema{Type: scalar.openAPI, Format: scalar.format}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+// openAPIDefault returns the default value of the input flag, typed as its schema, if it is not the zero value.
+func openAPIDefault(f *pflag.Flag, typ reflect.Type) interface{} {
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		if f.DefValue == "0s" {
+			return nil
+		}
+
+		return f.DefValue
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		if f.DefValue != "" {
+			return f.DefValue
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(f.DefValue); err == nil && b {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(f.DefValue, 10, 64); err == nil && n != 0 {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(f.DefValue, 10, 64); err == nil && n != 0 {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(f.DefValue, 64); err == nil && n != 0 {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// scalarSpec is how the API definitions represent a scalar Go type.
+type scalarSpec struct {
+	proto   string
+	openAPI string
+	format  string
+}
+
+// specScalar returns how the API definitions represent the input scalar type.
+//
+// Durations, times, network addresses, and the types parsing themselves from strings or text are strings.
+func specScalar(typ reflect.Type) (scalarSpec, bool) {
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		return scalarSpec{proto: "string", openAPI: "string", format: "duration"}, true
+	}
+	if typ == timeType || typ == ipType || typ == ipNetType {
+		return scalarSpec{proto: "string", openAPI: "string"}, true
+	}
+	if reflect.PtrTo(typ).Implements(reflect.TypeOf((*pflag.Value)(nil)).Elem()) || isText(typ) {
+		return scalarSpec{proto: "string", openAPI: "string"}, true
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return scalarSpec{proto: "string", openAPI: "string"}, true
+	case reflect.Bool:
+		return scalarSpec{proto: "bool", openAPI: "boolean"}, true
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return scalarSpec{proto: "int32", openAPI: "integer", format: "int32"}, true
+	case reflect.Int, reflect.Int64:
+		return scalarSpec{proto: "int64", openAPI: "integer", format: "int64"}, true
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return scalarSpec{proto: "uint32", openAPI: "integer", format: "int32"}, true
+	case reflect.Uint, reflect.Uint64:
+		return scalarSpec{proto: "uint64", openAPI: "integer", format: "int64"}, true
+	case reflect.Float32:
+		return scalarSpec{proto: "float", openAPI: "number", format: "float"}, true
+	case reflect.Float64:
+		return scalarSpec{proto: "double", openAPI: "number", format: "double"}, true
+	}
+
+	return scalarSpec{}, false
+}
+
+// specComment returns the comment describing the input flag in the API definitions.
+func specComment(f *pflag.Flag) string {
+	parts := []string{}
+	if f.Usage != "" {
+		parts = append(parts, f.Usage)
+	}
+	notes := []string{}
+	if _, required := f.Annotations[cobra.BashCompOneRequiredFlag]; required {
+		notes = append(notes, "required")
+	}
+	if isSecretFlag(f) {
+		notes = append(notes, "secret")
+	} else if f.DefValue != "" && f.DefValue != "[]" && f.DefValue != "0" && f.DefValue != "false" && f.DefValue != "0s" {
+		notes = append(notes, "default "+f.DefValue)
+	}
+	if len(notes) > 0 {
+		parts = append(parts, "("+strings.Join(notes, ", ")+")")
+	}
+
+	return strings.Join(parts, " ")
+}
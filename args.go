@@ -0,0 +1,67 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+)
+
+// argsField is a field collecting the positional arguments of a command.
+type argsField struct {
+	path string
+	// mode is the value of the flagargs tag of the field
+	mode string
+}
+
+// defineArgs records the input field as the one collecting the positional arguments, as its flagargs tag asks.
+//
+// The "true" value collects all of them.
+// The "passthrough" value also stops the flag parsing at the first positional argument,
+// so that the flags following it (even unknown ones) stay in the arguments, as written.
+// The unknown flags preceding it are still errors, as pflag cannot tell whether they take a value:
+// users pass them after the first positional argument, or after "--".
+// The "dash" value only collects the arguments following "--", that are never parsed.
+func defineArgs(c *cobra.Command, f reflect.StructField, path string) (bool, error) {
+	tag := f.Tag.Get("flagargs")
+	if tag == "" || tag == "false" {
+		return false, nil
+	}
+	if f.Type != reflect.TypeOf([]string{}) {
+		return false, fmt.Errorf("invalid flagargs tag on %s: only []string fields can hold the arguments", path)
+	}
+	switch tag {
+	case "true", "dash":
+	case "passthrough":
+		c.Flags().SetInterspersed(false)
+	default:
+		return false, fmt.Errorf("invalid flagargs tag on %s: %q is not one of true, passthrough, dash", path, tag)
+	}
+	s := getScope(c)
+	s.args = append(s.args, argsField{path: path, mode: tag})
+
+	return true, nil
+}
+
+// commandArgs returns the positional arguments of the command a field with the input flagargs mode collects.
+func commandArgs(c *cobra.Command, mode string) []string {
+	args := c.Flags().Args()
+	if mode != "dash" {
+		return args
+	}
+	if at := c.Flags().ArgsLenAtDash(); at >= 0 {
+		return args[at:]
+	}
+
+	return []string{}
+}
+
+// setArgs sets the fields collecting the positional arguments of the command.
+func setArgs(c *cobra.Command, opts options.Options) {
+	for _, a := range getScope(c).args {
+		if field, ok := fieldByPath(reflect.ValueOf(target(opts)), a.path); ok && field.CanSet() {
+			field.Set(reflect.ValueOf(append([]string{}, commandArgs(c, a.mode)...)))
+		}
+	}
+}
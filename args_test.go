@@ -0,0 +1,88 @@
+package autoflags
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+type wrapperOptions struct {
+	fixture
+
+	Context string
+	Rest    []string `flagargs:"passthrough"`
+}
+
+type argsOptions struct {
+	fixture
+
+	Verbose bool
+	Files   []string `flagargs:"true"`
+}
+
+type invalidArgsOptions struct {
+	fixture
+
+	Files string `flagargs:"true"`
+}
+
+func (suite *UnmarshalSuite) TestArgs() {
+	var got *wrapperOptions
+	c := &cobra.Command{Use: "kubectl", RunE: func(c *cobra.Command, args []string) error {
+		got = &wrapperOptions{}
+
+		return Unmarshal(c, got)
+	}}
+	suite.Require().Nil(Define(c, &wrapperOptions{}))
+	suite.Nil(c.Flags().Lookup("rest"))
+	c.SetArgs([]string{"--context", "prod", "get", "pods", "--watch", "-n", "kube-system"})
+	suite.Require().Nil(c.Execute())
+	suite.Equal("prod", got.Context)
+	suite.Equal([]string{"get", "pods", "--watch", "-n", "kube-system"}, got.Rest)
+
+	// The unknown flags before the first positional argument are errors, unless after "--"
+	c.SetOut(io.Discard)
+	c.SetErr(io.Discard)
+	c.SetArgs([]string{"--watch", "get", "pods"})
+	suite.EqualError(c.Execute(), "unknown flag: --watch")
+	c.SetArgs([]string{"--context", "prod", "--", "--watch", "get", "pods"})
+	suite.Require().Nil(c.Execute())
+	suite.Equal([]string{"--watch", "get", "pods"}, got.Rest)
+
+	c = &cobra.Command{Use: "cat"}
+	opts := &argsOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"a.txt", "--verbose", "b.txt"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.True(opts.Verbose)
+	suite.Equal([]string{"a.txt", "b.txt"}, opts.Files)
+
+	suite.EqualError(Define(&cobra.Command{Use: "invalid"}, &invalidArgsOptions{}), "invalid flagargs tag on files: only []string fields can hold the arguments")
+}
+
+type dashOptions struct {
+	fixture
+
+	Image   string
+	Command []string `flagargs:"dash"`
+}
+
+func (suite *UnmarshalSuite) TestDashArgs() {
+	c := &cobra.Command{Use: "run"}
+	opts := &dashOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{}, opts.Command)
+	provenance, err := Provenance(c)
+	suite.Require().Nil(err)
+	suite.Equal(SourceDefault, provenance["command"])
+
+	suite.Require().Nil(c.Flags().Parse([]string{"ignored", "--image", "alpine", "--", "sh", "-c", "--image=x"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("alpine", opts.Image)
+	suite.Equal([]string{"sh", "-c", "--image=x"}, opts.Command)
+	provenance, err = Provenance(c)
+	suite.Require().Nil(err)
+	suite.Equal(SourcePassthrough, provenance["command"])
+	suite.Equal("passthrough", provenance["command"].String())
+}
@@ -0,0 +1,95 @@
+package autoflags
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// AuditValue is the resolved value of a flag, along with where it comes from.
+//
+// Base is the source the command line increments of a count flag add to, if any.
+type AuditValue struct {
+	Flag     string      `json:"flag"`
+	Value    interface{} `json:"value,omitempty"`
+	Source   string      `json:"source"`
+	Base     string      `json:"base,omitempty"`
+	Redacted bool        `json:"redacted,omitempty"`
+}
+
+// AuditRecord describes the configuration a command runs with.
+type AuditRecord struct {
+	Time           time.Time    `json:"time"`
+	Command        string       `json:"command"`
+	User           string       `json:"user,omitempty"`
+	Host           string       `json:"host,omitempty"`
+	ConfigFile     string       `json:"config_file,omitempty"`
+	ConfigChecksum string       `json:"config_checksum,omitempty"`
+	Env            EnvSnapshot  `json:"env,omitempty"`
+	Values         []AuditValue `json:"values"`
+}
+
+// JSON encodes the audit record.
+func (r *AuditRecord) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// NewAuditRecord produces the audit record of the input command.
+//
+// The values of secret flags are redacted.
+// The config file checksum is the SHA-256 of its content.
+// The values are the ones the fields hold, and the environment is the snapshot Unmarshal took (see SnapshotEnv):
+// a record produced before any Unmarshal has the defaults and no environment.
+func NewAuditRecord(c *cobra.Command) (*AuditRecord, error) {
+	provenance, err := Provenance(c)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AuditRecord{
+		Time:    time.Now().UTC(),
+		Command: c.CommandPath(),
+		Env:     scopes[c].env,
+		Values:  []AuditValue{},
+	}
+	if u, err := user.Current(); err == nil {
+		res.User = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		res.Host = host
+	}
+	if file := viper.ConfigFileUsed(); file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't checksum the config file: %w", err)
+		}
+		sum := sha256.Sum256(content)
+		res.ConfigFile = file
+		res.ConfigChecksum = hex.EncodeToString(sum[:])
+	}
+
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		val := AuditValue{Flag: f.Name, Source: provenance[f.Name].String()}
+		if base, ok := scopes[c].bases[f.Name]; ok {
+			val.Base = base.String()
+		}
+		if isSecretFlag(f) {
+			val.Redacted = true
+		} else if field, ok := fieldValue(c, f.Name); ok {
+			val.Value = flagValue(f, field)
+		} else {
+			val.Value = f.Value.String()
+		}
+		res.Values = append(res.Values, val)
+	})
+
+	return res, nil
+}
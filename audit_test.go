@@ -0,0 +1,31 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestAuditRecord() {
+	file := suite.useConfigFile("config.yaml", "workers: 2\n")
+
+	c := &cobra.Command{Use: "audit"}
+	opts := &telemetryOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--token", "x"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	record, err := NewAuditRecord(c)
+	suite.Require().Nil(err)
+	suite.Equal("audit", record.Command)
+	suite.Equal(file, record.ConfigFile)
+	suite.Len(record.ConfigChecksum, 64)
+	suite.Equal([]AuditValue{
+		{Flag: "other", Value: "", Source: "default"},
+		{Flag: "timeout", Value: "0s", Source: "default"},
+		{Flag: "token", Source: "flag", Redacted: true},
+		{Flag: "workers", Value: 2, Source: "config"},
+	}, record.Values)
+
+	data, err := record.JSON()
+	suite.Require().Nil(err)
+	suite.NotContains(string(data), `"x"`)
+}
@@ -0,0 +1,53 @@
+package autoflagstest
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Keyring is a fake autoflags.Keyring storing the secrets in memory.
+//
+// It is safe for concurrent use.
+type Keyring struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewKeyring returns a fake keyring holding the input secrets, by "service/account".
+func NewKeyring(secrets map[string]string) *Keyring {
+	k := &Keyring{secrets: map[string]string{}}
+	for entry, secret := range secrets {
+		k.secrets[entry] = secret
+	}
+
+	return k
+}
+
+// Get returns the secret of the input service and account, or the empty string when missing.
+func (k *Keyring) Get(service, account string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.secrets[service+"/"+account], nil
+}
+
+// Set stores the secret of the input service and account.
+func (k *Keyring) Set(service, account, secret string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.secrets[service+"/"+account] = secret
+
+	return nil
+}
+
+// Delete removes the secret of the input service and account, failing when missing.
+func (k *Keyring) Delete(service, account string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.secrets[service+"/"+account]; !ok {
+		return fmt.Errorf("no secret %s/%s", service, account)
+	}
+	delete(k.secrets, service+"/"+account)
+
+	return nil
+}
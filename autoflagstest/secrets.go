@@ -0,0 +1,55 @@
+// Package autoflagstest provides utilities for testing the applications using autoflags.
+package autoflagstest
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SecretsProvider is a fake autoflags.SecretsProvider serving the secrets from memory.
+//
+// It counts the resolutions of each reference, so that tests can check the caching.
+// It is safe for concurrent use.
+type SecretsProvider struct {
+	mu      sync.Mutex
+	secrets map[string]string
+	calls   map[string]int
+}
+
+// NewSecretsProvider returns a fake provider serving the input secrets, by reference (eg., "vault://kv/app#token").
+func NewSecretsProvider(secrets map[string]string) *SecretsProvider {
+	p := &SecretsProvider{secrets: map[string]string{}, calls: map[string]int{}}
+	for ref, secret := range secrets {
+		p.secrets[ref] = secret
+	}
+
+	return p
+}
+
+// Resolve returns the secret of the input reference, failing when unknown.
+func (p *SecretsProvider) Resolve(ref string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls[ref]++
+	secret, ok := p.secrets[ref]
+	if !ok {
+		return "", fmt.Errorf("no secret %s", ref)
+	}
+
+	return secret, nil
+}
+
+// Set changes the secret of the input reference (eg., to simulate a rotation).
+func (p *SecretsProvider) Set(ref, secret string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.secrets[ref] = secret
+}
+
+// Calls returns how many times the input reference was resolved.
+func (p *SecretsProvider) Calls(ref string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.calls[ref]
+}
@@ -0,0 +1,274 @@
+package autoflags
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Blueprint is the definition of an options type, checked once and then applied to any number of commands.
+//
+// It serves the applications building a fresh command tree for each invocation (eg., tests, REPLs, gRPC-driven CLIs):
+// the definition errors surface when preparing the blueprint rather than at each invocation,
+// and Release drops the state of the discarded trees.
+type Blueprint struct {
+	typ        reflect.Type
+	defineOpts []DefineOption
+	// proto is the command the blueprint checked the definition on
+	proto *cobra.Command
+	// cloneable tells whether the flags of proto can be bound to other options, rather than defined again
+	cloneable bool
+}
+
+// NewBlueprint prepares the blueprint of the type of the input options, defined with the input define options.
+//
+// It returns the error Define would return for such options.
+func NewBlueprint(o options.Options, defineOpts ...DefineOption) (*Blueprint, error) {
+	proto := &cobra.Command{Use: "blueprint"}
+	if err := Define(proto, o, defineOpts...); err != nil {
+		Release(proto)
+
+		return nil, err
+	}
+
+	return &Blueprint{
+		typ:        reflect.TypeOf(o),
+		defineOpts: defineOpts,
+		proto:      proto,
+		cloneable:  isCloneable(proto, o),
+	}, nil
+}
+
+// Define defines the flags of the blueprint on the input command, binding them to the input options.
+//
+// The options must have the type the blueprint was prepared with.
+// It copies the flags, their annotations, and the state of the blueprint to the command, rather than walking the options again,
+// unless the command has flags from Define already, or the options define flags via hooks (eg., FlagDefiner, RegisterType, flagcustom).
+func (b *Blueprint) Define(c *cobra.Command, o options.Options) error {
+	if typ := reflect.TypeOf(o); typ != b.typ {
+		return fmt.Errorf("the blueprint of %s cannot define %s", typeName(reflect.New(b.typ).Elem().Interface()), typeName(o))
+	}
+	if _, defined := scopes[c]; defined || !b.cloneable {
+		return Define(c, o, b.defineOpts...)
+	}
+
+	return b.clone(c, o)
+}
+
+// isCloneable tells whether all the flags the input command defines for the input options can be bound to other options of the same type.
+func isCloneable(c *cobra.Command, o options.Options) bool {
+	typ := reflect.TypeOf(o)
+	if _, ok := o.(options.DynamicOptions); ok || typ.Kind() != reflect.Ptr {
+		return false
+	}
+	s := getScope(c)
+	probe := reflect.New(typ.Elem())
+	res := true
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		path, ok := s.flagPath(f.Name)
+		if !ok {
+			// Virtual flags hold their own values
+			return
+		}
+		sf, _ := structFieldByPath(typ, path)
+		custom, _ := strconv.ParseBool(sf.Tag.Get("flagcustom"))
+		_, registered := registeredTypes[sf.Type]
+		if custom || registered || isFlagDefiner(sf.Type) {
+			// The hooks define the flags bound to the fields they get
+			res = false
+
+			return
+		}
+		field, ok := fieldByPath(probe, path)
+		if !ok {
+			res = false
+
+			return
+		}
+		if _, ok := bindValue(f.Value, field); !ok {
+			res = false
+		}
+	})
+
+	return res
+}
+
+// clone defines the flags of the blueprint on the input command, binding copies of them to the input options.
+func (b *Blueprint) clone(c *cobra.Command, o options.Options) error {
+	var err error
+	b.proto.Flags().VisitAll(func(f *pflag.Flag) {
+		if err == nil {
+			err = checkCollision(c, f.Name, f.Shorthand)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	ps := getScope(b.proto)
+	s := getScope(c)
+	s.order = append([]Source(nil), ps.order...)
+	s.strictDurations, s.partial, s.saturate = ps.strictDurations, ps.partial, ps.saturate
+	s.skipped = append([]SkippedField(nil), ps.skipped...)
+	s.args = append([]argsField(nil), ps.args...)
+	s.options = []options.Options{o}
+	for path, name := range ps.paths {
+		s.paths[path] = name
+	}
+	for path, alias := range ps.aliases {
+		s.aliases[path] = alias
+		s.viper.RegisterAlias(path, alias)
+	}
+	for name, typ := range ps.types {
+		s.types[name] = typ
+	}
+	for name, def := range ps.defaults {
+		s.defaults[name] = def
+		s.viper.SetDefault(name, def)
+	}
+	for name, origin := range ps.origins {
+		s.origins[name] = origin
+	}
+	for name, order := range ps.fieldOrders {
+		s.fieldOrders[name] = order
+	}
+	for group, gate := range ps.gates {
+		s.gates[group] = gate
+	}
+	for name, hook := range ps.hooks {
+		s.hooks[name] = hook
+	}
+	for _, a := range s.args {
+		if a.mode == "passthrough" {
+			c.Flags().SetInterspersed(false)
+		}
+	}
+
+	opts := reflect.ValueOf(target(o))
+	b.proto.Flags().VisitAll(func(f *pflag.Flag) {
+		clone := *f
+		clone.Changed = false
+		clone.Annotations = map[string][]string{}
+		for key, val := range f.Annotations {
+			clone.Annotations[key] = append([]string(nil), val...)
+		}
+		if path, ok := s.flagPath(f.Name); ok {
+			field, _ := fieldByPath(opts, path)
+			clone.Value, _ = bindValue(f.Value, field)
+		} else {
+			fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+			fs.String("value", f.DefValue, "")
+			clone.Value = fs.Lookup("value").Value
+		}
+		c.Flags().AddFlag(&clone)
+		if complete, ok := b.proto.GetFlagCompletionFunc(f.Name); ok {
+			_ = c.RegisterFlagCompletionFunc(f.Name, complete)
+		}
+	})
+
+	s.viper.BindPFlags(c.Flags())
+	bindEnv(s.viper, c)
+	defineExamples(c, o)
+	cfg := &defineConfig{}
+	for _, opt := range b.defineOpts {
+		opt(cfg)
+	}
+	if cfg.synopsis {
+		defineSynopsis(c)
+	}
+	setUsage(c)
+
+	return nil
+}
+
+// boundValue is a flag value of autoflags able to bind a copy of itself to another field.
+type boundValue interface {
+	bind(field reflect.Value) pflag.Value
+}
+
+// definePflagValue defines the "value" flag on the input flag set, bound to the input field, with the pflag value of the input type.
+//
+// It returns false for the types of the values Define does not get from pflag.
+func definePflagValue(fs *pflag.FlagSet, typ string, ptr unsafe.Pointer) bool {
+	switch typ {
+	case "bool":
+		fs.BoolVar((*bool)(ptr), "value", *(*bool)(ptr), "")
+	case "string":
+		fs.StringVar((*string)(ptr), "value", *(*string)(ptr), "")
+	case "int":
+		fs.IntVar((*int)(ptr), "value", *(*int)(ptr), "")
+	case "count":
+		fs.CountVar((*int)(ptr), "value", "")
+	case "uint":
+		fs.UintVar((*uint)(ptr), "value", *(*uint)(ptr), "")
+	case "uint8":
+		fs.Uint8Var((*uint8)(ptr), "value", *(*uint8)(ptr), "")
+	case "int64":
+		fs.Int64Var((*int64)(ptr), "value", *(*int64)(ptr), "")
+	case "duration":
+		fs.DurationVar((*time.Duration)(ptr), "value", *(*time.Duration)(ptr), "")
+	case "stringSlice":
+		fs.StringSliceVar((*[]string)(ptr), "value", *(*[]string)(ptr), "")
+	case "intSlice":
+		fs.IntSliceVar((*[]int)(ptr), "value", *(*[]int)(ptr), "")
+	case "int64Slice":
+		fs.Int64SliceVar((*[]int64)(ptr), "value", *(*[]int64)(ptr), "")
+	case "uintSlice":
+		fs.UintSliceVar((*[]uint)(ptr), "value", *(*[]uint)(ptr), "")
+	case "float64Slice":
+		fs.Float64SliceVar((*[]float64)(ptr), "value", *(*[]float64)(ptr), "")
+	case "boolSlice":
+		fs.BoolSliceVar((*[]bool)(ptr), "value", *(*[]bool)(ptr), "")
+	case "ipSlice":
+		fs.IPSliceVar((*[]net.IP)(ptr), "value", *(*[]net.IP)(ptr), "")
+	case "stringToString":
+		fs.StringToStringVar((*map[string]string)(ptr), "value", *(*map[string]string)(ptr), "")
+	default:
+		return false
+	}
+
+	return true
+}
+
+// bindValue returns a copy of the input flag value bound to the input field, if it knows how to.
+func bindValue(v pflag.Value, field reflect.Value) (pflag.Value, bool) {
+	if bv, ok := v.(boundValue); ok {
+		return bv.bind(field), true
+	}
+	if reflect.TypeOf(v) == field.Addr().Type() {
+		return field.Addr().Interface().(pflag.Value), true
+	}
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	if !definePflagValue(fs, v.Type(), unsafe.Pointer(field.UnsafeAddr())) {
+		return nil, false
+	}
+
+	return fs.Lookup("value").Value, true
+}
+
+// Flags returns the names of the flags the blueprint defines, in lexicographical order.
+func (b *Blueprint) Flags() []string {
+	names := []string{}
+	b.proto.Flags().VisitAll(func(f *pflag.Flag) {
+		names = append(names, f.Name)
+	})
+
+	return names
+}
+
+// Release forgets the state autoflags keeps for the commands of the tree rooted at the input command.
+//
+// Call it once a command tree is discarded, so that rebuilding the tree for each invocation does not accumulate state.
+// Inspect, Provenance, and Unmarshal do not work on released commands anymore.
+func Release(c *cobra.Command) {
+	for _, cmd := range walkTree(c) {
+		delete(scopes, cmd)
+	}
+}
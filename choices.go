@@ -0,0 +1,98 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagChoicesAnnotation = "___flagchoices"
+)
+
+// isChoosable tells whether the fields of the input type can have the flagchoices tag.
+func isChoosable(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// defineChoices validates the flagchoices tag and annotates the flag with it.
+//
+// It also appends the choices to the usage of the flag, and completes the flag with them.
+func defineChoices(c *cobra.Command, f reflect.StructField, path, name string) error {
+	tag := f.Tag.Get("flagchoices")
+	if tag == "" {
+		return nil
+	}
+	if !isChoosable(f.Type) {
+		return fmt.Errorf("invalid flagchoices tag on %s: only string and number fields, or slices of them, can have it", path)
+	}
+	choices := parseFlagList(tag)
+	if len(choices) == 0 {
+		return fmt.Errorf("invalid flagchoices tag on %s: no choices", path)
+	}
+	_ = c.Flags().SetAnnotation(name, FlagChoicesAnnotation, choices)
+	flag := c.Flags().Lookup(name)
+	flag.Usage = strings.TrimSpace(fmt.Sprintf("%s (%s)", flag.Usage, strings.Join(choices, ", ")))
+	_ = c.RegisterFlagCompletionFunc(name, func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return choices, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return nil
+}
+
+// checkChoices errors when the fields of the options tagged with flagchoices have values other than their choices.
+//
+// The defaults are not checked, so that the fields can default to their zero values (eg., the empty string).
+func checkChoices(c *cobra.Command, opts interface{}) error {
+	s := getScope(c)
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		choices, ok := f.Annotations[FlagChoicesAnnotation]
+		if err != nil || !ok || winner(lookupSources(c, f), s.precedenceOf(f.Name)) == SourceDefault {
+			return
+		}
+		path, ok := s.flagPath(f.Name)
+		if !ok {
+			return
+		}
+		field, ok := fieldByPath(reflect.ValueOf(opts), path)
+		if !ok {
+			return
+		}
+		values := []reflect.Value{field}
+		if field.Kind() == reflect.Slice || field.Kind() == reflect.Array {
+			values = values[:0]
+			for i := 0; i < field.Len(); i++ {
+				values = append(values, field.Index(i))
+			}
+		}
+	check:
+		for _, val := range values {
+			got := fmt.Sprint(val.Interface())
+			for _, choice := range choices {
+				if got == choice {
+					continue check
+				}
+			}
+			err = fmt.Errorf("%s must be one of %s, got %s (from %s)", f.Name, strings.Join(choices, ", "), got, origin(c, f))
+
+			return
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,50 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type choicesOptions struct {
+	fixture
+
+	Env     string   `flagchoices:"dev, staging, prod" flagdescr:"deployment environment" flagenv:"true"`
+	Level   int      `flagchoices:"1,2,3"`
+	Regions []string `flagchoices:"eu,us"`
+}
+
+type badChoicesOptions struct {
+	fixture
+
+	Debug bool `flagchoices:"true,false"`
+}
+
+func (suite *UnmarshalSuite) TestChoices() {
+	suite.useConfigFile("config.yaml", "regions: [eu, us]\n")
+	suite.T().Setenv("ENV", "staging")
+
+	c := &cobra.Command{Use: "choices"}
+	opts := &choicesOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("deployment environment (dev, staging, prod)", c.Flags().Lookup("env").Usage)
+	suite.Equal("(1, 2, 3)", c.Flags().Lookup("level").Usage)
+	complete, ok := c.GetFlagCompletionFunc("env")
+	suite.Require().True(ok)
+	completions, directive := complete(c, nil, "")
+	suite.Equal([]string{"dev", "staging", "prod"}, completions)
+	suite.Equal(cobra.ShellCompDirectiveNoFileComp, directive)
+
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("staging", opts.Env)
+	suite.Equal(0, opts.Level)
+	suite.Equal([]string{"eu", "us"}, opts.Regions)
+
+	suite.T().Setenv("ENV", "qa")
+	suite.EqualError(Unmarshal(c, opts), "env must be one of dev, staging, prod, got qa (from env ENV)")
+	suite.T().Setenv("ENV", "prod")
+	suite.Require().Nil(c.Flags().Parse([]string{"--level", "4"}))
+	suite.EqualError(Unmarshal(c, opts), "level must be one of 1, 2, 3, got 4 (from flag)")
+	suite.Require().Nil(c.Flags().Parse([]string{"--level", "2", "--regions", "ap"}))
+	suite.ErrorContains(Unmarshal(c, opts), "regions must be one of eu, us, got ap")
+
+	suite.EqualError(Define(&cobra.Command{Use: "bad"}, &badChoicesOptions{}), "invalid flagchoices tag on debug: only string and number fields, or slices of them, can have it")
+}
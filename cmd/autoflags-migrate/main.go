@@ -0,0 +1,68 @@
+// Command autoflags-migrate converts cobra flag definitions or kong structs into autoflags options structs.
+//
+//	autoflags-migrate --from cobra --package cmd cmd/serve.go > cmd/serve_options.go
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/leodido/autoflags"
+	"github.com/leodido/autoflags/migrate"
+	"github.com/spf13/cobra"
+)
+
+type migrateOptions struct {
+	From    string `default:"cobra" flagdescr:"the kind of the input definitions (cobra or kong)"`
+	Package string `default:"main" flagdescr:"the package of the generated code"`
+}
+
+func (o *migrateOptions) Attach(c *cobra.Command) {}
+
+func main() {
+	opts := &migrateOptions{}
+	c := &cobra.Command{
+		Use:   "autoflags-migrate [file]...",
+		Short: "Generate autoflags options structs from existing flag definitions",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := autoflags.Unmarshal(c, opts); err != nil {
+				return err
+			}
+			convert := migrate.FromCobra
+			switch opts.From {
+			case "cobra":
+			case "kong":
+				convert = migrate.FromKong
+			default:
+				return fmt.Errorf("unknown input kind %q", opts.From)
+			}
+			structs := []migrate.Struct{}
+			for _, file := range args {
+				src, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				res, err := convert(src)
+				if err != nil {
+					return fmt.Errorf("%s: %w", file, err)
+				}
+				structs = append(structs, res...)
+			}
+			out, err := migrate.Generate(opts.Package, structs)
+			if err != nil {
+				return err
+			}
+			_, err = c.OutOrStdout().Write(out)
+
+			return err
+		},
+	}
+	if err := autoflags.Define(c, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := c.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+package autoflags
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// HiddenCommandsKey is the config key listing the commands to hide from the help
+	HiddenCommandsKey = "commands.hidden"
+	// DisabledCommandsKey is the config key listing the commands to disable
+	DisabledCommandsKey = "commands.disabled"
+)
+
+// ToggleCommands hides or disables the subcommands of the input root command that the loaded config file lists.
+//
+// Commands are named by their path below the root (eg., "db migrate"):
+//
+//	commands:
+//	  hidden: [experimental]
+//	  disabled: ["db migrate"]
+//
+// The <PREFIX>HIDDEN_COMMANDS and <PREFIX>DISABLED_COMMANDS environment variables, comma separated, replace the lists of the config file
+// (when an env prefix is set).
+// Disabled commands, along with their subcommands, are hidden and fail when run.
+// It errors on the names matching no command, so that typos do not go unnoticed.
+//
+// Call it once the config file is loaded, before the command runs (eg., from a persistent pre-run hook).
+// Cobra prints the help of the commands without a run function before such hooks, so that when disabled from there they stay hidden but keep printing it.
+func ToggleCommands(rootC *cobra.Command) error {
+	commands := map[string]*cobra.Command{}
+	for _, c := range walkTree(rootC) {
+		if c != rootC {
+			commands[strings.TrimPrefix(c.CommandPath(), rootC.CommandPath()+" ")] = c
+		}
+	}
+	lookup := func(key, env string) ([]*cobra.Command, error) {
+		res := []*cobra.Command{}
+		for _, name := range commandToggles(key, env) {
+			c, ok := commands[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown command %q in %s", name, key)
+			}
+			res = append(res, c)
+		}
+
+		return res, nil
+	}
+
+	hidden, err := lookup(HiddenCommandsKey, "HIDDEN_COMMANDS")
+	if err != nil {
+		return err
+	}
+	disabled, err := lookup(DisabledCommandsKey, "DISABLED_COMMANDS")
+	if err != nil {
+		return err
+	}
+	for _, c := range hidden {
+		c.Hidden = true
+	}
+	for _, c := range disabled {
+		for _, sub := range walkTree(c) {
+			disableCommand(sub)
+		}
+	}
+
+	return nil
+}
+
+// commandToggles returns the names of the commands the input config key lists, or the input environment variable does.
+func commandToggles(key, env string) []string {
+	names := []string{}
+	if val, ok := lookupConfig(key, false); ok {
+		names = cast.ToStringSlice(val)
+	}
+	if prefix != "" {
+		if val, ok := os.LookupEnv(prefix + env); ok {
+			names = strings.Split(val, ",")
+		}
+	}
+	res := []string{}
+	for _, name := range names {
+		if name = strings.Join(strings.Fields(name), " "); name != "" {
+			res = append(res, name)
+		}
+	}
+
+	return res
+}
+
+// disableCommand hides the input command and makes it fail when run.
+//
+// It fails from its pre-run hook, so that cobra does not complain about its required flags first,
+// and it becomes runnable, so that cobra does not print its help instead.
+func disableCommand(c *cobra.Command) {
+	disabled := func(c *cobra.Command, args []string) error {
+		return fmt.Errorf("command %q is disabled", c.CommandPath())
+	}
+	c.Hidden = true
+	c.Args = cobra.ArbitraryArgs
+	c.PreRun, c.PreRunE = nil, disabled
+	c.Run, c.RunE = nil, disabled
+	c.PostRun, c.PostRunE = nil, nil
+}
@@ -0,0 +1,80 @@
+package autoflags
+
+import (
+	"bytes"
+
+	"github.com/leodido/autoflags/setup"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestToggleCommands() {
+	newTree := func() (*cobra.Command, *bool) {
+		ran := false
+		run := func(c *cobra.Command, args []string) { ran = true }
+		root := &cobra.Command{Use: "app"}
+		db := &cobra.Command{Use: "db"}
+		migrate := &cobra.Command{Use: "migrate", Run: run}
+		migrate.Flags().String("target", "", "the target version")
+		_ = migrate.MarkFlagRequired("target")
+		db.AddCommand(migrate)
+		root.AddCommand(db, &cobra.Command{Use: "experimental", Run: run}, &cobra.Command{Use: "serve", Run: run})
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+
+		return root, &ran
+	}
+
+	file := suite.useConfigFile("config.yaml", "commands:\n  hidden: [experimental]\n  disabled: [db]\n")
+	root, ran := newTree()
+	suite.Require().Nil(ToggleCommands(root))
+	suite.True(root.Commands()[1].Hidden)
+	suite.True(root.Commands()[0].Commands()[0].Hidden)
+	suite.False(root.Commands()[2].Hidden)
+	root.SetArgs([]string{"db", "migrate"})
+	suite.EqualError(root.Execute(), `command "app db migrate" is disabled`)
+	suite.False(*ran)
+	root.SetArgs([]string{"db"})
+	suite.EqualError(root.Execute(), `command "app db" is disabled`)
+	root.SetArgs([]string{"experimental"})
+	suite.Require().Nil(root.Execute())
+	suite.True(*ran)
+	suite.True(ValidateConfig(root).OK())
+
+	SetEnvPrefix("APP")
+	suite.T().Cleanup(func() { prefix = "" })
+	suite.T().Setenv("APP_DISABLED_COMMANDS", "serve, db  migrate")
+	root, _ = newTree()
+	suite.Require().Nil(ToggleCommands(root))
+	suite.False(root.Commands()[0].Hidden)
+	suite.True(root.Commands()[0].Commands()[0].Hidden)
+	suite.True(root.Commands()[2].Hidden)
+
+	suite.T().Setenv("APP_DISABLED_COMMANDS", "srve")
+	suite.EqualError(ToggleCommands(root), `unknown command "srve" in commands.disabled`)
+
+	suite.T().Setenv("APP_DISABLED_COMMANDS", "experimental")
+	viper.Reset()
+	root, ran = newTree()
+	suite.Require().Nil(Setup(root, setup.Options{Config: &setup.Config{Commands: true}}))
+	root.SetArgs([]string{"experimental", "--config", file})
+	suite.EqualError(root.Execute(), `command "app experimental" is disabled`)
+	suite.False(*ran)
+
+	suite.T().Setenv("APP_DISABLED_COMMANDS", "db migrate")
+	viper.Reset()
+	root, ran = newTree()
+	suite.Require().Nil(Setup(root, setup.Options{Config: &setup.Config{Commands: true}}))
+	root.SetArgs([]string{"db", "migrate", "--config", file})
+	suite.EqualError(root.Execute(), `command "app db migrate" is disabled`)
+	suite.False(*ran)
+
+	// The commands without a run function stay so until disabled
+	suite.False(root.Commands()[0].Runnable())
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetArgs([]string{"db", "--config", file})
+	suite.Require().Nil(root.Execute())
+	suite.Contains(out.String(), "help for db")
+	suite.NotContains(out.String(), "app db [flags]")
+}
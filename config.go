@@ -1,18 +1,31 @@
 package autoflags
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/pelletier/go-toml/v2/unstable"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
+// configSettings holds the settings of the config file loaded by UseConfig.
+var configSettings map[string]interface{}
+
 func UseConfig(readWhen func() bool) (bool, string) {
 	str := ""
 	ret := false
 	if readWhen == nil || readWhen() {
 		// If a config file is found, read it in
-		if err := viper.ReadInConfig(); err == nil {
-			str = fmt.Sprintf("Using config file: %s", viper.ConfigFileUsed())
+		err := readInConfig()
+		if err == nil {
+			str = fmt.Sprintf("Using config file: %s", MaskPath(viper.ConfigFileUsed()))
+			if currentContext != "" {
+				str += fmt.Sprintf(" (context %s)", currentContext)
+			}
 			ret = true
 		} else {
 			if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -20,10 +33,169 @@ func UseConfig(readWhen func() bool) (bool, string) {
 				str = "Running without a configuration file"
 			} else {
 				// Config file was found but another error was produced
-				str = fmt.Sprintf("Error running with config file: %s", viper.ConfigFileUsed())
+				str = fmt.Sprintf("Error running with config file: %s: %s", MaskPath(viper.ConfigFileUsed()), err)
 			}
 		}
 	}
 
 	return ret, str
 }
+
+// readInConfig has viper find and read the config file, then reads it on its own too.
+func readInConfig() error {
+	err := viper.ReadInConfig()
+	if _, ok := err.(viper.ConfigFileNotFoundError); !ok && viper.ConfigFileUsed() != "" {
+		// Encrypted config files only parse once decrypted
+		err = readConfigFile(viper.ConfigFileUsed())
+	}
+
+	return err
+}
+
+// readConfigFile reads the input config file, decrypting it when needed, into the global viper.
+//
+// It also keeps its settings on their own, so that they can be merged into the viper instance of each command at unmarshalling time.
+func readConfigFile(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	return readConfig(content, strings.TrimPrefix(filepath.Ext(file), "."), false)
+}
+
+// readConfig reads the input config content, decrypting it when needed, then applies the selected context.
+//
+// The global viper reads the content too when it could not (ie., it is encrypted or it is forced to).
+func readConfig(content []byte, format string, force bool) error {
+	content, encrypted, err := decryptConfig(content)
+	if err != nil {
+		return err
+	}
+	if encrypted || force {
+		viper.SetConfigType(format)
+		if err := viper.ReadConfig(bytes.NewReader(content)); err != nil {
+			return err
+		}
+	}
+
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return err
+	}
+	configSettings = v.AllSettings()
+	projectFile, projectSettings = "", nil
+
+	return applyContext()
+}
+
+// lookupConfig returns the value the loaded config file has for the input (dotted) key.
+//
+// Maps are considered values rather than sections only when requested, for the flags holding maps.
+func lookupConfig(key string, maps bool) (interface{}, bool) {
+	return lookupSetting(configSettings, key, maps)
+}
+
+// lookupSetting returns the value the input settings have for the input (dotted) key, as lookupConfig does.
+func lookupSetting(settings map[string]interface{}, key string, maps bool) (interface{}, bool) {
+	var cur interface{} = settings
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	if _, ok := cur.(map[string]interface{}); ok && !maps {
+		return nil, false
+	}
+
+	return cur, cur != nil
+}
+
+// configPosition returns the line and the column of the input (dotted) key in the input config file.
+//
+// It supports YAML, JSON, and TOML files.
+func configPosition(file, key string) (int, int, bool) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return 0, 0, false
+	}
+	path := strings.Split(strings.ToLower(key), ".")
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml", ".json":
+		return yamlPosition(content, path)
+	case ".toml":
+		return tomlPosition(content, path)
+	}
+
+	return 0, 0, false
+}
+
+// yamlPosition finds the input key path in the input YAML (or JSON) content.
+func yamlPosition(content []byte, path []string) (int, int, bool) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(content, doc); err != nil || len(doc.Content) == 0 {
+		return 0, 0, false
+	}
+	node := doc.Content[0]
+	var key *yaml.Node
+	for _, part := range path {
+		if node.Kind != yaml.MappingNode {
+			return 0, 0, false
+		}
+		found := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.ToLower(node.Content[i].Value) == part {
+				key, node = node.Content[i], node.Content[i+1]
+				found = true
+
+				break
+			}
+		}
+		if !found {
+			return 0, 0, false
+		}
+	}
+
+	return key.Line, key.Column, true
+}
+
+// tomlPosition finds the input key path in the input TOML content.
+//
+// Keys within inline tables are not found.
+func tomlPosition(content []byte, path []string) (int, int, bool) {
+	want := strings.Join(path, ".")
+	p := &unstable.Parser{}
+	p.Reset(content)
+	table := []string{}
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = table[:0]
+			for it := expr.Key(); it.Next(); {
+				table = append(table, strings.ToLower(string(it.Node().Data)))
+			}
+		case unstable.KeyValue:
+			parts := append([]string{}, table...)
+			var first *unstable.Node
+			for it := expr.Key(); it.Next(); {
+				if first == nil {
+					first = it.Node()
+				}
+				parts = append(parts, strings.ToLower(string(it.Node().Data)))
+			}
+			if strings.Join(parts, ".") == want {
+				pos := p.Shape(first.Raw).Start
+
+				return pos.Line, pos.Column, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
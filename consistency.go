@@ -0,0 +1,82 @@
+package autoflags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagOverrideAnnotation = "___flagoverride"
+)
+
+// markOverrides marks the input flags, by name or by struct path, as intentionally differing across commands.
+func markOverrides(c *cobra.Command, names []string) error {
+	return annotateFlags(c, names, FlagOverrideAnnotation, "couldn't mark %s as overridden")
+}
+
+// sharedFlag is how the first command defining a flag of some options struct defines it.
+type sharedFlag struct {
+	command string
+	defval  string
+	group   string
+	envs    string
+}
+
+func newSharedFlag(c *cobra.Command, f *pflag.Flag) sharedFlag {
+	res := sharedFlag{command: c.CommandPath(), defval: f.DefValue, envs: strings.Join(f.Annotations[FlagEnvsAnnotation], ", ")}
+	if group, ok := f.Annotations[FlagGroupAnnotation]; ok {
+		res.group = group[0]
+	}
+
+	return res
+}
+
+// CheckSharedOptions walks a fully assembled command tree and reports the drift of the options structs shared by many commands.
+//
+// The flags an options struct defines must have the same defaults, groups, and environment variables on every command,
+// as the first command defining them does, unless marked via WithOverrides.
+// Like Lint, it is meant to run in CI once every command has been defined.
+func CheckSharedOptions(root *cobra.Command) []error {
+	errs := []error{}
+	// Maps the options types to their flags, by name
+	baselines := map[string]map[string]sharedFlag{}
+	for _, c := range walkTree(root) {
+		s, ok := scopes[c]
+		if !ok {
+			continue
+		}
+		c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			typ, ok := s.origins[f.Name]
+			if !ok {
+				return
+			}
+			if _, overridden := f.Annotations[FlagOverrideAnnotation]; overridden {
+				return
+			}
+			if baselines[typ] == nil {
+				baselines[typ] = map[string]sharedFlag{}
+			}
+			base, ok := baselines[typ][f.Name]
+			if !ok {
+				baselines[typ][f.Name] = newSharedFlag(c, f)
+
+				return
+			}
+			cur := newSharedFlag(c, f)
+			if cur.defval != base.defval {
+				errs = append(errs, fmt.Errorf("%s: flag --%s of %s defaults to %q, but to %q on %s", cur.command, f.Name, typ, cur.defval, base.defval, base.command))
+			}
+			if cur.group != base.group {
+				errs = append(errs, fmt.Errorf("%s: flag --%s of %s is in group %q, but in group %q on %s", cur.command, f.Name, typ, cur.group, base.group, base.command))
+			}
+			if cur.envs != base.envs {
+				errs = append(errs, fmt.Errorf("%s: flag --%s of %s binds env [%s], but [%s] on %s", cur.command, f.Name, typ, cur.envs, base.envs, base.command))
+			}
+		})
+	}
+
+	return errs
+}
@@ -0,0 +1,255 @@
+package autoflags
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ContextsKey is the config key holding the named contexts
+	ContextsKey = "contexts"
+	// CurrentContextKey is the config key holding the name of the context in use
+	CurrentContextKey = "current-context"
+	// ContextEnvFileKey is the key of a context holding the path of its env file
+	ContextEnvFileKey = "env-file"
+	// DefaultContextFlag is the name of the flag SetupContexts adds
+	DefaultContextFlag = "context"
+)
+
+var (
+	// contextFlag is the flag selecting the context, once SetupContexts adds it
+	contextFlag *pflag.Flag
+	// currentContext is the name of the context applied to the loaded config file
+	currentContext string
+)
+
+// SetupContexts adds the persistent --context flag selecting a named context of the config file to the input command.
+//
+// A config file can group settings into named contexts (like kubectl does), overlaying the top-level ones:
+//
+//	current-context: staging
+//	log-level: info
+//	contexts:
+//	  staging:
+//	    server:
+//	      host: staging.example.com
+//	  prod:
+//	    env-file: prod.env
+//	    server:
+//	      host: example.com
+//
+// The context in use is the one the --context flag names, then the one the <PREFIX>CONTEXT environment variable names
+// (when an env prefix is set), then the current-context key of the config file.
+// Its env-file, relative to the config file, sets the environment variables it lists that the process does not already set.
+//
+// Without SetupContexts, the contexts and current-context keys are plain settings of the config file.
+// Since the flag must be parsed by the time the config file is loaded, call UseConfig from cobra.OnInitialize or a PersistentPreRunE.
+func SetupContexts(rootC *cobra.Command) error {
+	if rootC.PersistentFlags().Lookup(DefaultContextFlag) != nil {
+		return fmt.Errorf("flag --%s already exists", DefaultContextFlag)
+	}
+	rootC.PersistentFlags().String(DefaultContextFlag, "", "the named context of the config file to use")
+	contextFlag = rootC.PersistentFlags().Lookup(DefaultContextFlag)
+
+	return nil
+}
+
+// CurrentContext returns the name of the context applied to the loaded config file, if any.
+func CurrentContext() string {
+	return currentContext
+}
+
+// selectedContext returns the name of the context to use, given the current-context of the config file.
+func selectedContext(current string) string {
+	if contextFlag != nil && contextFlag.Changed {
+		return contextFlag.Value.String()
+	}
+	if prefix != "" {
+		if name := os.Getenv(prefix + "CONTEXT"); name != "" {
+			return name
+		}
+	}
+
+	return current
+}
+
+// splitContexts separates the named contexts out of the input settings.
+//
+// It returns the top-level settings without the context keys, the contexts by name, and the current context of the settings.
+func splitContexts(settings map[string]interface{}) (map[string]interface{}, map[string]map[string]interface{}, string) {
+	base := map[string]interface{}{}
+	for key, val := range settings {
+		base[key] = val
+	}
+	current, _ := base[CurrentContextKey].(string)
+	delete(base, CurrentContextKey)
+	contexts := map[string]map[string]interface{}{}
+	if all, ok := base[ContextsKey].(map[string]interface{}); ok {
+		for name, val := range all {
+			if section, ok := val.(map[string]interface{}); ok {
+				contexts[name] = section
+			}
+		}
+	}
+	delete(base, ContextsKey)
+
+	return base, contexts, current
+}
+
+// applyContext overlays the selected context onto the settings of the loaded config file, and loads its env file.
+//
+// Without SetupContexts, the context keys are plain settings.
+func applyContext() error {
+	currentContext = ""
+	if contextFlag == nil {
+		return nil
+	}
+	base, contexts, current := splitContexts(configSettings)
+	configSettings = base
+	name := selectedContext(current)
+	if name == "" {
+		return nil
+	}
+	section, ok := contexts[name]
+	if !ok {
+		return fmt.Errorf("unknown context %q", name)
+	}
+	currentContext = name
+
+	section = mergeSettings(map[string]interface{}{}, section)
+	if envFile, ok := section[ContextEnvFileKey].(string); ok {
+		delete(section, ContextEnvFileKey)
+		if !filepath.IsAbs(envFile) && viper.ConfigFileUsed() != "" {
+			envFile = filepath.Join(filepath.Dir(viper.ConfigFileUsed()), envFile)
+		}
+		if err := loadEnvFile(envFile); err != nil {
+			return fmt.Errorf("context %q: %w", name, err)
+		}
+	}
+	configSettings = mergeSettings(configSettings, section)
+
+	return viper.MergeConfigMap(section)
+}
+
+// mergeSettings deeply merges the src settings into the dst ones, returning dst.
+func mergeSettings(dst, src map[string]interface{}) map[string]interface{} {
+	for key, val := range src {
+		if nested, ok := val.(map[string]interface{}); ok {
+			if existing, ok := dst[key].(map[string]interface{}); ok {
+				dst[key] = mergeSettings(mergeSettings(map[string]interface{}{}, existing), nested)
+
+				continue
+			}
+			dst[key] = mergeSettings(map[string]interface{}{}, nested)
+
+			continue
+		}
+		dst[key] = val
+	}
+
+	return dst
+}
+
+// loadEnvFile sets the environment variables listed in the input dotenv file, unless already set.
+//
+// Lines are KEY=VALUE pairs, optionally prefixed by "export", with values optionally quoted.
+// Blank lines and the ones starting with # are skipped.
+func loadEnvFile(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("couldn't read the env file: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", MaskPath(file), n)
+		}
+		val = strings.TrimSpace(val)
+		if strings.HasPrefix(val, `"`) {
+			if val, err = strconv.Unquote(val); err != nil {
+				return fmt.Errorf("%s:%d: invalid quoted value", MaskPath(file), n)
+			}
+		} else if len(val) > 1 && strings.HasPrefix(val, "'") && strings.HasSuffix(val, "'") {
+			val = val[1 : len(val)-1]
+		}
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, val); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// UseContext makes the named context the current one of the config file at the input path.
+//
+// The context must exist. Comments of the file are not preserved, and encrypted files are not supported.
+func UseContext(file, name string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if _, encrypted, err := decryptConfig(content); err != nil || encrypted {
+		return fmt.Errorf("couldn't update %s: encrypted config files are not supported", MaskPath(file))
+	}
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return err
+	}
+	_, contexts, _ := splitContexts(v.AllSettings())
+	if _, ok := contexts[name]; !ok {
+		names := make([]string, 0, len(contexts))
+		for n := range contexts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("unknown context %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	v.Set(CurrentContextKey, name)
+
+	return v.WriteConfigAs(file)
+}
+
+// NewUseContextCmd returns a "use-context" command persisting the named context as the current one of the config file in use.
+//
+// Add it to a "config" command to get the kubectl-like "config use-context NAME".
+func NewUseContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-context NAME",
+		Short: "Set the current context of the config file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			file := viper.ConfigFileUsed()
+			if file == "" {
+				return fmt.Errorf("no config file in use")
+			}
+			if err := UseContext(file, args[0]); err != nil {
+				return err
+			}
+			c.Printf("Switched to context %q\n", args[0])
+
+			return nil
+		},
+	}
+}
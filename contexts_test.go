@@ -0,0 +1,65 @@
+package autoflags
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestContexts() {
+	suite.T().Setenv("CONTEXT_TOKEN", "")
+	suite.Require().Nil(os.Unsetenv("CONTEXT_TOKEN"))
+	suite.T().Cleanup(func() {
+		contextFlag = nil
+		currentContext = ""
+	})
+	content := "current-context: staging\nserver:\n  port: 1\ncontexts:\n  staging:\n    server:\n      port: 3\n  prod:\n    env-file: prod.env\n    server:\n      port: 2\n"
+
+	// Without SetupContexts, the context keys are plain settings
+	suite.useConfigFile("config.yaml", "current-context: missing\n"+content[len("current-context: staging\n"):])
+	suite.Empty(CurrentContext())
+	suite.Equal("missing", configSettings[CurrentContextKey])
+	suite.Contains(configSettings, ContextsKey)
+	c := &cobra.Command{Use: "plain"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(1, opts.Server.Port)
+
+	root := &cobra.Command{Use: "app"}
+	suite.Require().Nil(SetupContexts(root))
+	file := suite.useConfigFile("config.yaml", content)
+	suite.Equal("staging", CurrentContext())
+	c = &cobra.Command{Use: "staging"}
+	opts = &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(3, opts.Server.Port)
+	suite.Empty(ValidateConfig(c).Unknown)
+
+	suite.Require().Nil(os.WriteFile(filepath.Join(filepath.Dir(file), "prod.env"), []byte("# prod\nexport CONTEXT_TOKEN=\"s3cr3t\"\n"), 0o600))
+	suite.Require().Nil(root.ParseFlags([]string{"--context", "prod"}))
+	found, msg := UseConfig(nil)
+	suite.Require().True(found)
+	suite.Equal("Using config file: "+file+" (context prod)", msg)
+	c = &cobra.Command{Use: "prod"}
+	opts = &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(2, opts.Server.Port)
+	suite.Equal(2, viper.GetInt("server.port"))
+	suite.Equal("s3cr3t", os.Getenv("CONTEXT_TOKEN"))
+
+	suite.Require().Nil(root.ParseFlags([]string{"--context", "dev"}))
+	found, msg = UseConfig(nil)
+	suite.False(found)
+	suite.Equal("Error running with config file: "+file+`: unknown context "dev"`, msg)
+
+	suite.EqualError(UseContext(file, "dev"), `unknown context "dev" (available: prod, staging)`)
+	suite.Require().Nil(UseContext(file, "prod"))
+	contextFlag.Changed = false
+	suite.Require().Nil(readConfigFile(file))
+	suite.Equal("prod", CurrentContext())
+}
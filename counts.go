@@ -0,0 +1,91 @@
+package autoflags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// countState tracks the command line increments of a count flag.
+//
+// The flag shares its memory with the options field, so it counts up from the value of the last unmarshal.
+type countState struct {
+	increments int
+	last       int
+}
+
+// isCountFlag tells whether the input flag is a count flag (ie., `type:"count"`).
+func isCountFlag(f *pflag.Flag) bool {
+	return f.Value.Type() == "count"
+}
+
+// countOf returns the current value of the input count flag.
+func countOf(f *pflag.Flag) int {
+	n, _ := strconv.Atoi(f.Value.String())
+
+	return n
+}
+
+// mergeCounts makes the command line increments of the count flags add to the value they get from the environment or the config file.
+//
+// For example, APP_VERBOSE=2 plus -v resolves to 3.
+// The increments add to the value of the highest-precedence source among env and config.
+// When a custom precedence order ranks such a source above the command line, its value wins as is.
+func mergeCounts(c *cobra.Command, settings map[string]interface{}) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if !isCountFlag(f) || !f.Changed {
+			return
+		}
+		state, ok := s.counts[f.Name]
+		if !ok {
+			state = &countState{}
+			s.counts[f.Name] = state
+		}
+		state.increments += countOf(f) - state.last
+		state.last = countOf(f)
+
+		values := lookupSources(c, f)
+		order := s.precedenceOf(f.Name)
+		if winner(values, order) != SourceFlag {
+			return
+		}
+		delete(values, SourceFlag)
+		base := winner(values, order)
+		if base == SourceDefault {
+			visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+				m[key] = state.increments
+			})
+
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(values[base])))
+		if err != nil {
+			// Leave the invalid value to the decoding errors
+			return
+		}
+		s.bases[f.Name] = base
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			m[key] = n + state.increments
+		})
+	})
+}
+
+// recordCounts stores the values the count flags got from the last unmarshal.
+func recordCounts(c *cobra.Command) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if !isCountFlag(f) {
+			return
+		}
+		state, ok := s.counts[f.Name]
+		if !ok {
+			state = &countState{}
+			s.counts[f.Name] = state
+		}
+		state.last = countOf(f)
+	})
+}
@@ -0,0 +1,50 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestCountFlags() {
+	suite.T().Setenv("VERBOSE", "2")
+
+	c := &cobra.Command{Use: "count"}
+	opts := &countOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal([]string{"VERBOSE"}, c.Flags().Lookup("verbose").Annotations[FlagEnvsAnnotation])
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(2, opts.Verbose)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"-vv"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(4, opts.Verbose)
+	// Unmarshalling again does not add the increments twice
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(4, opts.Verbose)
+
+	provenance, err := Provenance(c)
+	suite.Require().Nil(err)
+	suite.Equal(SourceFlag, provenance["verbose"])
+	record, err := NewAuditRecord(c)
+	suite.Require().Nil(err)
+	suite.Contains(record.Values, AuditValue{Flag: "verbose", Value: 4, Source: "flag", Base: "env"})
+}
+
+func (suite *UnmarshalSuite) TestCountFlagsFromConfig() {
+	suite.useConfigFile("config.yaml", "verbose: 3\n")
+
+	c := &cobra.Command{Use: "count"}
+	opts := &countOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(3, opts.Verbose)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"-v"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(4, opts.Verbose)
+}
+
+type countOptions struct {
+	fixture
+
+	Verbose int `flagshort:"v" flagenv:"true" type:"count"`
+}
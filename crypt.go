@@ -0,0 +1,166 @@
+package autoflags
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Decrypter decrypts config files that are encrypted at rest.
+//
+// Applications register implementations via RegisterDecrypter: AgeDecrypter and SOPSDecrypter, or their own.
+type Decrypter interface {
+	// Detect tells whether the content is encrypted in the format the decrypter handles.
+	Detect(content []byte) bool
+	// Decrypt returns the plain text content.
+	//
+	// The key comes from the <PREFIX>CONFIG_KEY or <PREFIX>CONFIG_KEY_FILE environment variables, when set, and it is nil otherwise.
+	Decrypt(content []byte, key []byte) ([]byte, error)
+}
+
+var decrypters = []Decrypter{}
+
+// RegisterDecrypter makes UseConfig transparently decrypt the config files the input decrypter detects.
+func RegisterDecrypter(d Decrypter) {
+	decrypters = append(decrypters, d)
+}
+
+// AgeDecrypter decrypts the age encrypted config files by running the age command.
+//
+// The key is the age identity (ie., the AGE-SECRET-KEY-1... line of a key file), and it is required.
+var AgeDecrypter Decrypter = commandDecrypter{name: "age", detect: IsAgeEncrypted}
+
+// SOPSDecrypter decrypts the SOPS encrypted config files by running the sops command.
+//
+// The key, when set, is the age identity SOPS decrypts with (as SOPS_AGE_KEY),
+// otherwise SOPS looks for the keys as usual (eg., in its key file, or via the KMS of the cloud provider).
+var SOPSDecrypter Decrypter = commandDecrypter{name: "sops", detect: IsSOPSEncrypted}
+
+// commandDecrypter is a Decrypter running the command line tool of an encryption format.
+type commandDecrypter struct {
+	name   string
+	detect func(content []byte) bool
+}
+
+func (d commandDecrypter) Detect(content []byte) bool {
+	return d.detect(content)
+}
+
+func (d commandDecrypter) Decrypt(content []byte, key []byte) ([]byte, error) {
+	var cmd *exec.Cmd
+	switch d.name {
+	case "age":
+		if len(key) == 0 {
+			return nil, fmt.Errorf("no key to decrypt with: set %sCONFIG_KEY or %sCONFIG_KEY_FILE", prefix, prefix)
+		}
+		// The identity goes through a private file, keeping it off the arguments of the process
+		identity, err := privateFile("identity", append(key, '\n'))
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(identity)
+		cmd = exec.Command("age", "--decrypt", "--identity", identity)
+		cmd.Stdin = bytes.NewReader(content)
+	case "sops":
+		format := "yaml"
+		if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+			format = "json"
+		}
+		// The content goes through a private file, since sops only reads files (and /dev/stdin is not everywhere)
+		file, err := privateFile("config*."+format, content)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(file)
+		cmd = exec.Command("sops", "--decrypt", "--input-type", format, "--output-type", format, file)
+		if len(key) > 0 {
+			cmd.Env = append(os.Environ(), "SOPS_AGE_KEY="+string(key))
+		}
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := runCommand(cmd); err != nil {
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// privateFile writes the input content to a new temporary file only its owner can read, returning its path.
+//
+// The caller removes it.
+func privateFile(pattern string, content []byte) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// IsAgeEncrypted tells whether the content is an age encrypted file, either binary or armored.
+func IsAgeEncrypted(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("age-encryption.org/")) ||
+		bytes.HasPrefix(bytes.TrimSpace(content), []byte("-----BEGIN AGE ENCRYPTED FILE-----"))
+}
+
+// IsSOPSEncrypted tells whether the content is a SOPS encrypted YAML or JSON document.
+func IsSOPSEncrypted(content []byte) bool {
+	str := string(content)
+
+	return strings.Contains(str, "ENC[") && (strings.Contains(str, "\nsops:") || strings.Contains(str, `"sops":`))
+}
+
+// decryptConfig returns the plain text content of the input config file.
+//
+// The boolean result tells whether the file was encrypted.
+func decryptConfig(content []byte) ([]byte, bool, error) {
+	for _, d := range decrypters {
+		if !d.Detect(content) {
+			continue
+		}
+		key, err := configKey()
+		if err != nil {
+			return nil, true, err
+		}
+		plain, err := d.Decrypt(content, key)
+		if err != nil {
+			return nil, true, fmt.Errorf("couldn't decrypt the config file: %w", err)
+		}
+
+		return plain, true, nil
+	}
+	if IsAgeEncrypted(content) || IsSOPSEncrypted(content) {
+		return nil, true, fmt.Errorf("the config file is encrypted but no decrypter handles it (eg., register AgeDecrypter or SOPSDecrypter)")
+	}
+
+	return content, false, nil
+}
+
+// configKey returns the key to decrypt config files with, if any.
+func configKey() ([]byte, error) {
+	if key := os.Getenv(prefix + "CONFIG_KEY"); key != "" {
+		return []byte(key), nil
+	}
+	if file := os.Getenv(prefix + "CONFIG_KEY_FILE"); file != "" {
+		key, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the config key: %w", err)
+		}
+
+		return bytes.TrimSpace(key), nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,106 @@
+package autoflags
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestEncryptedConfig() {
+	suite.T().Setenv("CONFIG_KEY", "k")
+	RegisterDecrypter(&base64Decrypter{})
+	suite.T().Cleanup(func() {
+		decrypters = []Decrypter{}
+	})
+	suite.useConfigFile("config.yaml", "B64:"+base64.StdEncoding.EncodeToString([]byte("server:\n  port: 5\n")))
+
+	c := &cobra.Command{Use: "encrypted"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(5, opts.Server.Port)
+	suite.Equal(5, viper.GetInt("server.port"))
+}
+
+func (suite *UnmarshalSuite) TestAgeDecrypter() {
+	// A fake age command checking the identity it gets
+	bin := suite.T().TempDir()
+	script := "#!/bin/sh\n[ \"$1 $2\" = \"--decrypt --identity\" ] || exit 1\nread -r id < \"$3\"\n[ \"$id\" = AGE-SECRET-KEY-1FAKE ] || exit 1\nprintf 'server:\\n  port: 6\\n'\n"
+	suite.Require().Nil(os.WriteFile(filepath.Join(bin, "age"), []byte(script), 0o700))
+	suite.T().Setenv("PATH", bin)
+	RegisterDecrypter(AgeDecrypter)
+	suite.T().Cleanup(func() {
+		decrypters = []Decrypter{}
+	})
+
+	file := filepath.Join(suite.T().TempDir(), "config.yaml")
+	suite.Require().Nil(os.WriteFile(file, []byte("-----BEGIN AGE ENCRYPTED FILE-----\n"), 0o600))
+	suite.T().Setenv("CONFIG_KEY", "")
+	suite.EqualError(readConfigFile(file), "couldn't decrypt the config file: no key to decrypt with: set CONFIG_KEY or CONFIG_KEY_FILE")
+
+	suite.T().Setenv("CONFIG_KEY", "AGE-SECRET-KEY-1FAKE")
+	suite.Require().Nil(readConfigFile(file))
+	suite.T().Cleanup(func() {
+		viper.Reset()
+		configSettings = nil
+	})
+	c := &cobra.Command{Use: "age"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(6, opts.Server.Port)
+}
+
+func (suite *UnmarshalSuite) TestSOPSDecrypter() {
+	// A fake sops command checking the private file it gets, and recording its path
+	bin := suite.T().TempDir()
+	seen := filepath.Join(bin, "seen")
+	script := "#!/bin/sh\n[ \"$1 $2 $3 $4 $5\" = \"--decrypt --input-type yaml --output-type yaml\" ] || exit 1\n" +
+		"[ \"$SOPS_AGE_KEY\" = AGE-SECRET-KEY-1FAKE ] || exit 1\n" +
+		"case \"$(ls -l \"$6\")\" in -rw-------*) ;; *) exit 1 ;; esac\n" +
+		"grep -q 'ENC\\[' \"$6\" || exit 1\n" +
+		"printf '%s' \"$6\" > " + seen + "\nprintf 'server:\\n  port: 8\\n'\n"
+	suite.Require().Nil(os.WriteFile(filepath.Join(bin, "sops"), []byte(script), 0o700))
+	suite.T().Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	suite.T().Setenv("CONFIG_KEY", "AGE-SECRET-KEY-1FAKE")
+
+	content := []byte("server:\n    port: ENC[AES256_GCM,data:abc,type:int]\nsops:\n    version: 3.8.1\n")
+	suite.Require().True(SOPSDecrypter.Detect(content))
+	plain, err := SOPSDecrypter.Decrypt(content, []byte("AGE-SECRET-KEY-1FAKE"))
+	suite.Require().Nil(err)
+	suite.Equal("server:\n  port: 8\n", string(plain))
+
+	file, err := os.ReadFile(seen)
+	suite.Require().Nil(err)
+	_, err = os.Stat(string(file))
+	suite.True(os.IsNotExist(err))
+}
+
+func (suite *UnmarshalSuite) TestEncryptedConfigWithoutDecrypter() {
+	file := filepath.Join(suite.T().TempDir(), "config.yaml")
+	suite.Require().Nil(os.WriteFile(file, []byte("-----BEGIN AGE ENCRYPTED FILE-----\n"), 0o600))
+	viper.SetConfigFile(file)
+	suite.T().Cleanup(viper.Reset)
+
+	found, _ := UseConfig(nil)
+	suite.False(found)
+}
+
+type base64Decrypter struct{}
+
+func (d *base64Decrypter) Detect(content []byte) bool {
+	return strings.HasPrefix(string(content), "B64:")
+}
+
+func (d *base64Decrypter) Decrypt(content []byte, key []byte) ([]byte, error) {
+	if string(key) != "k" {
+		return nil, errors.New("wrong key")
+	}
+
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(string(content), "B64:"))
+}
@@ -0,0 +1,178 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// DefaultDryRunFlag is the name of the flag SetupDebug adds when DebugOptions does not set one.
+const DefaultDryRunFlag = "dry-run-config"
+
+// DebugOptions customizes the flags added by SetupDebug.
+type DebugOptions struct {
+	// DryRunFlag is the name of the flag printing the resolved invocation instead of running the command
+	DryRunFlag string
+	// Hooks are the decode hooks the dry run unmarshals the options with, that should be the ones the commands use
+	Hooks []mapstructure.DecodeHookFunc
+}
+
+// SetupDebug adds the debugging flags to the input root command and to all of its subcommands.
+//
+// The dry-run flag resolves the options of the running command, prints them as the equivalent command line,
+// and exits before the command runs.
+// It only sees the options attached to the command by reference.
+// The dry run unmarshals the options with the decode hooks of DebugOptions, and gives the command back its hooks once done.
+// Setup the debugging flags once every command has been added, after setting their persistent pre-run hooks, that SetupDebug wraps.
+// The root command can be a virtual one (see MarkVirtualRoot).
+func SetupDebug(rootC *cobra.Command, opts DebugOptions) error {
+	if !isRoot(rootC) {
+		return fmt.Errorf("SetupDebug must be called on the root command")
+	}
+	name := opts.DryRunFlag
+	if name == "" {
+		name = DefaultDryRunFlag
+	}
+	if rootC.PersistentFlags().Lookup(name) != nil {
+		return fmt.Errorf("flag --%s is already defined", name)
+	}
+	rootC.PersistentFlags().Bool(name, false, "print the resolved options as a command line and exit")
+
+	restores := map[*cobra.Command]func(){}
+	wrapPersistentPreRun(rootC, func(c *cobra.Command, args []string) error {
+		// Give back the hooks a previous dry run replaced, if it stopped before restoring them
+		if restore, ok := restores[c]; ok {
+			restore()
+		}
+		if dryRun, _ := c.Flags().GetBool(name); !dryRun {
+			return nil
+		}
+
+		// Replace the hooks of the command so that nothing runs but the printing, until it is done
+		preRun, preRunE := c.PreRun, c.PreRunE
+		run, runE := c.Run, c.RunE
+		postRun, postRunE := c.PostRun, c.PostRunE
+		restores[c] = func() {
+			c.PreRun, c.PreRunE = preRun, preRunE
+			c.Run, c.RunE = run, runE
+			c.PostRun, c.PostRunE = postRun, postRunE
+			delete(restores, c)
+		}
+		c.PreRun, c.PreRunE = nil, nil
+		c.Run = nil
+		c.PostRun = nil
+		c.RunE = func(c *cobra.Command, args []string) error {
+			if err := printInvocation(c, args, opts.Hooks); err != nil {
+				restores[c]()
+
+				return err
+			}
+
+			return nil
+		}
+		c.PostRunE = func(c *cobra.Command, args []string) error {
+			restores[c]()
+
+			return nil
+		}
+
+		return nil
+	})
+
+	return nil
+}
+
+// printInvocation prints the command line equivalent to the options of the input command, unmarshalling them first.
+func printInvocation(c *cobra.Command, args []string, hooks []mapstructure.DecodeHookFunc) error {
+	if _, ok := scopes[c]; ok {
+		if err := UnmarshalAll(c, hooks...); err != nil {
+			return err
+		}
+	}
+	line, err := Invocation(c, args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.OutOrStdout(), line)
+
+	return nil
+}
+
+// Invocation returns the command line equivalent to the resolved options of the input command.
+//
+// It lists the flags whose values do not come from the defaults, with the values of the secret ones masked, followed by the input arguments.
+// The values are the ones the fields hold: before Unmarshal, the flags that any source sets show with their defaults.
+func Invocation(c *cobra.Command, args []string) (string, error) {
+	provenance, err := Provenance(c)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{c.CommandPath()}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		field, ok := fieldValue(c, f.Name)
+		if !ok || provenance[f.Name] == SourceDefault {
+			return
+		}
+		if isSecretFlag(f) {
+			parts = append(parts, fmt.Sprintf("--%s=%s", f.Name, shellQuote("***")))
+
+			return
+		}
+		parts = append(parts, invocationFlags(f, flagValue(f, field))...)
+	})
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// invocationFlags returns the command line flags setting the input value, repeating the flag for each element of slices and maps.
+func invocationFlags(f *pflag.Flag, value interface{}) []string {
+	res := []string{}
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Bool:
+		if val.Bool() {
+			return []string{"--" + f.Name}
+		}
+	case reflect.Slice:
+		for i := 0; i < val.Len(); i++ {
+			res = append(res, fmt.Sprintf("--%s=%s", f.Name, shellQuote(fmt.Sprint(val.Index(i).Interface()))))
+		}
+
+		return res
+	case reflect.Map:
+		entries := []string{}
+		iter := val.MapRange()
+		for iter.Next() {
+			entries = append(entries, fmt.Sprintf("%v=%v", iter.Key().Interface(), iter.Value().Interface()))
+		}
+		sort.Strings(entries)
+		for _, entry := range entries {
+			res = append(res, fmt.Sprintf("--%s=%s", f.Name, shellQuote(entry)))
+		}
+
+		return res
+	}
+
+	return []string{fmt.Sprintf("--%s=%s", f.Name, shellQuote(fmt.Sprint(value)))}
+}
+
+var shellSafeRe = regexp.MustCompile(`^[a-zA-Z0-9_./:=@%+,-]+$`)
+
+// shellQuote quotes the input string for POSIX shells, when needed.
+func shellQuote(s string) string {
+	if shellSafeRe.MatchString(s) {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
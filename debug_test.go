@@ -0,0 +1,55 @@
+package autoflags
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestDryRun() {
+	suite.useConfigFile("config.yaml", "timeout: 1m30s\nhosts: [a, b]\n")
+	suite.T().Setenv("TOKEN", "s3cr3t")
+
+	ran, preRan := false, false
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "serve", RunE: func(c *cobra.Command, args []string) error {
+		ran = true
+
+		return nil
+	}, PersistentPreRun: func(c *cobra.Command, args []string) {
+		preRan = true
+	}}
+	root.AddCommand(sub)
+	suite.Require().Nil(Define(sub, &dryRunOptions{}))
+	upper := func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() == reflect.String && to.Kind() == reflect.String {
+			return strings.ToUpper(data.(string)), nil
+		}
+
+		return data, nil
+	}
+	suite.Require().Nil(SetupDebug(root, DebugOptions{Hooks: []mapstructure.DecodeHookFunc{upper}}))
+	suite.EqualError(SetupDebug(root, DebugOptions{}), "flag --dry-run-config is already defined")
+	suite.EqualError(SetupDebug(sub, DebugOptions{}), "SetupDebug must be called on the root command")
+
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetArgs([]string{"serve", "--dry-run-config", "--verbose", "--name", "my app", "arg"})
+	suite.Require().Nil(root.Execute())
+	suite.False(ran)
+	suite.True(preRan, "the own hook of the subcommand runs too")
+	suite.Equal("app serve --hosts=A --hosts=B --name='MY APP' --timeout=1m30s --token='***' --verbose arg\n", out.String())
+
+	// The command gets its hooks back once printed
+	suite.NotNil(sub.RunE)
+	suite.Nil(sub.PostRunE)
+	suite.Require().Nil(root.PersistentFlags().Set(DefaultDryRunFlag, "false"))
+	out.Reset()
+	root.SetArgs([]string{"serve"})
+	suite.Require().Nil(root.Execute())
+	suite.True(ran)
+	suite.Empty(out.String())
+}
@@ -10,34 +10,115 @@ import (
 
 	"github.com/leodido/autoflags/options"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"github.com/spf13/pflag"
 )
 
-func Define(c *cobra.Command, o options.Options, exclusions ...string) {
-	v := viper.New()
-	if reuse, ok := vipers[c]; !ok {
-		vipers[c] = v
-	} else {
-		v = reuse
+// pflagValueType is the type of the values of the flags parsing themselves.
+var pflagValueType = reflect.TypeOf((*pflag.Value)(nil)).Elem()
+
+// Define generates the flags of the input options onto the command.
+//
+// The names of the flags to exclude, that it took before returning an error, go via WithExclusions.
+// With WithStrictTypes, it errors on the fields it cannot define a flag for, including the flagcustom ones without their hook.
+func Define(c *cobra.Command, o options.Options, defineOpts ...DefineOption) error {
+	cfg := &defineConfig{}
+	for _, opt := range defineOpts {
+		opt(cfg)
+	}
+	s := getScope(c)
+
+	if cfg.precedence != nil {
+		order, err := completePrecedence(cfg.precedence)
+		if err != nil {
+			return err
+		}
+		s.order = order
+	}
+	if cfg.strictDurations {
+		s.strictDurations = true
+	}
+	if cfg.partial {
+		s.partial = true
+	}
+	if cfg.saturate {
+		s.saturate = true
 	}
 
 	// Map flags to exclude to the current command
 	ignores := map[string]string{}
-	for _, flag := range exclusions {
+	for _, flag := range cfg.exclusions {
 		ignores[strings.ToLower(flag)] = c.Name()
 	}
 
 	// Define the flags from struct
-	define(c, o, "", "", ignores, false, false)
+	existing := map[string]bool{}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		existing[f.Name] = true
+	})
+	for _, p := range cfg.withoutAliases {
+		if !hasStructPath(reflect.TypeOf(target(o)), p) {
+			return fmt.Errorf("couldn't disable the aliases of %s: no such struct path", p)
+		}
+	}
+	if cfg.strictTypes {
+		// Fail before defining any flag
+		if unsupported := unsupportedFields(c, reflect.TypeOf(target(o)), "", ignores); len(unsupported) > 0 {
+			return fmt.Errorf("unsupported field types: %s", strings.Join(unsupported, ", "))
+		}
+	}
+	s.withoutAliases = cfg.withoutAliases
+	err := define(c, target(o), "", "", ignores, false, false)
+	s.withoutAliases = nil
+	if err != nil {
+		return err
+	}
+	if err := defineVirtuals(c, o); err != nil {
+		return err
+	}
+	// Track which options struct defined the new flags
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if existing[f.Name] {
+			return
+		}
+		s.origins[f.Name] = typeName(o)
+		// Apply the slice options to the new string slices
+		if typ, ok := s.types[f.Name]; ok && typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String {
+			if cfg.trimSlices {
+				_ = c.Flags().SetAnnotation(f.Name, FlagTrimAnnotation, []string{"true"})
+			}
+			if cfg.dropEmptySlices {
+				_ = c.Flags().SetAnnotation(f.Name, FlagDropEmptyAnnotation, []string{"true"})
+			}
+		}
+	})
+	if err := checkRelatedNames(c, existing); err != nil {
+		return err
+	}
+	s.options = append(s.options, o)
+	if err := lockDown(c, cfg.locked); err != nil {
+		return err
+	}
+	if err := markOverrides(c, cfg.overrides); err != nil {
+		return err
+	}
 	// Bind flag values to struct field values
-	v.BindPFlags(c.Flags())
+	s.viper.BindPFlags(c.Flags())
 	// Bind environment
-	bindEnv(v, c)
+	bindEnv(s.viper, c)
+	// Let the gates control the required flags of their groups
+	applyGates(c)
+	defineExamples(c, o)
+	if cfg.synopsis {
+		defineSynopsis(c)
+	}
 	// Generate the usage message
 	setUsage(c)
+
+	return nil
 }
 
-func define(c *cobra.Command, o interface{}, startingGroup string, structPath string, exclusions map[string]string, defineEnv bool, mandatory bool) {
+func define(c *cobra.Command, o interface{}, startingGroup string, structPath string, exclusions map[string]string, defineEnv bool, mandatory bool) error {
+	s := getScope(c)
 	val := getValue(o)
 	// TODO: double-check this one
 	// if !val.IsValid() {
@@ -60,22 +141,42 @@ func define(c *cobra.Command, o interface{}, startingGroup string, structPath st
 		}
 
 		if cname, ok := exclusions[strings.TrimPrefix(strings.TrimPrefix(path, "-"), "-")]; ok && c.Name() == cname {
+			s.skip(path, f.Type.String(), SkipExcluded)
+
 			continue
 		}
 
 		ignore, _ := strconv.ParseBool(f.Tag.Get("flagignore"))
 		if ignore {
+			s.skip(path, f.Type.String(), SkipIgnored)
+
+			continue
+		}
+
+		isArgs, err := defineArgs(c, f, path)
+		if err != nil {
+			return err
+		}
+		if isArgs {
+			continue
+		}
+
+		if feature := f.Tag.Get("flagfeature"); feature != "" && !features.Enabled(feature) {
+			s.skip(path, f.Type.String(), SkipFeatureDisabled)
+
 			continue
 		}
 
 		short := f.Tag.Get("flagshort")
 		alias := f.Tag.Get("flag")
 		if cname, ok := exclusions[alias]; ok && c.Name() == cname {
+			s.skip(path, f.Type.String(), SkipExcluded)
+
 			continue
 		}
 		defval := f.Tag.Get("default") // TODO: flagdefault?
-		descr := f.Tag.Get("flagdescr")
-		group := f.Tag.Get("flaggroup")
+		descr := expandDescr(f.Tag.Get("flagdescr"))
+		group := groupTitle(c, f.Tag.Get("flaggroup"))
 		if startingGroup != "" {
 			group = startingGroup
 		}
@@ -83,13 +184,32 @@ func define(c *cobra.Command, o interface{}, startingGroup string, structPath st
 		envs, defineEnv := getEnv(f, defineEnv, path, alias)
 		mandatory := isMandatory(f) || mandatory
 
+		if !isNested(f.Type) {
+			if err := checkCollision(c, name, short); err != nil {
+				return err
+			}
+			if err := checkAliasCollision(c, path, name); err != nil {
+				return err
+			}
+		}
+
+		var order []Source
+		if tag := f.Tag.Get("flagprecedence"); tag != "" {
+			var err error
+			if order, err = parsePrecedence(tag); err != nil {
+				return fmt.Errorf("invalid flagprecedence tag on %s: %w", path, err)
+			}
+		}
+
 		// Flags with custom definition hooks
 		custom, _ := strconv.ParseBool(f.Tag.Get("flagcustom"))
-		if custom && f.Type.Kind() != reflect.Struct {
+		if custom && !isNested(f.Type) {
 			hookName := fmt.Sprintf("Define%s", f.Name)
 			if structPtr := getValuePtr(o); structPtr.IsValid() {
 				hookFunc := structPtr.MethodByName(hookName)
 				if !hookFunc.IsValid() {
+					s.skip(path, f.Type.String(), SkipMissingHook)
+
 					continue
 				}
 				hookFunc.Call([]reflect.Value{
@@ -99,98 +219,290 @@ func define(c *cobra.Command, o interface{}, startingGroup string, structPath st
 					getValue(short),
 					getValue(descr),
 				})
-				inferDecodeHooks(c, name, f.Type.String())
+				inferDecodeHooks(c, name, f.Type)
 
 				goto definition_done
 			}
 		}
 
+		// Fields whose types are registered
+		if hook, ok := registeredTypes[f.Type]; ok {
+			hook(c, f.Type.String(), name, short, descr)
+			inferDecodeHooks(c, name, f.Type)
+
+			goto definition_done
+		}
+
+		// Fields whose types define their own flag
+		if isFlagDefiner(f.Type) {
+			field.Addr().Interface().(FlagDefiner).DefineFlag(c, name, short, descr)
+			inferDecodeHooks(c, name, f.Type)
+
+			goto definition_done
+		}
+
+		// Slices of structs parsed from repeated key-value flags
+		if tag := f.Tag.Get("flagtuple"); tag != "" {
+			if f.Type.Kind() != reflect.Slice {
+				return fmt.Errorf("invalid flagtuple tag on %s: only slices of structs can be tuples", path)
+			}
+			t, err := parseTuple(tag, f.Type.Elem())
+			if err != nil {
+				return fmt.Errorf("invalid flagtuple tag on %s: %w", path, err)
+			}
+			c.Flags().VarP(newTupleSliceValue(field, t), name, short, descr)
+			s.hooks[t.name()] = t.hook()
+			_ = c.Flags().SetAnnotation(name, FlagDecodeHookAnnotation, []string{t.name()})
+
+			goto definition_done
+		}
+
+		// Fields whose types know how to parse themselves
+		if !isNested(f.Type) {
+			if ref, ok := field.Addr().Interface().(pflag.Value); ok {
+				c.Flags().VarP(ref, name, short, descr)
+				inferDecodeHooks(c, name, f.Type)
+
+				goto definition_done
+			}
+		}
+
+		// Timestamps parsed with the layout of the field
+		if f.Type == timeType {
+			if err := defineTime(c, f, (*time.Time)(unsafe.Pointer(field.UnsafeAddr())), path, name, short, descr); err != nil {
+				return err
+			}
+
+			goto definition_done
+		}
+
+		// Network addresses
+		if defineNetwork(c, field, name, short, descr) {
+			inferDecodeHooks(c, name, f.Type)
+
+			goto definition_done
+		}
+
+		// Fields whose types unmarshal themselves from text
+		if defineText(c, field, name, short, descr) {
+			goto definition_done
+		}
+
 		// TODO: complete type switch
 		switch f.Type.Kind() {
 		case reflect.Struct:
 			// NOTE > field.Interface() doesn't work because it actually returns a copy of the object wrapping the interface
-			define(c, field.Addr().Interface(), group, path, exclusions, defineEnv, mandatory)
+			if err := define(c, field.Addr().Interface(), group, path, exclusions, defineEnv, mandatory); err != nil {
+				return err
+			}
 
 			continue
 
 		case reflect.Bool:
-			val := field.Interface().(bool)
+			val := field.Bool()
 			ref := (*bool)(unsafe.Pointer(field.UnsafeAddr()))
 			c.Flags().BoolVarP(ref, name, short, val, descr)
 
 		case reflect.String:
-			val := field.Interface().(string)
+			val := field.String()
 			ref := (*string)(unsafe.Pointer(field.UnsafeAddr()))
 			c.Flags().StringVarP(ref, name, short, val, descr)
 
 		case reflect.Int:
-			val := field.Interface().(int)
+			val := int(field.Int())
 			ref := (*int)(unsafe.Pointer(field.UnsafeAddr()))
 			if f.Tag.Get("type") == "count" {
 				c.Flags().CountVarP(ref, name, short, descr)
-
-				continue
+			} else {
+				c.Flags().IntVarP(ref, name, short, val, descr)
 			}
-			c.Flags().IntVarP(ref, name, short, val, descr)
 
 		case reflect.Uint:
-			val := field.Interface().(uint)
+			val := uint(field.Uint())
 			ref := (*uint)(unsafe.Pointer(field.UnsafeAddr()))
 			c.Flags().UintVarP(ref, name, short, val, descr)
 
 		case reflect.Uint8:
-			val := field.Interface().(uint8)
+			val := uint8(field.Uint())
 			ref := (*uint8)(unsafe.Pointer(field.UnsafeAddr()))
 			c.Flags().Uint8VarP(ref, name, short, val, descr)
 
 		case reflect.Slice:
 			if f.Type.Elem().Kind() == reflect.String {
-				val := field.Interface().([]string)
 				ref := (*[]string)(unsafe.Pointer(field.UnsafeAddr()))
-				c.Flags().StringSliceVarP(ref, name, short, val, descr)
+				val := *ref
+				sep := f.Tag.Get("flagsep")
+				hasSep := sep != ""
+				split, err := strconv.ParseBool(f.Tag.Get("flagsplit"))
+				if err == nil && !split {
+					if hasSep {
+						return fmt.Errorf("invalid flagsplit tag on %s: it conflicts with flagsep", path)
+					}
+					hasSep, sep = true, ""
+				}
+				if hasSep {
+					c.Flags().VarP(newStringSliceValue(ref, sep), name, short, descr)
+					_ = c.Flags().SetAnnotation(name, FlagSeparatorAnnotation, []string{sep})
+				} else {
+					c.Flags().StringSliceVarP(ref, name, short, val, descr)
+				}
+			} else if !defineSlice(c, field, name, short, descr) {
+				s.skip(path, f.Type.String(), SkipUnsupported)
+
+				continue
+			}
+
+		case reflect.Map:
+			if f.Type.Key().Kind() != reflect.String || f.Type.Elem().Kind() != reflect.String {
+				s.skip(path, f.Type.String(), SkipUnsupported)
+
+				continue
 			}
+			ref := (*map[string]string)(unsafe.Pointer(field.UnsafeAddr()))
+			c.Flags().StringToStringVarP(ref, name, short, *ref, descr)
 
 		case reflect.Int64:
 			switch f.Type.String() {
 			case "int64":
-				val := field.Interface().(int64)
+				val := field.Int()
 				ref := (*int64)(unsafe.Pointer(field.UnsafeAddr()))
 				c.Flags().Int64VarP(ref, name, short, val, descr)
 
 			case "time.Duration":
-				val := field.Interface().(time.Duration)
+				val := time.Duration(field.Int())
 				ref := (*time.Duration)(unsafe.Pointer(field.UnsafeAddr()))
 				c.Flags().DurationVarP(ref, name, short, val, descr)
 
 			default:
+				s.skip(path, f.Type.String(), SkipUnsupported)
+
 				continue
 			}
 
 		default:
+			s.skip(path, f.Type.String(), SkipUnsupported)
+
 			continue
 		}
 
 	definition_done:
 
+		s.paths[path] = name
+		s.types[name] = f.Type
+		if order != nil {
+			s.fieldOrders[name] = order
+		}
+
 		// Marking the flag
 		if mandatory {
 			c.MarkFlagRequired(name)
 		}
+		if hidden, _ := strconv.ParseBool(f.Tag.Get("flaghidden")); hidden {
+			_ = c.Flags().MarkHidden(name)
+		}
+		if msg := f.Tag.Get("flagdeprecated"); msg != "" {
+			_ = c.Flags().MarkDeprecated(name, msg)
+		}
+		if isSecret(f) {
+			_ = c.Flags().SetAnnotation(name, FlagSecretAnnotation, []string{"true"})
+		}
+		if gate := f.Tag.Get("flaggroupgate"); gate != "" {
+			if f.Type.Kind() != reflect.Bool {
+				return fmt.Errorf("invalid flaggroupgate tag on %s: only bool fields can gate groups", path)
+			}
+			gate = groupTitle(c, gate)
+			if other, ok := s.gates[gate]; ok && other != name {
+				return fmt.Errorf("invalid flaggroupgate tag on %s: group %s is already gated by --%s", path, gate, other)
+			}
+			s.gates[gate] = name
+		}
+		if err := defineDurationBounds(c, f, path, name); err != nil {
+			return err
+		}
+		if err := defineKeyring(c, f, path, name); err != nil {
+			return err
+		}
+		if err := defineChoices(c, f, path, name); err != nil {
+			return err
+		}
+		for _, tag := range []string{"flaglayout", "flagzone"} {
+			if f.Tag.Get(tag) != "" && f.Type != timeType {
+				return fmt.Errorf("invalid %s tag on %s: only time.Time fields can have it", tag, path)
+			}
+		}
+		if deps := parseFlagList(f.Tag.Get("flagdependson")); len(deps) > 0 {
+			_ = c.Flags().SetAnnotation(name, FlagDependsOnAnnotation, deps)
+		}
+		if conflicts := parseFlagList(f.Tag.Get("flagconflictswith")); len(conflicts) > 0 {
+			_ = c.Flags().SetAnnotation(name, FlagConflictsWithAnnotation, conflicts)
+		}
+		if id := f.Tag.Get("flagid"); id != "" {
+			other := ""
+			c.Flags().VisitAll(func(f *pflag.Flag) {
+				if ids, ok := f.Annotations[FlagIDAnnotation]; ok && ids[0] == id && f.Name != name {
+					other = f.Name
+				}
+			})
+			if other != "" {
+				return fmt.Errorf("invalid flagid tag on %s: --%s has id %s already", path, other, id)
+			}
+			_ = c.Flags().SetAnnotation(name, FlagIDAnnotation, []string{id})
+		}
+		if groups := parseFlagList(f.Tag.Get("flagtogether")); len(groups) > 0 {
+			_ = c.Flags().SetAnnotation(name, FlagTogetherAnnotation, groups)
+		}
+		if groups := parseFlagList(f.Tag.Get("flagoneof")); len(groups) > 0 {
+			_ = c.Flags().SetAnnotation(name, FlagOneOfAnnotation, groups)
+		}
+		if unique, _ := strconv.ParseBool(f.Tag.Get("flagunique")); unique {
+			_ = c.Flags().SetAnnotation(name, FlagUniqueAnnotation, []string{"true"})
+		}
+		if policy := f.Tag.Get("flagmerge"); policy != "" {
+			if err := checkMergePolicy(policy, f.Type); err != nil {
+				return fmt.Errorf("invalid flagmerge tag on %s: %w", path, err)
+			}
+			_ = c.Flags().SetAnnotation(name, FlagMergeAnnotation, []string{policy})
+		}
+		if trim, _ := strconv.ParseBool(f.Tag.Get("flagtrim")); trim {
+			_ = c.Flags().SetAnnotation(name, FlagTrimAnnotation, []string{"true"})
+		}
+		if dropEmpty, _ := strconv.ParseBool(f.Tag.Get("flagdropempty")); dropEmpty {
+			_ = c.Flags().SetAnnotation(name, FlagDropEmptyAnnotation, []string{"true"})
+		}
+		if isTelemetry(f) {
+			_ = c.Flags().SetAnnotation(name, FlagTelemetryAnnotation, []string{"true"})
+		}
 
 		// Set the defaults
 		if defval != "" {
-			vipers[c].SetDefault(name, defval)
+			s.viper.SetDefault(name, defval)
+			s.defaults[name] = defval
 			// This is needed for the usage help messages
 			c.Flags().Lookup(name).DefValue = defval
 		}
 
-		if alias != "" && path != alias {
+		// Render the default duration in the unit of the flag, if any, and the default time in the layout and the time zone of the flag
+		if err := defineDurationUnit(c, f, path, name); err != nil {
+			return err
+		}
+		if f.Type == timeType {
+			defineTimeDefault(c, name)
+		}
+
+		if alias != "" && path != alias && !s.unaliased(path) {
 			// Alias the actual path to the flag name (ie., the alias when not empty)
-			vipers[c].RegisterAlias(path, alias)
+			s.viper.RegisterAlias(path, alias)
+			s.aliases[path] = alias
 		}
 
+		// The fallback chain of the field, if any, has no legacy names
+		legacy := getLegacyEnvs(envs)
+		envs = append(envs, getFallbackEnvs(f)...)
 		if len(envs) > 0 {
 			_ = c.Flags().SetAnnotation(name, FlagEnvsAnnotation, envs)
+			if len(legacy) > 0 {
+				_ = c.Flags().SetAnnotation(name, FlagLegacyEnvsAnnotation, legacy)
+			}
 		}
 
 		// Set the group annotation on the current flag
@@ -198,6 +510,127 @@ func define(c *cobra.Command, o interface{}, startingGroup string, structPath st
 			_ = c.Flags().SetAnnotation(name, FlagGroupAnnotation, []string{group})
 		}
 	}
+
+	return nil
+}
+
+// checkCollision errors when the flag name or its shorthand is already defined on the command.
+func checkCollision(c *cobra.Command, name, short string) error {
+	s := getScope(c)
+	if f := c.Flags().Lookup(name); f != nil {
+		if origin, ok := s.origins[name]; ok {
+			return fmt.Errorf("flag --%s is already defined by %s", name, origin)
+		}
+
+		return fmt.Errorf("flag --%s is already defined", name)
+	}
+	if short != "" {
+		if f := c.Flags().ShorthandLookup(short); f != nil {
+			return fmt.Errorf("shorthand -%s for flag --%s is already used by flag --%s", short, name, f.Name)
+		}
+	}
+
+	return nil
+}
+
+func typeName(o interface{}) string {
+	t := reflect.TypeOf(o)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.String()
+}
+
+// isNested tells whether the input type is a struct whose fields define flags of their own, rather than a single flag.
+//
+// Structs unmarshalling themselves from text (eg., time.Time), defining their own flag, or registered via RegisterType, define a single flag.
+func isNested(t reflect.Type) bool {
+	if _, ok := registeredTypes[t]; ok || isFlagDefiner(t) {
+		return false
+	}
+
+	return t.Kind() == reflect.Struct && !isText(t)
+}
+
+// isSupported tells whether define knows how to define a flag for the input field, or the fields nested in it.
+func isSupported(f reflect.StructField) bool {
+	t := f.Type
+	if custom, _ := strconv.ParseBool(f.Tag.Get("flagcustom")); custom {
+		return true
+	}
+	if _, ok := registeredTypes[t]; ok || isFlagDefiner(t) || f.Tag.Get("flagtuple") != "" {
+		return true
+	}
+	if reflect.PtrTo(t).Implements(pflagValueType) || t == timeType || t == ipType || t == ipNetType || t == reflect.SliceOf(ipType) || isText(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Bool, reflect.String, reflect.Int, reflect.Uint, reflect.Uint8:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String || isDefinableSlice(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+	case reflect.Int64:
+		return t == reflect.TypeOf(int64(0)) || t == durationType
+	}
+
+	return false
+}
+
+// unsupportedFields returns the fields of the input type, along with their types, that define would skip as unsupported,
+// or for the lack of their custom definition hooks.
+//
+// It looks at the types only, so that WithStrictTypes can fail before defining any flag.
+func unsupportedFields(c *cobra.Command, t reflect.Type, structPath string, exclusions map[string]string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	res := []string{}
+	if t.Kind() != reflect.Struct {
+		return res
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		path := strings.ToLower(f.Name)
+		if structPath != "" {
+			path = fmt.Sprintf("%s.%s", structPath, path)
+		}
+		if cname, ok := exclusions[path]; ok && c.Name() == cname {
+			continue
+		}
+		if cname, ok := exclusions[f.Tag.Get("flag")]; ok && c.Name() == cname {
+			continue
+		}
+		ignore, _ := strconv.ParseBool(f.Tag.Get("flagignore"))
+		args := f.Tag.Get("flagargs")
+		feature := f.Tag.Get("flagfeature")
+		if ignore || (args != "" && args != "false") || (feature != "" && !features.Enabled(feature)) {
+			continue
+		}
+		if !isSupported(f) {
+			res = append(res, fmt.Sprintf("%s (%s)", path, f.Type))
+
+			continue
+		}
+		if custom, _ := strconv.ParseBool(f.Tag.Get("flagcustom")); custom && !isNested(f.Type) {
+			hookName := fmt.Sprintf("Define%s", f.Name)
+			if _, ok := reflect.PtrTo(t).MethodByName(hookName); !ok {
+				res = append(res, fmt.Sprintf("%s (%s without the %s hook)", path, f.Type, hookName))
+			}
+
+			continue
+		}
+		if isNested(f.Type) {
+			res = append(res, unsupportedFields(c, f.Type, path, exclusions)...)
+		}
+	}
+
+	return res
 }
 
 func getName(name, alias string) string {
@@ -209,6 +642,15 @@ func getName(name, alias string) string {
 	return res
 }
 
+// target returns the value holding the fields of the input options.
+func target(o interface{}) interface{} {
+	if d, ok := o.(options.DynamicOptions); ok {
+		return d.Target()
+	}
+
+	return o
+}
+
 func getValue(o interface{}) reflect.Value {
 	var ptr reflect.Value
 	var val reflect.Value
@@ -0,0 +1,141 @@
+package autoflags
+
+import "strings"
+
+// DefineOption customizes the behavior of Define.
+type DefineOption func(*defineConfig)
+
+type defineConfig struct {
+	exclusions      []string
+	strictTypes     bool
+	precedence      []Source
+	strictDurations bool
+	trimSlices      bool
+	dropEmptySlices bool
+	partial         bool
+	saturate        bool
+	locked          []string
+	overrides       []string
+	withoutAliases  []string
+	synopsis        bool
+}
+
+// WithExclusions prevents Define from generating flags for the given names.
+//
+// Names can be either flag aliases or lowercase struct paths (eg., "nest.json").
+func WithExclusions(exclusions ...string) DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.exclusions = append(cfg.exclusions, exclusions...)
+	}
+}
+
+// WithStrictTypes makes Define return an error when the options contain fields whose types it cannot handle.
+func WithStrictTypes() DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.strictTypes = true
+	}
+}
+
+// WithPrecedence customizes the order in which the sources override each other, from the highest to the lowest.
+//
+// The default order is SourceValues, SourceFlag, SourceEnv, SourceConfig.
+// Sources not listed keep their default relative order, after the listed ones,
+// except for SourceValues which stays the highest one unless listed.
+// Defaults always have the lowest precedence.
+func WithPrecedence(sources ...Source) DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.precedence = sources
+	}
+}
+
+// WithoutAliases stops Define from aliasing the struct paths of the renamed fields (see the flag tag) to their flag names.
+//
+// Config files can then set such fields only by flag name, leaving their struct paths free for other keys.
+// Without arguments, it applies to all the fields of the options. Otherwise, to those under the given struct paths (eg., "server").
+// Lint reports the aliases clashing with other flags, that this option resolves.
+func WithoutAliases(paths ...string) DefineOption {
+	return func(cfg *defineConfig) {
+		if len(paths) == 0 {
+			paths = []string{""}
+		}
+		for _, p := range paths {
+			cfg.withoutAliases = append(cfg.withoutAliases, strings.ToLower(p))
+		}
+	}
+}
+
+// WithSynopsis makes Define list the required flags of the options in the usage line of the command (eg., "serve --port int [flags]").
+//
+// Documentation generators pick it up too, since it goes into the Use of the command.
+func WithSynopsis() DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.synopsis = true
+	}
+}
+
+// WithStrictDurations makes Unmarshal reject bare numbers for time.Duration fields coming from config files.
+//
+// Without units, such numbers would silently mean nanoseconds.
+func WithStrictDurations() DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.strictDurations = true
+	}
+}
+
+// WithTrimSlices makes Unmarshal trim the whitespace around the entries of all the string slices the options define.
+//
+// Use the flagtrim tag to do it for single fields.
+func WithTrimSlices() DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.trimSlices = true
+	}
+}
+
+// WithDropEmptySlices makes Unmarshal drop the empty entries of all the string slices the options define.
+//
+// For example, ",a,,b," decodes to ["a", "b"] rather than to five entries.
+// Use the flagdropempty tag to do it for single fields.
+func WithDropEmptySlices() DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.dropEmptySlices = true
+	}
+}
+
+// WithPartialSuccess makes Unmarshal keep the defaults of the fields whose values fail to decode, rather than failing.
+//
+// Each of such failures becomes a warning, that Inspect reports.
+// Use it for daemons preferring a degraded startup over crash loops.
+func WithPartialSuccess() DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.partial = true
+	}
+}
+
+// WithSaturation makes Unmarshal clamp the numbers not fitting their fields to the closest value they can hold, rather than failing.
+//
+// For example, 300 decodes to 255 into an uint8 field.
+func WithSaturation() DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.saturate = true
+	}
+}
+
+// WithLockdown makes Unmarshal reject the values of the given flags coming from anything other than the system config file.
+//
+// Use it for managed environments, where administrators pre-configure the dangerous options in /etc/<app> (%PROGRAMDATA%\<app> on Windows).
+// Names can be either flag names or lowercase struct paths (eg., "tls.insecure").
+func WithLockdown(names ...string) DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.locked = append(cfg.locked, names...)
+	}
+}
+
+// WithOverrides marks the given flags as intentionally differing from the ones the same options define onto other commands.
+//
+// CheckSharedOptions does not report them.
+// Names can be either flag names or lowercase struct paths (eg., "server.timeout").
+func WithOverrides(names ...string) DefineOption {
+	return func(cfg *defineConfig) {
+		cfg.overrides = append(cfg.overrides, names...)
+	}
+}
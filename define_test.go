@@ -2,6 +2,8 @@ package autoflags
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -41,7 +43,7 @@ func (suite *FlagsBaseSuite) TestDefine() {
 	for _, tc := range cases {
 		suite.T().Run(tc.desc, func(t *testing.T) {
 			c := &cobra.Command{}
-			Define(c, tc.input)
+			assert.Nil(t, Define(c, tc.input))
 			f := c.Flags()
 			vip, e := Viper(c)
 			assert.Nil(t, e)
@@ -83,6 +85,357 @@ func (suite *FlagsBaseSuite) TestDefine() {
 	}
 }
 
+func (suite *FlagsBaseSuite) TestDefineSkipped() {
+	c := &cobra.Command{Use: "skip"}
+	suite.Require().Nil(Define(c, &skipOptions{}, WithExclusions("excluded")))
+
+	res, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Require().Len(res.Skipped, 4)
+	suite.Equal(SkippedField{Path: "ignored", Type: "string", Reason: SkipIgnored}, res.Skipped[0])
+	suite.Equal(SkippedField{Path: "excluded", Type: "string", Reason: SkipExcluded}, res.Skipped[1])
+	suite.Equal(SkippedField{Path: "complex", Type: "complex128", Reason: SkipUnsupported}, res.Skipped[2])
+	suite.Equal(SkippedField{Path: "custom", Type: "string", Reason: SkipMissingHook}, res.Skipped[3])
+	suite.NotNil(c.Flags().Lookup("kept"))
+
+	_, err = Inspect(&cobra.Command{})
+	suite.NotNil(err)
+}
+
+func (suite *FlagsBaseSuite) TestDefineStrictTypes() {
+	c := &cobra.Command{Use: "strict"}
+	err := Define(c, &skipOptions{}, WithStrictTypes())
+	suite.EqualError(err, "unsupported field types: complex (complex128), custom (string without the DefineCustom hook)")
+	suite.False(c.Flags().HasFlags())
+
+	c = &cobra.Command{Use: "strict"}
+	err = Define(c, &namedOptions{}, WithStrictTypes())
+	suite.EqualError(err, "unsupported field types: serial (autoflags.serialNum)")
+	suite.False(c.Flags().HasFlags())
+
+	suite.Nil(Define(&cobra.Command{Use: "strict"}, &skipOptions{}, WithStrictTypes(), WithExclusions("complex", "custom")))
+
+	suite.Nil(Define(&cobra.Command{Use: "strict"}, &testOptions{}, WithStrictTypes()))
+}
+
+func (suite *FlagsBaseSuite) TestDefineNamedTypes() {
+	c := &cobra.Command{Use: "named"}
+	opts := &namedOptions{Mode: "fast", Level: 2}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("fast", c.Flags().Lookup("mode").DefValue)
+	suite.Equal("2", c.Flags().Lookup("level").DefValue)
+	suite.Equal("stringSlice", c.Flags().Lookup("hosts").Value.Type())
+
+	// Named types of kinds with several builtin types are skipped
+	res, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Equal([]SkippedField{{Path: "serial", Type: "autoflags.serialNum", Reason: SkipUnsupported}}, res.Skipped)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--mode", "slow", "--level", "3", "--hosts", "a,b"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(modeName("slow"), opts.Mode)
+	suite.Equal(levelNum(3), opts.Level)
+	suite.Equal(hostList{"a", "b"}, opts.Hosts)
+}
+
+func (suite *FlagsBaseSuite) TestDefineMultiple() {
+	c := &cobra.Command{Use: "multi"}
+	first := &skipOptions{}
+	second := &otherOptions{}
+	suite.Require().Nil(Define(c, first))
+	suite.Require().Nil(Define(c, second))
+
+	res, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Equal("autoflags.skipOptions", res.Origins["kept"])
+	suite.Equal("autoflags.otherOptions", res.Origins["other"])
+
+	suite.Equal([]any{first, second}, OptionsOf(c))
+	suite.Nil(OptionsOf(&cobra.Command{}))
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--kept", "k", "--other", "o"}))
+	suite.Require().Nil(UnmarshalAll(c))
+	suite.Equal("k", first.Kept)
+	suite.Equal("o", second.Other)
+
+	err = Define(c, &skipOptions{})
+	suite.EqualError(err, "flag --kept is already defined by autoflags.skipOptions")
+
+	err = Define(c, &shortOptions{})
+	suite.EqualError(err, "shorthand -o for flag --another is already used by flag --other")
+}
+
+func (suite *FlagsBaseSuite) TestAttachTree() {
+	root := &cobra.Command{Use: "root"}
+	user := &cobra.Command{Use: "user"}
+	add := &cobra.Command{Use: "add"}
+	root.AddCommand(user)
+	user.AddCommand(add)
+
+	err := AttachTree(root, map[*cobra.Command]options.Options{
+		user: &otherOptions{},
+		add:  &skipOptions{},
+	})
+	suite.Require().Nil(err)
+	suite.NotNil(user.Flags().Lookup("other"))
+	suite.NotNil(add.Flags().Lookup("kept"))
+	suite.Nil(root.Flags().Lookup("other"))
+
+	err = AttachTree(root, map[*cobra.Command]options.Options{
+		{Use: "orphan"}: &otherOptions{},
+	})
+	suite.EqualError(err, "command orphan is not part of the root command tree")
+}
+
+func (suite *FlagsBaseSuite) TestBlueprint() {
+	_, err := NewBlueprint(&skipOptions{}, WithStrictTypes())
+	suite.EqualError(err, "unsupported field types: complex (complex128), custom (string without the DefineCustom hook)")
+
+	blueprint, err := NewBlueprint(&skipOptions{}, WithExclusions("excluded"))
+	suite.Require().Nil(err)
+	suite.Equal([]string{"kept"}, blueprint.Flags())
+
+	for _, value := range []string{"a", "b"} {
+		c := &cobra.Command{Use: "fresh"}
+		opts := &skipOptions{}
+		suite.Require().Nil(blueprint.Define(c, opts))
+		suite.Nil(c.Flags().Lookup("excluded"))
+		suite.Require().Nil(c.Flags().Parse([]string{"--kept", value}))
+		suite.Require().Nil(Unmarshal(c, opts))
+		suite.Equal(value, opts.Kept)
+
+		Release(c)
+		_, err = Inspect(c)
+		suite.NotNil(err)
+	}
+
+	suite.EqualError(blueprint.Define(&cobra.Command{Use: "other"}, &otherOptions{}), "the blueprint of autoflags.skipOptions cannot define autoflags.otherOptions")
+}
+
+func (suite *FlagsBaseSuite) TestBlueprintClone() {
+	blueprint, err := NewBlueprint(&blueprintOptions{}, WithSynopsis())
+	suite.Require().Nil(err)
+	suite.True(blueprint.cloneable)
+
+	first, second := &cobra.Command{Use: "first"}, &cobra.Command{Use: "second"}
+	firstOpts, secondOpts := &blueprintOptions{}, &blueprintOptions{}
+	suite.Require().Nil(blueprint.Define(first, firstOpts))
+	suite.Require().Nil(blueprint.Define(second, secondOpts))
+	suite.Equal("first --name string", first.Use)
+
+	// The clones have their own values and annotations
+	suite.NotSame(blueprint.proto.Flags().Lookup("level"), first.Flags().Lookup("level"))
+	first.Flags().Lookup("level").Annotations[FlagChoicesAnnotation][0] = "changed"
+	suite.Equal([]string{"1", "2", "3"}, second.Flags().Lookup("level").Annotations[FlagChoicesAnnotation])
+	complete, ok := second.GetFlagCompletionFunc("level")
+	suite.Require().True(ok)
+	choices, _ := complete(second, nil, "")
+	suite.Equal([]string{"1", "2", "3"}, choices)
+
+	suite.Require().Nil(first.Flags().Parse([]string{"--name", "a", "--hosts", "x;y", "--port", "1", "-vv"}))
+	suite.Require().Nil(second.Flags().Parse([]string{"--name", "b", "--since", "2024-01-02T00:00:00Z"}))
+	suite.Require().Nil(Unmarshal(first, firstOpts))
+	suite.Require().Nil(Unmarshal(second, secondOpts))
+	suite.Equal(blueprintOptions{Name: "a", Level: 3, Hosts: []string{"x", "y"}, Server: blueprintServer{Port: 1}, Verbose: 2}, *firstOpts)
+	suite.Equal(blueprintOptions{Name: "b", Level: 3, Hosts: []string{}, Since: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, *secondOpts)
+
+	res, err := Inspect(second)
+	suite.Require().Nil(err)
+	suite.Equal("autoflags.blueprintOptions", res.Origins["port"])
+
+	// Options defining flags via hooks are defined again
+	blueprint, err = NewBlueprint(&definerOptions{})
+	suite.Require().Nil(err)
+	suite.False(blueprint.cloneable)
+}
+
+func (suite *FlagsBaseSuite) TestLint() {
+	root := &cobra.Command{Use: "root"}
+	root.PersistentFlags().String("other", "", "")
+	sub := &cobra.Command{Use: "sub"}
+	root.AddCommand(sub)
+	suite.Require().Nil(Define(sub, &lintOptions{}, WithoutAliases("a")))
+
+	errs := Lint(root)
+	suite.Require().Len(errs, 2)
+	suite.EqualError(errs[0], "root sub: env A_B binds to --x but it already binds to --a.b")
+	suite.EqualError(errs[1], "root sub: flag --other shadows the persistent flag of root")
+}
+
+func (suite *FlagsBaseSuite) TestAliasCollisions() {
+	err := Define(&cobra.Command{Use: "lint"}, &lintOptions{})
+	suite.EqualError(err, "flag --a.b of c clashes with the alias of the struct path of --x")
+
+	c := &cobra.Command{Use: "clash"}
+	err = Define(c, &clashOptions{})
+	suite.EqualError(err, "the alias of a.b to --x clashes with flag --a.b")
+
+	// The struct paths to disable the aliases of are checked before defining any flag
+	c = &cobra.Command{Use: "invalid"}
+	err = Define(c, &lintOptions{}, WithoutAliases("a", "b"))
+	suite.EqualError(err, "couldn't disable the aliases of b: no such struct path")
+	suite.False(c.Flags().HasFlags())
+}
+
+type sharedOptions struct {
+	fixture
+
+	Timeout int    `default:"5" flagenv:"true"`
+	Host    string `flaggroup:"Net"`
+}
+
+func (suite *FlagsBaseSuite) TestCheckSharedOptions() {
+	root := &cobra.Command{Use: "root"}
+	a, b, c, d := &cobra.Command{Use: "a"}, &cobra.Command{Use: "b"}, &cobra.Command{Use: "c"}, &cobra.Command{Use: "d"}
+	root.AddCommand(a, b, c, d)
+	suite.Require().Nil(Define(a, &sharedOptions{}))
+	suite.Require().Nil(Define(b, &sharedOptions{Host: "localhost"}))
+	SetEnvPrefix("APP")
+	defer func() { prefix = "" }()
+	suite.Require().Nil(Define(c, &sharedOptions{}))
+	suite.Require().Nil(Define(d, &sharedOptions{Host: "localhost"}, WithOverrides("host", "timeout")))
+
+	errs := CheckSharedOptions(root)
+	suite.Require().Len(errs, 2)
+	suite.EqualError(errs[0], `root b: flag --host of autoflags.sharedOptions defaults to "localhost", but to "" on root a`)
+	suite.EqualError(errs[1], "root c: flag --timeout of autoflags.sharedOptions binds env [APP_TIMEOUT], but [TIMEOUT] on root a")
+
+	suite.EqualError(Define(&cobra.Command{Use: "e"}, &sharedOptions{}, WithOverrides("port")), "couldn't mark port as overridden: no such flag")
+}
+
+func (suite *FlagsBaseSuite) TestDefineFeature() {
+	c := &cobra.Command{Use: "feature"}
+	suite.Require().Nil(Define(c, &featureOptions{}))
+	suite.Nil(c.Flags().Lookup("planner"))
+	res, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Equal([]SkippedField{{Path: "planner", Type: "string", Reason: SkipFeatureDisabled}}, res.Skipped)
+
+	suite.T().Setenv("FEATURE_NEW_PLANNER", "true")
+	c = &cobra.Command{Use: "feature"}
+	suite.Require().Nil(Define(c, &featureOptions{}))
+	suite.NotNil(c.Flags().Lookup("planner"))
+
+	SetFeatureProvider(FeatureProviderFunc(func(feature string) bool {
+		return false
+	}))
+	defer SetFeatureProvider(nil)
+	c = &cobra.Command{Use: "feature"}
+	suite.Require().Nil(Define(c, &featureOptions{}))
+	suite.Nil(c.Flags().Lookup("planner"))
+}
+
+func (suite *FlagsBaseSuite) TestRenderer() {
+	SetRenderer(RendererFunc(func(c *cobra.Command, groups []UsageGroup) string {
+		lines := []string{}
+		for _, group := range groups {
+			for _, f := range group.Flags {
+				lines = append(lines, fmt.Sprintf("%s|%s|%v|%v|%v|%v", group.Name, f.Name, f.Envs, f.Required, group.Gate, group.Collapsed))
+			}
+		}
+
+		return strings.Join(lines, "\n")
+	}))
+	defer SetRenderer(nil)
+
+	c := &cobra.Command{Use: "render"}
+	suite.Require().Nil(Define(c, &renderOptions{}))
+	suite.Contains(c.UsageTemplate(), strings.Join([]string{
+		"|token|[TOKEN]|true||false",
+		"Metrics|enable-metrics|[]|false|enable-metrics|true",
+		"Metrics|metrics.endpoint|[]|false|enable-metrics|true",
+	}, "\n"))
+
+	SetRenderer(nil)
+	c = &cobra.Command{Use: "render"}
+	suite.Require().Nil(Define(c, &renderOptions{}))
+	suite.Contains(c.UsageTemplate(), "Flags:\n      --token string")
+}
+
+type networkOptions struct {
+	fixture
+
+	Host string `flaggroup:"Network"`
+}
+
+type proxyNetworkOptions struct {
+	fixture
+
+	Proxy  string `flaggroup:" network "`
+	Tunnel bool   `flaggroup:"network" flaggroupgate:"NETWORK" default:"true"`
+}
+
+type regateNetworkOptions struct {
+	fixture
+
+	Vpn bool `flaggroupgate:"network"`
+}
+
+func (suite *FlagsBaseSuite) TestSharedGroups() {
+	c := &cobra.Command{Use: "shared"}
+	suite.Require().Nil(Define(c, &networkOptions{}))
+	suite.Require().Nil(Define(c, &proxyNetworkOptions{}))
+	suite.Equal([]string{"Network"}, c.Flags().Lookup("proxy").Annotations[FlagGroupAnnotation])
+	suite.Contains(c.UsageTemplate(), "Network Flags:\n      --host string    \n      --proxy string   \n      --tunnel          (default true)")
+	suite.NotContains(c.UsageTemplate(), "network Flags")
+
+	manifest := NewManifest(c)
+	suite.Equal([]GroupManifest{{Name: "Network", Gate: "tunnel", Flags: []string{"host", "proxy", "tunnel"}}}, manifest.Commands[0].Groups)
+
+	suite.EqualError(Define(c, &regateNetworkOptions{}), "invalid flaggroupgate tag on vpn: group Network is already gated by --tunnel")
+}
+
+type exampleOptions struct {
+	fixture
+
+	Port    int    `flagrequired:"true"`
+	Token   string `flagrequired:"true"`
+	Verbose bool
+}
+
+func (o *exampleOptions) Examples() []options.Example {
+	return []options.Example{
+		{Description: "Serve on a custom port", Command: "app serve --port 8080 --token abc"},
+		{Command: "app serve --port 80 --token abc --verbose"},
+	}
+}
+
+type moreExampleOptions struct {
+	fixture
+
+	Force bool `flagrequired:"true"`
+}
+
+func (o *moreExampleOptions) Examples() []options.Example {
+	return []options.Example{{Description: "Force it", Command: "app serve --force"}}
+}
+
+func (suite *FlagsBaseSuite) TestExamples() {
+	c := &cobra.Command{Use: "serve [dir]"}
+	suite.Require().Nil(Define(c, &exampleOptions{}, WithSynopsis()))
+	suite.Require().Nil(Define(c, &moreExampleOptions{}, WithSynopsis()))
+	suite.Equal("serve --force --port int --token string [dir]", c.Use)
+	suite.Equal("serve", c.Name())
+	suite.Equal(`  # Serve on a custom port
+  app serve --port 8080 --token abc
+  app serve --port 80 --token abc --verbose
+
+  # Force it
+  app serve --force`, c.Example)
+
+	plain := &cobra.Command{Use: "plain"}
+	suite.Require().Nil(Define(plain, &exampleOptions{}))
+	suite.Equal("plain", plain.Use)
+}
+
+type renderOptions struct {
+	fixture
+
+	Token   string         `flagenv:"true" flagrequired:"true"`
+	Enabled bool           `flag:"enable-metrics" flaggroup:"Metrics" flaggroupgate:"Metrics"`
+	Metrics metricsOptions `flaggroup:"Metrics"`
+}
+
 type ConfigFlags struct {
 	LogLevel string `default:"info" flag:"log-level" flagdescr:"set the logging level" flaggroup:"Config"`
 	Timeout  int    `flagdescr:"set the timeout, in seconds" flagset:"Config"`
@@ -107,3 +460,106 @@ type testOptions struct {
 func (o testOptions) Attach(c *cobra.Command)             {}
 func (o testOptions) Transform(ctx context.Context) error { return nil }
 func (o testOptions) Validate() []error                   { return nil }
+
+type skipOptions struct {
+	fixture
+
+	Kept     string
+	Ignored  string `flagignore:"true"`
+	Excluded string
+	Complex  complex128
+	Custom   string `flagcustom:"true"`
+}
+
+type modeName string
+
+type levelNum int
+
+type hostList []string
+
+type serialNum int64
+
+type namedOptions struct {
+	fixture
+
+	Mode   modeName
+	Level  levelNum
+	Hosts  hostList
+	Serial serialNum
+}
+
+type otherOptions struct {
+	fixture
+
+	Other string `flagshort:"o"`
+}
+
+type shortOptions struct {
+	fixture
+
+	Another string `flagshort:"o"`
+}
+
+type lintInner struct {
+	B string `flag:"x" flagenv:"true"`
+}
+
+type lintOptions struct {
+	fixture
+
+	A     lintInner
+	C     string `flag:"a.b" flagenv:"true"`
+	Other string
+}
+
+type blueprintServer struct {
+	Port int `flag:"port"`
+}
+
+type blueprintOptions struct {
+	fixture
+
+	Name    string   `flagrequired:"true"`
+	Level   int      `default:"3" flagchoices:"1,2,3"`
+	Hosts   []string `flagsep:";"`
+	Since   time.Time
+	Server  blueprintServer
+	Verbose int `type:"count" flagshort:"v"`
+}
+
+type clashOptions struct {
+	fixture
+
+	C string `flag:"a.b"`
+	A lintInner
+}
+
+type featureOptions struct {
+	fixture
+
+	Planner string `flagfeature:"new-planner"`
+}
+
+func TestDiff(t *testing.T) {
+	prev := &diffOptions{Timeout: 30 * time.Second, Nest: JSONFlags{JQ: ".a"}, Token: "a"}
+	next := &diffOptions{Timeout: time.Minute, Nest: JSONFlags{JQ: ".b"}, Token: "b", Since: time.Unix(1, 0)}
+
+	changes := Diff(prev, next)
+	assert.Len(t, changes, 4)
+	assert.Equal(t, "timeout: 30s→1m0s", changes[0].String())
+	assert.Equal(t, "nest.jq: .a→.b", changes[1].String())
+	assert.Equal(t, FieldChange{Path: "token", Secret: true}, changes[2])
+	assert.Equal(t, "token: changed", changes[2].String())
+	assert.Equal(t, "since", changes[3].Path)
+
+	assert.Empty(t, Diff(prev, *prev))
+	assert.Nil(t, Diff(prev, &testOptions{}))
+}
+
+type diffOptions struct {
+	Timeout time.Duration
+	Nest    JSONFlags
+	Token   string `flagsecret:"true"`
+	Since   time.Time
+	private int
+}
@@ -0,0 +1,50 @@
+package autoflags
+
+import (
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+)
+
+// FlagDefiner is implemented by the field types defining their own flag, wherever they appear.
+//
+// Unlike the Define<Field> methods of the flagcustom tag, the behavior travels with the type rather than with the options struct.
+type FlagDefiner interface {
+	DefineFlag(c *cobra.Command, name, short, descr string)
+}
+
+// FlagDecoder is implemented by the field types decoding themselves from the values of their flag (eg., strings from env or config).
+type FlagDecoder interface {
+	DecodeFlag(input interface{}) error
+}
+
+var (
+	flagDefinerType = reflect.TypeOf((*FlagDefiner)(nil)).Elem()
+	flagDecoderType = reflect.TypeOf((*FlagDecoder)(nil)).Elem()
+)
+
+// isFlagDefiner tells whether the input type, or its pointer, implements FlagDefiner.
+func isFlagDefiner(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(flagDefinerType)
+}
+
+// isFlagDecoder tells whether the input type, or its pointer, implements FlagDecoder.
+func isFlagDecoder(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(flagDecoderType)
+}
+
+// FlagDecoderHookFunc decodes the values into the types implementing FlagDecoder, via DecodeFlag.
+func FlagDecoderHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f == t || !isFlagDecoder(t) {
+			return data, nil
+		}
+		res := reflect.New(t)
+		if err := res.Interface().(FlagDecoder).DecodeFlag(data); err != nil {
+			return nil, err
+		}
+
+		return res.Elem().Interface(), nil
+	}
+}
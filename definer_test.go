@@ -0,0 +1,51 @@
+package autoflags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type serverMode string
+
+func (m *serverMode) DefineFlag(c *cobra.Command, name, short, descr string) {
+	c.Flags().StringVarP((*string)(m), name, short, string(*m), descr+" (dev, prod)")
+}
+
+func (m *serverMode) DecodeFlag(input interface{}) error {
+	mode := strings.ToLower(fmt.Sprint(input))
+	if mode != "dev" && mode != "prod" {
+		return fmt.Errorf("invalid server mode %q", input)
+	}
+	*m = serverMode(mode)
+
+	return nil
+}
+
+type definerOptions struct {
+	fixture
+
+	Mode   serverMode `flagenv:"true"`
+	Server struct {
+		Mode serverMode
+	}
+}
+
+func (suite *UnmarshalSuite) TestFlagDefiner() {
+	suite.useConfigFile("config.yaml", "server:\n  mode: PROD\n")
+	suite.T().Setenv("MODE", "Dev")
+
+	c := &cobra.Command{Use: "definer"}
+	opts := &definerOptions{Mode: "prod"}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().NotNil(c.Flags().Lookup("mode"))
+	suite.Equal("prod", c.Flags().Lookup("mode").DefValue)
+	suite.Contains(c.Flags().Lookup("server.mode").Usage, "(dev, prod)")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(serverMode("dev"), opts.Mode)
+	suite.Equal(serverMode("prod"), opts.Server.Mode)
+
+	suite.T().Setenv("MODE", "test")
+	suite.ErrorContains(Unmarshal(c, opts), `invalid server mode "test"`)
+}
@@ -0,0 +1,36 @@
+package autoflags
+
+import (
+	"os"
+	"regexp"
+)
+
+var (
+	descrVars    = map[string]string{}
+	descrVarsRgx = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// SetDescriptionVars sets the variables that flagdescr tags can reference as ${NAME}.
+//
+// Variables not set here are looked up in the environment.
+// References that cannot be resolved are kept as they are.
+func SetDescriptionVars(vars map[string]string) {
+	descrVars = map[string]string{}
+	for k, v := range vars {
+		descrVars[k] = v
+	}
+}
+
+func expandDescr(descr string) string {
+	return descrVarsRgx.ReplaceAllStringFunc(descr, func(ref string) string {
+		name := descrVarsRgx.FindStringSubmatch(ref)[1]
+		if val, ok := descrVars[name]; ok {
+			return val
+		}
+		if val, ok := os.LookupEnv(name); ok {
+			return val
+		}
+
+		return ref
+	})
+}
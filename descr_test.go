@@ -0,0 +1,18 @@
+package autoflags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandDescr(t *testing.T) {
+	t.Setenv("AUTOFLAGS_TEST_REGION", "eu-west-1")
+	SetDescriptionVars(map[string]string{"PORT_RANGE": "8000-8100"})
+	defer SetDescriptionVars(nil)
+
+	assert.Equal(t, "listen address (default chosen from 8000-8100)", expandDescr("listen address (default chosen from ${PORT_RANGE})"))
+	assert.Equal(t, "region (eu-west-1)", expandDescr("region (${AUTOFLAGS_TEST_REGION})"))
+	assert.Equal(t, "unknown ${NOPE_NOT_SET}", expandDescr("unknown ${NOPE_NOT_SET}"))
+	assert.Equal(t, "costs $5", expandDescr("costs $5"))
+}
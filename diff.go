@@ -0,0 +1,86 @@
+package autoflags
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldChange is the change of an options field between two values of the same options type.
+type FieldChange struct {
+	// Path is the lowercase struct path of the field (eg., "configflags.timeout")
+	Path string
+	// Old and New are nil for the secret fields, so that their values do not leak
+	Old    interface{}
+	New    interface{}
+	Secret bool
+}
+
+func (c FieldChange) String() string {
+	if c.Secret {
+		return fmt.Sprintf("%s: changed", c.Path)
+	}
+
+	return fmt.Sprintf("%s: %v→%v", c.Path, c.Old, c.New)
+}
+
+// Diff returns the fields whose values differ between the input options.
+//
+// Both options must be of the same type, either structs or pointers to structs, otherwise Diff returns nil.
+// Fields tagged with flagsecret are reported as secret changes, that do not carry their values.
+func Diff(oldOpts, newOpts interface{}) []FieldChange {
+	oldVal := reflect.Indirect(reflect.ValueOf(target(oldOpts)))
+	newVal := reflect.Indirect(reflect.ValueOf(target(newOpts)))
+	if !oldVal.IsValid() || !newVal.IsValid() || oldVal.Type() != newVal.Type() || oldVal.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return diff(oldVal, newVal, "", false)
+}
+
+func diff(oldVal, newVal reflect.Value, structPath string, secret bool) []FieldChange {
+	res := []FieldChange{}
+	for i := 0; i < oldVal.NumField(); i++ {
+		f := oldVal.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		path := strings.ToLower(f.Name)
+		if structPath != "" {
+			path = fmt.Sprintf("%s.%s", structPath, path)
+		}
+		isSecret := secret || isSecret(f)
+
+		oldField, newField := oldVal.Field(i), newVal.Field(i)
+		if f.Type.Kind() == reflect.Struct && !isLeafStruct(f.Type) {
+			res = append(res, diff(oldField, newField, path, isSecret)...)
+
+			continue
+		}
+		if reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			continue
+		}
+		change := FieldChange{Path: path, Secret: isSecret}
+		if !isSecret {
+			change.Old, change.New = oldField.Interface(), newField.Interface()
+		}
+		res = append(res, change)
+	}
+
+	return res
+}
+
+// isLeafStruct tells whether values of the input struct type are to be compared as a whole.
+func isLeafStruct(t reflect.Type) bool {
+	if t.Implements(reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()) || t.Implements(reflect.TypeOf((*fmt.Stringer)(nil)).Elem()) {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return false
+		}
+	}
+
+	return true
+}
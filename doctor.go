@@ -0,0 +1,196 @@
+package autoflags
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// DoctorCheck is the outcome of a single check of Doctor.
+type DoctorCheck struct {
+	Name     string   `json:"name"`
+	Problems []string `json:"problems"`
+}
+
+// OK tells whether the check found no problems.
+func (c DoctorCheck) OK() bool {
+	return len(c.Problems) == 0
+}
+
+// DoctorReport is the outcome of the self-check of the bindings of a command tree.
+type DoctorReport struct {
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// OK tells whether all the checks passed.
+func (r *DoctorReport) OK() bool {
+	for _, check := range r.Checks {
+		if !check.OK() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *DoctorReport) String() string {
+	var b strings.Builder
+	for _, check := range r.Checks {
+		if check.OK() {
+			fmt.Fprintf(&b, "PASS %s\n", check.Name)
+
+			continue
+		}
+		fmt.Fprintf(&b, "FAIL %s\n", check.Name)
+		for _, problem := range check.Problems {
+			fmt.Fprintf(&b, "  - %s\n", problem)
+		}
+	}
+
+	return b.String()
+}
+
+// Doctor checks at runtime the bindings of the command tree rooted at the input command.
+//
+// It verifies that:
+//   - the decode hooks the flags need are all registered
+//   - no environment variable is bound to differently named flags
+//   - the config file in use, if any, parses (once decrypted)
+//   - the search paths set by UseSearchPaths that exist are readable
+//
+// Call it once every command has been defined and the config file loaded (eg., via UseConfig).
+func Doctor(root *cobra.Command) *DoctorReport {
+	return &DoctorReport{Checks: []DoctorCheck{
+		{Name: "decode hooks", Problems: checkDecodeHooks(root)},
+		{Name: "env bindings", Problems: checkEnvBindings(root)},
+		{Name: "config file", Problems: checkConfigFile()},
+		{Name: "search paths", Problems: checkSearchPaths()},
+	}}
+}
+
+// checkDecodeHooks reports the flags needing decode hooks that are not registered.
+func checkDecodeHooks(root *cobra.Command) []string {
+	problems := []string{}
+	for _, c := range walkTree(root) {
+		c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			for _, hook := range f.Annotations[FlagDecodeHookAnnotation] {
+				if _, ok := lookupDecodeHook(c, hook); !ok {
+					problems = append(problems, fmt.Sprintf("%s: flag --%s needs the unknown decode hook %s", c.CommandPath(), f.Name, hook))
+				}
+			}
+		})
+	}
+
+	return problems
+}
+
+// checkEnvBindings reports the environment variables bound to differently named flags.
+//
+// Flags with the same name on different commands usually come from the same options, so they can share their variables.
+func checkEnvBindings(root *cobra.Command) []string {
+	bindings := map[string]map[string]string{}
+	for _, c := range walkTree(root) {
+		c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			for _, env := range boundEnvs(f) {
+				if bindings[env] == nil {
+					bindings[env] = map[string]string{}
+				}
+				if _, ok := bindings[env][f.Name]; !ok {
+					bindings[env][f.Name] = c.CommandPath()
+				}
+			}
+		})
+	}
+
+	envs := make([]string, 0, len(bindings))
+	for env, flags := range bindings {
+		if len(flags) > 1 {
+			envs = append(envs, env)
+		}
+	}
+	sort.Strings(envs)
+	problems := []string{}
+	for _, env := range envs {
+		flags := []string{}
+		for name, path := range bindings[env] {
+			flags = append(flags, fmt.Sprintf("--%s (%s)", name, path))
+		}
+		sort.Strings(flags)
+		problems = append(problems, fmt.Sprintf("env %s is bound to %s", env, strings.Join(flags, " and ")))
+	}
+
+	return problems
+}
+
+// checkConfigFile reports the config file in use when it cannot be read or parsed.
+func checkConfigFile() []string {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		return []string{}
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return []string{fmt.Sprintf("couldn't read %s: %s", MaskPath(file), err)}
+	}
+	content, _, err = decryptConfig(content)
+	if err != nil {
+		return []string{fmt.Sprintf("couldn't decrypt %s: %s", MaskPath(file), err)}
+	}
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return []string{fmt.Sprintf("couldn't parse %s: %s", MaskPath(file), err)}
+	}
+
+	return []string{}
+}
+
+// checkSearchPaths reports the existing search paths that cannot be read.
+func checkSearchPaths() []string {
+	problems := []string{}
+	if searchApp == "" {
+		return problems
+	}
+	for _, p := range SearchPaths(searchApp) {
+		if _, err := os.ReadDir(p.Dir); err != nil && !os.IsNotExist(err) {
+			problems = append(problems, fmt.Sprintf("%s search path %s is unreadable: %s", p.Category, MaskPath(p.Dir), err))
+		}
+	}
+
+	return problems
+}
+
+// NewDoctorCmd returns a "doctor" command printing the Doctor report of the input root command.
+//
+// It is meant for support scenarios, where users paste its output.
+// It errors when any check fails.
+func NewDoctorCmd(root *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the flag bindings, the config file, and the search paths",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			report := Doctor(root)
+			c.Print(report.String())
+			if !report.OK() {
+				failed := 0
+				for _, check := range report.Checks {
+					if !check.OK() {
+						failed++
+					}
+				}
+
+				return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package autoflags
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestDoctor() {
+	home := suite.T().TempDir()
+	suite.T().Setenv("HOME", home)
+	suite.T().Setenv("XDG_CONFIG_HOME", "")
+	suite.T().Cleanup(func() {
+		searchApp = ""
+	})
+	file := suite.useConfigFile("config.yaml", "host: example.com\n")
+	UseSearchPaths("app")
+
+	root := &cobra.Command{Use: "app"}
+	serve := &cobra.Command{Use: "serve"}
+	run := &cobra.Command{Use: "run"}
+	root.AddCommand(serve, run)
+	suite.Require().Nil(Define(serve, &envBlockOptions{}))
+	suite.Require().Nil(Define(run, &envBlockOptions{}))
+	report := Doctor(root)
+	suite.True(report.OK())
+	suite.Equal("PASS decode hooks\nPASS env bindings\nPASS config file\nPASS search paths\n", report.String())
+
+	run.Flags().String("addr", "", "")
+	suite.Require().Nil(run.Flags().SetAnnotation("addr", FlagEnvsAnnotation, []string{"HOST"}))
+	suite.Require().Nil(run.Flags().SetAnnotation("addr", FlagDecodeHookAnnotation, []string{"StringToAddrHookFunc"}))
+	suite.Require().Nil(os.WriteFile(file, []byte("host: [\n"), 0o600))
+	suite.Require().Nil(os.WriteFile(filepath.Join(home, ".app"), nil, 0o600))
+
+	root.AddCommand(NewDoctorCmd(root))
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetErr(out)
+	root.SetArgs([]string{"doctor"})
+	suite.EqualError(root.Execute(), "4 of 4 checks failed")
+	suite.Contains(out.String(), "FAIL decode hooks\n  - app run: flag --addr needs the unknown decode hook StringToAddrHookFunc\n")
+	suite.Contains(out.String(), "FAIL env bindings\n  - env HOST is bound to --addr (app run) and --host (app run)\n")
+	suite.Contains(out.String(), "FAIL config file\n  - couldn't parse "+file+": ")
+	suite.Contains(out.String(), "FAIL search paths\n  - user search path $HOME/.app is unreadable: ")
+}
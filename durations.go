@@ -0,0 +1,180 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagMinDurationAnnotation = "___flagminduration"
+	FlagMaxDurationAnnotation = "___flagmaxduration"
+	FlagUnitAnnotation        = "___flagunit"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationUnits are the units the flagunit tag accepts, as time.ParseDuration does.
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// defineDurationUnit validates the flagunit tag and annotates the flag with it.
+//
+// It also renders the default value of the flag in such unit, for the usage message.
+func defineDurationUnit(c *cobra.Command, f reflect.StructField, path, name string) error {
+	unit := f.Tag.Get("flagunit")
+	if unit == "" {
+		return nil
+	}
+	if f.Type != durationType {
+		return fmt.Errorf("invalid flagunit tag on %s: only time.Duration fields can have it", path)
+	}
+	if _, ok := durationUnits[unit]; !ok {
+		return fmt.Errorf("invalid flagunit tag on %s: unknown unit %q", path, unit)
+	}
+	_ = c.Flags().SetAnnotation(name, FlagUnitAnnotation, []string{unit})
+	flag := c.Flags().Lookup(name)
+	if d, err := time.ParseDuration(flag.DefValue); err == nil {
+		flag.DefValue = formatDuration(d, unit)
+	}
+
+	return nil
+}
+
+// durationUnit returns the unit the input flag renders its duration in, if any.
+func durationUnit(f *pflag.Flag) (string, bool) {
+	if unit := f.Annotations[FlagUnitAnnotation]; len(unit) > 0 {
+		return unit[0], true
+	}
+
+	return "", false
+}
+
+// formatDuration renders the input duration in the given unit (eg., 1500ms rather than 1.5s).
+//
+// The result is a valid input for time.ParseDuration, so users can type what they see.
+func formatDuration(d time.Duration, unit string) string {
+	n := float64(d) / float64(durationUnits[unit])
+
+	return strconv.FormatFloat(n, 'f', -1, 64) + unit
+}
+
+// defineDurationBounds validates the flagminduration and flagmaxduration tags and annotates the flag with them.
+func defineDurationBounds(c *cobra.Command, f reflect.StructField, path, name string) error {
+	for tag, annotation := range map[string]string{
+		"flagminduration": FlagMinDurationAnnotation,
+		"flagmaxduration": FlagMaxDurationAnnotation,
+	} {
+		val := f.Tag.Get(tag)
+		if val == "" {
+			continue
+		}
+		if f.Type != durationType {
+			return fmt.Errorf("invalid %s tag on %s: only time.Duration fields can have it", tag, path)
+		}
+		if _, err := time.ParseDuration(val); err != nil {
+			return fmt.Errorf("invalid %s tag on %s: %w", tag, path, err)
+		}
+		_ = c.Flags().SetAnnotation(name, annotation, []string{val})
+	}
+
+	return nil
+}
+
+// checkBareDurations errors when config files set duration flags with bare numbers, that would mean nanoseconds.
+func checkBareDurations(c *cobra.Command) error {
+	s := getScope(c)
+	if !s.strictDurations {
+		return nil
+	}
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || s.types[f.Name] != durationType {
+			return
+		}
+		values := lookupSources(c, f)
+		if winner(values, s.precedenceOf(f.Name)) != SourceConfig {
+			return
+		}
+		switch values[SourceConfig].(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			err = fmt.Errorf("invalid duration %v for %s (from %s): add a unit (eg., %vs)", values[SourceConfig], f.Name, origin(c, f), values[SourceConfig])
+		}
+	})
+
+	return err
+}
+
+// checkDurationBounds errors when the duration fields of the options are out of their bounds.
+func checkDurationBounds(c *cobra.Command, opts interface{}) error {
+	s := getScope(c)
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil {
+			return
+		}
+		min, hasMin := f.Annotations[FlagMinDurationAnnotation]
+		max, hasMax := f.Annotations[FlagMaxDurationAnnotation]
+		if !hasMin && !hasMax {
+			return
+		}
+		path, ok := s.flagPath(f.Name)
+		if !ok {
+			return
+		}
+		field, ok := fieldByPath(reflect.ValueOf(opts), path)
+		if !ok || field.Type() != durationType {
+			return
+		}
+		val := time.Duration(field.Int())
+		format := time.Duration.String
+		if unit, ok := durationUnit(f); ok {
+			format = func(d time.Duration) string { return formatDuration(d, unit) }
+		}
+		// Echo the bounds and the value as users wrote them, when possible
+		bounds := []string{}
+		outside := false
+		for _, b := range []struct {
+			tag   []string
+			below bool
+		}{{min, true}, {max, false}} {
+			if b.tag == nil {
+				continue
+			}
+			bound, _ := time.ParseDuration(b.tag[0])
+			if b.below && val < bound || !b.below && val > bound {
+				outside = true
+			}
+			text := b.tag[0]
+			if _, ok := durationUnit(f); ok {
+				text = format(bound)
+			}
+			bounds = append(bounds, text)
+		}
+		if !outside {
+			return
+		}
+		got := providedValue(c, f, format(val))
+		switch {
+		case hasMin && hasMax:
+			err = fmt.Errorf("%s must be between %s and %s, got %s (from %s)", f.Name, bounds[0], bounds[1], got, origin(c, f))
+		case hasMin:
+			err = fmt.Errorf("%s must be at least %s, got %s (from %s)", f.Name, bounds[0], got, origin(c, f))
+		default:
+			err = fmt.Errorf("%s must be at most %s, got %s (from %s)", f.Name, bounds[0], got, origin(c, f))
+		}
+	})
+
+	return err
+}
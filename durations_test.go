@@ -0,0 +1,81 @@
+package autoflags
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestDurations() {
+	file := suite.useConfigFile("config.yaml", "timeout: 30\n")
+
+	c := &cobra.Command{Use: "durations"}
+	opts := &durationOptions{}
+	suite.Require().Nil(Define(c, opts, WithStrictDurations()))
+	suite.EqualError(Unmarshal(c, opts), "invalid duration 30 for timeout (from config "+file+":1:1): add a unit (eg., 30s)")
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--timeout", "500ms"}))
+	suite.EqualError(Unmarshal(c, opts), "timeout must be between 1s and 10m, got 500ms (from flag)")
+	suite.Require().Nil(c.Flags().Parse([]string{"--timeout", "11m"}))
+	suite.EqualError(Unmarshal(c, opts), "timeout must be between 1s and 10m, got 11m0s (from flag)")
+	suite.Require().Nil(c.Flags().Parse([]string{"--timeout", "1m"}))
+	suite.Nil(Unmarshal(c, opts))
+
+	// Values from config files are echoed as written
+	file = suite.useConfigFile("config.yaml", "timeout: 1h30m\n")
+	c = &cobra.Command{Use: "durations"}
+	suite.Require().Nil(Define(c, opts))
+	suite.EqualError(Unmarshal(c, opts), "timeout must be between 1s and 10m, got 1h30m (from config "+file+":1:1)")
+
+	err := Define(&cobra.Command{}, &invalidDurationOptions{})
+	suite.EqualError(err, "invalid flagminduration tag on timeout: only time.Duration fields can have it")
+}
+
+func (suite *UnmarshalSuite) TestDurationUnits() {
+	c := &cobra.Command{Use: "units"}
+	opts := &unitOptions{Poll: 1500 * time.Millisecond}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("1500ms", c.Flags().Lookup("poll").DefValue)
+	suite.Equal("0.5m", c.Flags().Lookup("grace").DefValue)
+	suite.Contains(c.Flags().FlagUsages(), "(default 1500ms)")
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--poll", "2s", "--grace", "3h"}))
+	suite.ErrorContains(Unmarshal(c, opts), "grace must be at most 60m, got 180m")
+	suite.Require().Nil(c.Flags().Parse([]string{"--grace", "1m"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	record, err := NewAuditRecord(c)
+	suite.Require().Nil(err)
+	suite.Contains(record.Values, AuditValue{Flag: "poll", Value: "2000ms", Source: "flag"})
+	suite.Contains(record.Values, AuditValue{Flag: "grace", Value: "1m", Source: "flag"})
+}
+
+func (suite *UnmarshalSuite) TestDurationUnitsInvalid() {
+	c := &cobra.Command{Use: "units"}
+	suite.ErrorContains(Define(c, &invalidUnitOptions{}), `invalid flagunit tag on poll: unknown unit "d"`)
+}
+
+type unitOptions struct {
+	fixture
+
+	Poll  time.Duration `flagunit:"ms"`
+	Grace time.Duration `flagunit:"m" default:"30s" flagmaxduration:"1h"`
+}
+
+type invalidUnitOptions struct {
+	fixture
+
+	Poll time.Duration `flagunit:"d"`
+}
+
+type durationOptions struct {
+	fixture
+
+	Timeout time.Duration `flagminduration:"1s" flagmaxduration:"10m"`
+}
+
+type invalidDurationOptions struct {
+	fixture
+
+	Timeout int `flagminduration:"1s"`
+}
@@ -2,32 +2,101 @@ package autoflags
 
 import (
 	"fmt"
+	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+// EnvReplacer maps the struct path or the alias of a flag to the name of its environment variable, prefix excluded.
+type EnvReplacer interface {
+	Replace(key string) string
+}
+
+// EnvReplacerFunc is an adapter to use ordinary functions as EnvReplacer.
+type EnvReplacerFunc func(key string) string
+
+func (f EnvReplacerFunc) Replace(key string) string {
+	return f(key)
+}
+
+// DefaultEnvReplacer uppercases the keys and turns dashes and dots into underscores.
+//
+// For example, both "configflags.log-level" and "configflags.log.level" become "CONFIGFLAGS_LOG_LEVEL".
+var DefaultEnvReplacer EnvReplacer = EnvReplacerFunc(func(key string) string {
+	return envRep.Replace(strings.ToUpper(key))
+})
+
 var (
-	envSep = "_"
-	envRep = strings.NewReplacer("-", envSep, ".", envSep)
-	prefix = ""
+	envSep      = "_"
+	envRep      = strings.NewReplacer("-", envSep, ".", envSep)
+	envReplacer = DefaultEnvReplacer
+	prefix      = ""
+	// legacyPrefixes are the fallback prefixes, still accepted during a rename migration
+	legacyPrefixes = []string{}
 )
 
 const (
-	FlagEnvsAnnotation = "___flagenvs"
+	FlagEnvsAnnotation       = "___flagenvs"
+	FlagLegacyEnvsAnnotation = "___flaglegacyenvs"
 )
 
 func SetEnvPrefix(str string) {
 	prefix = fmt.Sprintf("%s%s", strings.TrimSuffix(str, envSep), envSep)
 }
 
+// SetEnvReplacer changes how the struct paths and the aliases map to environment variable names.
+//
+// The keys the replacer receives are lowercase struct paths (eg., "configflags.loglevel") or flag aliases, as written.
+// It must be called before Define, so that the env annotations of the flags reflect it.
+// A nil replacer restores DefaultEnvReplacer.
+func SetEnvReplacer(r EnvReplacer) {
+	if r == nil {
+		r = DefaultEnvReplacer
+	}
+	envReplacer = r
+}
+
+// SetLegacyEnvPrefixes registers fallback prefixes for the environment variables.
+//
+// Variables with a legacy prefix are only used when the one with the primary prefix is not set.
+// In such case Unmarshal records a deprecation warning, retrievable via Inspect.
+// It must be called before Define.
+func SetLegacyEnvPrefixes(prefixes ...string) {
+	legacyPrefixes = []string{}
+	for _, str := range prefixes {
+		legacyPrefixes = append(legacyPrefixes, fmt.Sprintf("%s%s", strings.TrimSuffix(str, envSep), envSep))
+	}
+}
+
+// getLegacyEnvs returns the names of the input environment variables for each legacy prefix.
+func getLegacyEnvs(envs []string) []string {
+	ret := []string{}
+	for _, legacy := range legacyPrefixes {
+		for _, env := range envs {
+			ret = append(ret, legacy+strings.TrimPrefix(env, prefix))
+		}
+	}
+
+	return ret
+}
+
+// boundEnvs returns the names of the environment variables bound to the input flag, by priority.
+func boundEnvs(f *pflag.Flag) []string {
+	ret := append([]string{}, f.Annotations[FlagEnvsAnnotation]...)
+
+	return append(ret, f.Annotations[FlagLegacyEnvsAnnotation]...)
+}
+
 func bindEnv(v *viper.Viper, c *cobra.Command) {
 	c.Flags().VisitAll(func(f *pflag.Flag) {
-		if envs, defineEnv := f.Annotations[FlagEnvsAnnotation]; defineEnv {
+		if envs := boundEnvs(f); len(envs) > 0 {
 			input := []string{f.Name}
 			input = append(input, envs...)
 			v.BindEnv(input...)
@@ -35,6 +104,130 @@ func bindEnv(v *viper.Viper, c *cobra.Command) {
 	})
 }
 
+// VerifyEnv returns the environment variables having the env prefix (or a legacy one) that do not bind to any flag of the command.
+//
+// It helps catching typos (eg., MYAPP_TIMEOUTT) that would be otherwise silently ignored.
+// It returns nil when no env prefix is set.
+func VerifyEnv(c *cobra.Command) []string {
+	if prefix == "" {
+		return nil
+	}
+
+	bound := map[string]bool{}
+	collect := func(f *pflag.Flag) {
+		for _, env := range boundEnvs(f) {
+			bound[envKey(env)] = true
+		}
+	}
+	c.Flags().VisitAll(collect)
+	c.InheritedFlags().VisitAll(collect)
+
+	ret := []string{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if bound[envKey(name)] {
+			continue
+		}
+		if hasEnvPrefix(name) {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// checkLegacyEnv records a warning for every flag taking its value from a legacy environment variable.
+func checkLegacyEnv(c *cobra.Command) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		for _, env := range f.Annotations[FlagEnvsAnnotation] {
+			if val, ok := os.LookupEnv(env); ok && val != "" {
+				return
+			}
+		}
+		for _, legacy := range f.Annotations[FlagLegacyEnvsAnnotation] {
+			if val, ok := os.LookupEnv(legacy); ok && val != "" {
+				s.warn(fmt.Sprintf("env %s is deprecated, use %s instead", legacy, f.Annotations[FlagEnvsAnnotation][0]))
+
+				return
+			}
+		}
+	})
+}
+
+// EnvError describes an environment variable whose value cannot be decoded into the type of its flag.
+type EnvError struct {
+	Name  string
+	Value string
+	Flag  string
+	Err   error
+}
+
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("invalid value %q for env %s (flag --%s): %s", e.Value, e.Name, e.Flag, e.Err)
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+// CheckEnv validates the values of the environment variables bound to the flags of the command.
+//
+// It returns an EnvError for every variable that is set but whose value cannot be decoded into the type of its flag.
+// The input decode hooks apply before the ones of the flags, as for Unmarshal: pass the ones given to it.
+func CheckEnv(c *cobra.Command, hooks ...mapstructure.DecodeHookFunc) []error {
+	errs := []error{}
+	s, ok := scopes[c]
+	if !ok {
+		return errs
+	}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		typ, ok := s.types[f.Name]
+		if !ok {
+			return
+		}
+		for _, env := range boundEnvs(f) {
+			val, ok := os.LookupEnv(env)
+			if !ok || val == "" {
+				continue
+			}
+			if err := decodeValue(c, f, typ, val, hooks...); err != nil {
+				errs = append(errs, &EnvError{Name: env, Value: val, Flag: f.Name, Err: err})
+			}
+		}
+	})
+
+	return errs
+}
+
+// checkWinningEnv validates the values of the environment variables the flags of the command take their values from.
+//
+// Unlike CheckEnv, it skips the variables that lose to another source, or to another variable bound to the same flag.
+func checkWinningEnv(c *cobra.Command, hooks ...mapstructure.DecodeHookFunc) []error {
+	errs := []error{}
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		typ, ok := s.types[f.Name]
+		if !ok || winner(lookupSources(c, f), s.precedenceOf(f.Name)) != SourceEnv {
+			return
+		}
+		for _, env := range boundEnvs(f) {
+			val, ok := os.LookupEnv(env)
+			if !ok || val == "" {
+				continue
+			}
+			if err := decodeValue(c, f, typ, val, hooks...); err != nil {
+				errs = append(errs, &EnvError{Name: env, Value: val, Flag: f.Name, Err: err})
+			}
+
+			break
+		}
+	})
+
+	return errs
+}
+
 func getEnv(f reflect.StructField, inherit bool, path, alias string) ([]string, bool) {
 	ret := []string{}
 
@@ -42,10 +235,10 @@ func getEnv(f reflect.StructField, inherit bool, path, alias string) ([]string,
 	defineEnv, _ := strconv.ParseBool(env)
 
 	if defineEnv || inherit {
-		if f.Type.Kind() != reflect.Struct {
-			ret = append(ret, prefix+envRep.Replace(strings.ToUpper(path)))
+		if !isNested(f.Type) {
+			ret = append(ret, prefix+envReplacer.Replace(path))
 			if alias != "" && path != alias {
-				ret = append(ret, prefix+envRep.Replace(strings.ToUpper(alias)))
+				ret = append(ret, prefix+envReplacer.Replace(alias))
 			}
 		}
 	}
@@ -53,5 +246,22 @@ func getEnv(f reflect.StructField, inherit bool, path, alias string) ([]string,
 	return ret, defineEnv
 }
 
+// getFallbackEnvs returns the environment variables the flagenvs tag of the input field lists, by priority.
+//
+// They are taken as written, without the env prefix, and consulted after the ones flagenv binds (eg., flagenvs:"ANTHROPIC_API_KEY,API_KEY").
+func getFallbackEnvs(f reflect.StructField) []string {
+	ret := []string{}
+	if isNested(f.Type) {
+		return ret
+	}
+	for _, env := range strings.Split(f.Tag.Get("flagenvs"), ",") {
+		if env = strings.TrimSpace(env); env != "" {
+			ret = append(ret, env)
+		}
+	}
+
+	return ret
+}
+
 // FIXME: if a flag has flagrequired="true" and flagenv:"true" than flagrequired takes precedence and it forces you to always use the --flag
 // FIXME: no real way to circumvent this... document it
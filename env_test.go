@@ -0,0 +1,144 @@
+package autoflags
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type fallbackEnvOptions struct {
+	fixture
+
+	APIKey string `flagenv:"true" flagenvs:"ANTHROPIC_API_KEY, API_KEY" flagsecret:"true"`
+}
+
+func (suite *UnmarshalSuite) TestFallbackEnvs() {
+	suite.T().Setenv("APIKEY", "")
+	suite.T().Setenv("ANTHROPIC_API_KEY", "")
+	suite.T().Setenv("API_KEY", "b")
+	c := &cobra.Command{Use: "fallback"}
+	opts := &fallbackEnvOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal([]string{"APIKEY", "ANTHROPIC_API_KEY", "API_KEY"}, c.Flags().Lookup("apikey").Annotations[FlagEnvsAnnotation])
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("b", opts.APIKey)
+	settings, err := SettingsOf(c)
+	suite.Require().Nil(err)
+	suite.Equal("env API_KEY", settings.Origin("apikey"))
+
+	suite.T().Setenv("ANTHROPIC_API_KEY", "a")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("a", opts.APIKey)
+	suite.Equal("env ANTHROPIC_API_KEY", settings.Origin("apikey"))
+
+	suite.T().Setenv("APIKEY", "k")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("k", opts.APIKey)
+	suite.Equal("env APIKEY", settings.Origin("apikey"))
+}
+
+func (suite *UnmarshalSuite) TestEnvValues() {
+	c := &cobra.Command{Use: "env"}
+	opts := &envOptions{}
+	suite.Require().Nil(Define(c, opts))
+
+	suite.T().Setenv("TIMEOUT", "30")
+	suite.T().Setenv("RETRIES", "many")
+	errs := CheckEnv(c)
+	suite.Require().Len(errs, 2)
+	suite.EqualError(errs[0], `invalid value "many" for env RETRIES (flag --retries): cannot parse as int: strconv.ParseInt: parsing "many": invalid syntax`)
+	suite.EqualError(errs[1], `invalid value "30" for env TIMEOUT (flag --timeout): time: missing unit in duration "30"`)
+	suite.ErrorContains(Unmarshal(c, opts), `invalid value "many" for env RETRIES`)
+
+	// Only the env values in use fail the unmarshalling
+	suite.Require().Nil(c.Flags().Parse([]string{"--retries", "2", "--timeout", "5s"}))
+	suite.Len(CheckEnv(c), 2)
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(2, opts.Retries)
+	suite.Equal(5*time.Second, opts.Timeout)
+	c = &cobra.Command{Use: "env"}
+	suite.Require().Nil(Define(c, opts))
+
+	suite.T().Setenv("TIMEOUT", "30s")
+	suite.T().Setenv("RETRIES", "3")
+	suite.T().Setenv("HOSTS", "a,b")
+	suite.Empty(CheckEnv(c))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(30*time.Second, opts.Timeout)
+	suite.Equal(3, opts.Retries)
+	suite.Equal([]string{"a", "b"}, opts.Hosts)
+
+	// The decode hooks given to Unmarshal apply to the env values too
+	retries := func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() == reflect.String && t.Kind() == reflect.Int && data == "many" {
+			return 10, nil
+		}
+
+		return data, nil
+	}
+	suite.T().Setenv("RETRIES", "many")
+	suite.Empty(CheckEnv(c, retries))
+	suite.Require().Nil(Unmarshal(c, opts, retries))
+	suite.Equal(10, opts.Retries)
+}
+
+func (suite *UnmarshalSuite) TestLegacyEnvPrefixes() {
+	SetEnvPrefix("MYAPP")
+	SetLegacyEnvPrefixes("LEGACYAPP")
+	suite.T().Cleanup(func() {
+		prefix = ""
+		SetLegacyEnvPrefixes()
+	})
+
+	c := &cobra.Command{Use: "legacy"}
+	opts := &envOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal([]string{"LEGACYAPP_RETRIES"}, c.Flags().Lookup("retries").Annotations[FlagLegacyEnvsAnnotation])
+
+	suite.T().Setenv("LEGACYAPP_RETRIES", "3")
+	suite.T().Setenv("LEGACYAPP_TIMEOUT", "1s")
+	suite.T().Setenv("MYAPP_TIMEOUT", "2s")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(3, opts.Retries)
+	suite.Equal(2*time.Second, opts.Timeout)
+
+	res, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Equal([]string{"env LEGACYAPP_RETRIES is deprecated, use MYAPP_RETRIES instead"}, res.Warnings)
+}
+
+func (suite *UnmarshalSuite) TestEnvReplacer() {
+	SetEnvReplacer(EnvReplacerFunc(func(key string) string {
+		return strings.ToUpper(strings.ReplaceAll(key, ".", "__"))
+	}))
+	suite.T().Cleanup(func() {
+		SetEnvReplacer(nil)
+	})
+
+	c := &cobra.Command{Use: "replacer"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal([]string{"SERVER__PORT"}, c.Flags().Lookup("server.port").Annotations[FlagEnvsAnnotation])
+
+	suite.T().Setenv("SERVER__PORT", "8080")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(8080, opts.Server.Port)
+}
+
+func (suite *UnmarshalSuite) TestVerifyEnv() {
+	c := &cobra.Command{Use: "verify"}
+	suite.Require().Nil(Define(c, &envOptions{}))
+	suite.Nil(VerifyEnv(c))
+
+	SetEnvPrefix("VERIFYAPP")
+	suite.T().Cleanup(func() {
+		prefix = ""
+	})
+	c = &cobra.Command{Use: "verify"}
+	suite.Require().Nil(Define(c, &envOptions{}))
+	suite.T().Setenv("VERIFYAPP_TIMEOUT", "1s")
+	suite.T().Setenv("VERIFYAPP_TIMEOUTT", "1s")
+	suite.Equal([]string{"VERIFYAPP_TIMEOUTT"}, VerifyEnv(c))
+}
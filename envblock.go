@@ -0,0 +1,184 @@
+package autoflags
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// EnvFormat is a deployment manifest format EnvBlock renders the environment variables in.
+type EnvFormat string
+
+const (
+	// EnvFormatDockerfile renders Dockerfile ENV instructions
+	EnvFormatDockerfile EnvFormat = "dockerfile"
+	// EnvFormatKubernetes renders the env list of a Kubernetes container
+	EnvFormatKubernetes EnvFormat = "kubernetes"
+	// EnvFormatCompose renders the environment map of a docker-compose service
+	EnvFormatCompose EnvFormat = "compose"
+)
+
+// envEntry is an environment variable bound to a flag.
+type envEntry struct {
+	name    string
+	value   string
+	comment string
+}
+
+// EnvBlock renders the environment variables bound to the flags of the command tree rooted at the input command,
+// with the defaults of their flags and their descriptions as comments.
+//
+// Each variable appears once, for the first command defining it.
+// Secret flags get empty values, so that the output never embeds their defaults.
+// Deprecated legacy environment variables are omitted.
+func EnvBlock(root *cobra.Command, format EnvFormat) (string, error) {
+	entries := []envEntry{}
+	seen := map[string]bool{}
+	for _, c := range walkTree(root) {
+		c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			envs := f.Annotations[FlagEnvsAnnotation]
+			if len(envs) == 0 || seen[envs[0]] {
+				return
+			}
+			seen[envs[0]] = true
+			entry := envEntry{name: envs[0], value: envValue(f)}
+			comment := []string{}
+			if f.Usage != "" {
+				comment = append(comment, f.Usage)
+			}
+			ref := fmt.Sprintf("--%s", f.Name)
+			if c != root {
+				ref = fmt.Sprintf("%s --%s", c.CommandPath(), f.Name)
+			}
+			if isSecretFlag(f) {
+				entry.value = ""
+				ref += ", secret"
+			}
+			comment = append(comment, fmt.Sprintf("(%s)", ref))
+			entry.comment = strings.Join(comment, " ")
+			entries = append(entries, entry)
+		})
+	}
+
+	var b strings.Builder
+	switch format {
+	case EnvFormatDockerfile:
+		for _, e := range entries {
+			fmt.Fprintf(&b, "# %s\nENV %s=%s\n", e.comment, e.name, strconv.Quote(e.value))
+		}
+	case EnvFormatKubernetes:
+		b.WriteString("env:\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "  # %s\n  - name: %s\n    value: %s\n", e.comment, e.name, strconv.Quote(e.value))
+		}
+	case EnvFormatCompose:
+		b.WriteString("environment:\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "  # %s\n  %s: %s\n", e.comment, e.name, strconv.Quote(e.value))
+		}
+	default:
+		return "", fmt.Errorf("unknown env format %q", format)
+	}
+
+	return b.String(), nil
+}
+
+// envValue returns the default value of the input flag as its environment variable takes it.
+func envValue(f *pflag.Flag) string {
+	if _, ok := f.Value.(pflag.SliceValue); ok {
+		return strings.TrimSuffix(strings.TrimPrefix(f.DefValue, "["), "]")
+	}
+
+	return f.DefValue
+}
+
+// NewEnvBlockCmd returns an "env" command printing the EnvBlock of its root command.
+//
+// The --format flag selects the output among dockerfile, kubernetes, and compose.
+func NewEnvBlockCmd() *cobra.Command {
+	format := string(EnvFormatDockerfile)
+	c := &cobra.Command{
+		Use:   "env",
+		Short: "Print the environment variables the application reads, for deployment manifests",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			block, err := EnvBlock(rootOf(c), EnvFormat(format))
+			if err != nil {
+				return err
+			}
+			c.Print(block)
+
+			return nil
+		},
+	}
+	c.Flags().StringVar(&format, "format", format, "the output format (dockerfile, kubernetes, or compose)")
+
+	return c
+}
+
+// ExportShell writes the resolved options of the input command as POSIX shell export lines (eg., export MYAPP_TIMEOUT=30s).
+//
+// It lets wrapper scripts source the effective settings of a command (eg., eval "$(myapp config env)").
+// Each flag exports the first environment variable bound to it, or the one flagenv would bind otherwise.
+// Slices and maps are comma separated, as their environment variables take them.
+// Secret flags are omitted, and so are the flags of the options attached to the command by value.
+// It exports the values the fields hold, so that a wrapper sourcing them before Unmarshal runs gets the defaults.
+func ExportShell(c *cobra.Command, w io.Writer) error {
+	s, ok := scopes[c]
+	if !ok {
+		return fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || isSecretFlag(f) {
+			return
+		}
+		field, ok := fieldValue(c, f.Name)
+		if !ok {
+			return
+		}
+		name := ""
+		if envs := f.Annotations[FlagEnvsAnnotation]; len(envs) > 0 {
+			name = envs[0]
+		} else if path, ok := s.flagPath(f.Name); ok {
+			name = prefix + envReplacer.Replace(path)
+		}
+		_, err = fmt.Fprintf(w, "export %s=%s\n", name, shellQuote(exportValue(flagValue(f, field))))
+	})
+
+	return err
+}
+
+// exportValue formats the input value as its environment variable takes it.
+func exportValue(value interface{}) string {
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Slice:
+		if b, ok := value.([]byte); ok {
+			return string(b)
+		}
+		entries := []string{}
+		for i := 0; i < val.Len(); i++ {
+			entries = append(entries, fmt.Sprint(configValue(val.Index(i))))
+		}
+
+		return strings.Join(entries, ",")
+	case reflect.Map:
+		entries := []string{}
+		iter := val.MapRange()
+		for iter.Next() {
+			entries = append(entries, fmt.Sprintf("%v=%v", iter.Key().Interface(), configValue(iter.Value())))
+		}
+		sort.Strings(entries)
+
+		return strings.Join(entries, ",")
+	}
+
+	return fmt.Sprint(value)
+}
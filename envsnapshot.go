@@ -0,0 +1,121 @@
+package autoflags
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// EnvSnapshot maps the environment variables a command resolves its options with to their values.
+type EnvSnapshot map[string]string
+
+// redactedEnv is the value snapshots record for the variables they cannot tell are safe to capture.
+const redactedEnv = "***"
+
+// SnapshotEnv captures the environment variables having the env prefix (or a legacy one) and the ones bound to the flags of the command.
+//
+// The variables bound to secret flags and the config decryption key ones are left out.
+// The values of the prefixed variables not bound to a non-secret flag of the command are redacted,
+// since they can belong to the secret flags of other commands.
+// Unmarshal takes a snapshot at every resolution, that audit records include, so that configuration issues can be reproduced via Replay.
+func SnapshotEnv(c *cobra.Command) EnvSnapshot {
+	bound := map[string]bool{}
+	secrets := map[string]bool{}
+	collect := func(f *pflag.Flag) {
+		for _, env := range boundEnvs(f) {
+			bound[envKey(env)] = true
+			if isSecretFlag(f) {
+				secrets[envKey(env)] = true
+			}
+		}
+	}
+	c.Flags().VisitAll(collect)
+	c.InheritedFlags().VisitAll(collect)
+
+	res := EnvSnapshot{}
+	for _, kv := range os.Environ() {
+		name, val, _ := strings.Cut(kv, "=")
+		if secrets[envKey(name)] || isConfigKeyEnv(name) {
+			continue
+		}
+		switch {
+		case bound[envKey(name)]:
+			res[name] = val
+		case hasEnvPrefix(name):
+			res[name] = redactedEnv
+		}
+	}
+
+	return res
+}
+
+// hasEnvPrefix tells whether the input environment variable has the env prefix or a legacy one.
+func hasEnvPrefix(name string) bool {
+	if prefix == "" {
+		return false
+	}
+	for _, p := range append([]string{prefix}, legacyPrefixes...) {
+		if strings.HasPrefix(envKey(name), envKey(p)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isConfigKeyEnv tells whether the input environment variable holds the key to decrypt config files with.
+func isConfigKeyEnv(name string) bool {
+	for _, p := range append([]string{prefix}, legacyPrefixes...) {
+		if key := envKey(name); key == envKey(p+"CONFIG_KEY") || key == envKey(p+"CONFIG_KEY_FILE") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Replay sets the environment variables of the snapshot, unsetting the other ones having the env prefix (or a legacy one).
+//
+// The variables whose values the snapshot redacts keep their current values.
+//
+// It is meant for tests reproducing the environment of an audit record.
+// It returns the function restoring the environment as it was (eg., to pass to t.Cleanup).
+func (s EnvSnapshot) Replay() (restore func()) {
+	previous := map[string]*string{}
+	save := func(name string) {
+		if _, ok := previous[name]; ok {
+			return
+		}
+		if val, ok := os.LookupEnv(name); ok {
+			previous[name] = &val
+		} else {
+			previous[name] = nil
+		}
+	}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := s[name]; !ok && hasEnvPrefix(name) && !isConfigKeyEnv(name) {
+			save(name)
+			os.Unsetenv(name)
+		}
+	}
+	for name, val := range s {
+		if val == redactedEnv {
+			continue
+		}
+		save(name)
+		os.Setenv(name, val)
+	}
+
+	return func() {
+		for name, val := range previous {
+			if val == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *val)
+			}
+		}
+	}
+}
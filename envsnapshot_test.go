@@ -0,0 +1,66 @@
+package autoflags
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type snapshotOptions struct {
+	fixture
+
+	Timeout time.Duration `flagenv:"true"`
+	Token   string        `flagenv:"true" flagsecret:"true"`
+}
+
+func (suite *UnmarshalSuite) TestEnvSnapshot() {
+	SetEnvPrefix("APP")
+	suite.T().Cleanup(func() { prefix = "" })
+	suite.T().Setenv("APP_TIMEOUT", "5s")
+	suite.T().Setenv("APP_TOKEN", "x")
+	suite.T().Setenv("APP_TYPO", "1")
+	suite.T().Setenv("UNRELATED", "y")
+
+	c := &cobra.Command{Use: "snapshot"}
+	opts := &snapshotOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	record, err := NewAuditRecord(c)
+	suite.Require().Nil(err)
+	suite.Equal(EnvSnapshot{"APP_TIMEOUT": "5s", "APP_TYPO": "***"}, record.Env)
+
+	suite.T().Setenv("APP_TIMEOUT", "1s")
+	suite.T().Setenv("APP_TYPO", "2")
+	restore := record.Env.Replay()
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(5*time.Second, opts.Timeout)
+	_, ok := os.LookupEnv("APP_TOKEN")
+	suite.False(ok)
+	suite.Equal("y", os.Getenv("UNRELATED"))
+	suite.Equal("2", os.Getenv("APP_TYPO"))
+
+	restore()
+	suite.Equal("1s", os.Getenv("APP_TIMEOUT"))
+	suite.Equal("x", os.Getenv("APP_TOKEN"))
+}
+
+func (suite *UnmarshalSuite) TestEnvSnapshotConfigKey() {
+	SetEnvPrefix("APP")
+	suite.T().Cleanup(func() { prefix = "" })
+	suite.T().Setenv("APP_CONFIG_KEY", "AGE-SECRET-KEY-1FAKE")
+	suite.T().Setenv("APP_CONFIG_KEY_FILE", "/keys/age.txt")
+	suite.T().Setenv("APP_TIMEOUT", "5s")
+
+	c := &cobra.Command{Use: "snapshot"}
+	opts := &snapshotOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	record, err := NewAuditRecord(c)
+	suite.Require().Nil(err)
+	suite.Equal(EnvSnapshot{"APP_TIMEOUT": "5s"}, record.Env)
+
+	restore := record.Env.Replay()
+	suite.Equal("AGE-SECRET-KEY-1FAKE", os.Getenv("APP_CONFIG_KEY"))
+	restore()
+}
@@ -0,0 +1,208 @@
+package autoflags
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// FieldError is an error concerning a specific options field.
+//
+// Validate implementations can return it so that Unmarshal reports which flag, environment variable, or config key to fix.
+type FieldError struct {
+	// Path is the lowercase struct path of the field (eg., "configflags.endpoint") or its flag name.
+	Path string
+	Err  error
+}
+
+// NewFieldError wraps the input error so that it refers to the field at the given path.
+func NewFieldError(path string, err error) error {
+	return &FieldError{Path: path, Err: err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationIssue is a single validation failure, mapped to the inputs that set the offending field when possible.
+//
+// Source describes where the offending value comes from (eg., "env MYAPP_TIMEOUT"), unless it is the default one.
+// Policy is the identifier of the policy the issue violates, if any.
+type ValidationIssue struct {
+	Err       error
+	Flag      string
+	Envs      []string
+	ConfigKey string
+	Source    string
+	Policy    string
+}
+
+func (i ValidationIssue) String() string {
+	if i.Flag == "" {
+		return i.Err.Error()
+	}
+	inputs := []string{fmt.Sprintf("--%s", i.Flag)}
+	if len(i.Envs) > 0 {
+		inputs = append(inputs, fmt.Sprintf("env %s", strings.Join(i.Envs, ", ")))
+	}
+	if i.ConfigKey != "" {
+		inputs = append(inputs, fmt.Sprintf("config key %s", i.ConfigKey))
+	}
+	if i.Source != "" {
+		return fmt.Sprintf("%s (from %s; %s)", i.Err.Error(), i.Source, strings.Join(inputs, ", "))
+	}
+
+	return fmt.Sprintf("%s (%s)", i.Err.Error(), strings.Join(inputs, ", "))
+}
+
+// ValidationError is the error Unmarshal returns when the options fail their validation.
+type ValidationError struct {
+	// Options is the type name of the invalid options
+	Options string
+	Issues  []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	ret := fmt.Sprintf("invalid options for %s", e.Options)
+	for _, issue := range e.Issues {
+		ret += "\n       "
+		ret += issue.String()
+	}
+
+	return ret
+}
+
+// newValidationError maps the input errors to the flags of the command they refer to.
+func newValidationError(c *cobra.Command, opts interface{}, errs []error) *ValidationError {
+	res := &ValidationError{
+		Options: typeName(opts),
+	}
+	s := getScope(c)
+	for _, err := range errs {
+		issue := ValidationIssue{Err: err}
+		var policyErr *PolicyError
+		if errors.As(err, &policyErr) {
+			issue.Policy = policyErr.Policy
+		}
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			name, ok := s.paths[strings.ToLower(fieldErr.Path)]
+			if !ok {
+				name = fieldErr.Path
+			}
+			if f := c.Flags().Lookup(name); f != nil {
+				issue.Flag = f.Name
+				issue.Envs = f.Annotations[FlagEnvsAnnotation]
+				issue.ConfigKey = f.Name
+				if src := origin(c, f); src != SourceDefault.String() {
+					issue.Source = src
+				}
+			}
+		}
+		res.Issues = append(res.Issues, issue)
+	}
+
+	return res
+}
+
+var decodeFieldRe = regexp.MustCompile(`'([^']+)'`)
+
+// decodeError appends to each decoding failure the source of the offending value.
+//
+// Decoding failures quote the struct path of their field first (eg., "cannot parse 'Server.Port' as int").
+func decodeError(c *cobra.Command, err error) error {
+	var decodeErr *mapstructure.Error
+	if !errors.As(err, &decodeErr) {
+		return err
+	}
+	res := &mapstructure.Error{}
+	for _, msg := range decodeErr.Errors {
+		if f, _ := decodeFailure(c, msg); f != nil {
+			msg = fmt.Sprintf("%s (from %s)", msg, origin(c, f))
+		}
+		res.Errors = append(res.Errors, msg)
+	}
+
+	return res
+}
+
+// decodeFailure returns the flag and the lowercase struct path of the field the input decoding failure is about, if any.
+func decodeFailure(c *cobra.Command, msg string) (*pflag.Flag, string) {
+	m := decodeFieldRe.FindStringSubmatch(msg)
+	if m == nil {
+		return nil, ""
+	}
+	// Failures of slice and map entries refer to them by index or key (eg., 'Hosts[0]')
+	path := strings.ToLower(m[1])
+	if i := strings.Index(path, "["); i >= 0 {
+		path = path[:i]
+	}
+	name, ok := getScope(c).paths[path]
+	if !ok {
+		return nil, ""
+	}
+
+	return c.Flags().Lookup(name), path
+}
+
+// tolerateDecodeError replaces the values failing to decode with the defaults of their flags, warning about each of them.
+//
+// It returns the input error as decodeError does when some failure does not concern a flag.
+func tolerateDecodeError(c *cobra.Command, settings map[string]interface{}, err error) error {
+	var decodeErr *mapstructure.Error
+	if !errors.As(err, &decodeErr) {
+		return err
+	}
+	s := getScope(c)
+	for _, msg := range decodeErr.Errors {
+		f, path := decodeFailure(c, msg)
+		if f == nil || !setSetting(settings, path, defaultSetting(f)) {
+			return decodeError(c, err)
+		}
+		s.warn(fmt.Sprintf("%s (from %s), using the default %q instead", msg, origin(c, f), f.DefValue))
+	}
+
+	return nil
+}
+
+// setSetting replaces the value at the input lowercase struct path of the settings, if any.
+func setSetting(settings map[string]interface{}, path string, value interface{}) bool {
+	parts := strings.Split(path, ".")
+	m := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		m = next
+	}
+	if _, ok := m[parts[len(parts)-1]]; !ok {
+		return false
+	}
+	m[parts[len(parts)-1]] = value
+
+	return true
+}
+
+// defaultSetting returns the default value of the input flag as viper reads it.
+func defaultSetting(f *pflag.Flag) interface{} {
+	if _, ok := f.Value.(pflag.SliceValue); ok {
+		def := strings.TrimSuffix(strings.TrimPrefix(f.DefValue, "["), "]")
+		if def == "" {
+			return []string{}
+		}
+
+		return strings.Split(def, ",")
+	}
+
+	return f.DefValue
+}
@@ -0,0 +1,27 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestErrorSources() {
+	suite.T().Setenv("SERVER_PORT", "-1")
+	c := &cobra.Command{Use: "validate"}
+	opts := &validatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	var validationErr *ValidationError
+	suite.Require().ErrorAs(Unmarshal(c, opts), &validationErr)
+	suite.Equal("env SERVER_PORT", validationErr.Issues[0].Source)
+	suite.Equal("port must be positive (from env SERVER_PORT; --server.port, env SERVER_PORT, config key server.port)", validationErr.Issues[0].String())
+
+	file := suite.useConfigFile("config.yaml", "server:\n  port: many\n")
+	suite.T().Setenv("SERVER_PORT", "")
+	c = &cobra.Command{Use: "decode"}
+	suite.Require().Nil(Define(c, &portOptions{}))
+	suite.ErrorContains(Unmarshal(c, &portOptions{}), "cannot parse 'Server.Port' as int: strconv.ParseInt: parsing \"many\": invalid syntax (from config "+file+":2:3)")
+
+	file = suite.useConfigFile("config.toml", "# comment\n[server]\nhost = \"a\"\n  port = \"many\"\n")
+	c = &cobra.Command{Use: "decode"}
+	suite.Require().Nil(Define(c, &portOptions{}))
+	suite.ErrorContains(Unmarshal(c, &portOptions{}), "(from config "+file+":4:3)")
+}
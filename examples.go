@@ -0,0 +1,56 @@
+package autoflags
+
+import (
+	"strings"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defineExamples appends the examples of the input options, if any, to the Example of the command.
+func defineExamples(c *cobra.Command, o options.Options) {
+	eo, ok := o.(options.ExampleOptions)
+	if !ok {
+		return
+	}
+	lines := []string{}
+	for _, e := range eo.Examples() {
+		if e.Description != "" {
+			lines = append(lines, "  # "+e.Description)
+		}
+		lines = append(lines, "  "+e.Command)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if c.Example != "" {
+		c.Example += "\n\n"
+	}
+	c.Example += strings.Join(lines, "\n")
+}
+
+// defineSynopsis lists the required flags of the command missing from its usage line, before its other arguments.
+//
+// It replaces the synopsis of the previous Define calls, so that the flags keep their sorted order.
+func defineSynopsis(c *cobra.Command) {
+	s := getScope(c)
+	name, rest, _ := strings.Cut(c.Use, " ")
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, s.synopsis))
+	required := []string{}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if val, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; !ok || val[0] != "true" || f.Hidden {
+			return
+		}
+		if strings.Contains(" "+rest+" ", " --"+f.Name+" ") {
+			return
+		}
+		synopsis := "--" + f.Name
+		if f.Value.Type() != "bool" {
+			synopsis += " " + f.Value.Type()
+		}
+		required = append(required, synopsis)
+	})
+	s.synopsis = strings.Join(required, " ")
+	c.Use = strings.TrimSpace(name + " " + s.synopsis + " " + rest)
+}
@@ -0,0 +1,42 @@
+package autoflags
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FeatureProvider tells whether a feature flag is enabled.
+//
+// Implementations can wrap services like LaunchDarkly.
+type FeatureProvider interface {
+	Enabled(feature string) bool
+}
+
+// FeatureProviderFunc is an adapter to use ordinary functions as FeatureProvider.
+type FeatureProviderFunc func(feature string) bool
+
+func (f FeatureProviderFunc) Enabled(feature string) bool {
+	return f(feature)
+}
+
+// EnvFeatureProvider enables the features whose <PREFIX>FEATURE_<NAME> environment variable is true.
+//
+// For example, with the MYAPP prefix the "new-planner" feature is enabled by MYAPP_FEATURE_NEW_PLANNER=true.
+var EnvFeatureProvider FeatureProvider = FeatureProviderFunc(func(feature string) bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(prefix + "FEATURE_" + envRep.Replace(strings.ToUpper(feature))))
+
+	return enabled
+})
+
+var features = EnvFeatureProvider
+
+// SetFeatureProvider sets the provider Define asks whether the fields tagged with flagfeature get a flag.
+//
+// A nil provider restores EnvFeatureProvider.
+func SetFeatureProvider(p FeatureProvider) {
+	if p == nil {
+		p = EnvFeatureProvider
+	}
+	features = p
+}
@@ -0,0 +1,76 @@
+package autoflags
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagGatedRequiredAnnotation = "___flaggatedrequired"
+)
+
+// applyGates hands the required marks of the flags in gated groups over to their gates.
+//
+// Cobra would otherwise require them even when the group is disabled.
+func applyGates(c *cobra.Command) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		group, ok := f.Annotations[FlagGroupAnnotation]
+		if !ok {
+			return
+		}
+		if _, gated := s.gates[group[0]]; !gated {
+			return
+		}
+		if required, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok && required[0] == "true" {
+			delete(f.Annotations, cobra.BashCompOneRequiredFlag)
+			_ = c.Flags().SetAnnotation(f.Name, FlagGatedRequiredAnnotation, []string{"true"})
+		}
+	})
+}
+
+// gateOf returns the name of the flag gating the input group, if any.
+func gateOf(c *cobra.Command, group string) (string, bool) {
+	s, ok := scopes[c]
+	if !ok {
+		return "", false
+	}
+	gate, ok := s.gates[group]
+
+	return gate, ok
+}
+
+// checkGates errors when the required flags of the enabled groups are not set, reporting every such group.
+func checkGates(c *cobra.Command) error {
+	s := getScope(c)
+	provenance, _ := Provenance(c)
+	missing := map[string][]string{}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if _, ok := f.Annotations[FlagGatedRequiredAnnotation]; !ok {
+			return
+		}
+		group := f.Annotations[FlagGroupAnnotation][0]
+		if !cast.ToBool(resolvedValue(c, c.Flags().Lookup(s.gates[group]))) || provenance[f.Name] != SourceDefault {
+			return
+		}
+		missing[group] = append(missing[group], f.Name)
+	})
+
+	groups := make([]string, 0, len(missing))
+	for group := range missing {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	errs := make([]error, 0, len(groups))
+	for _, group := range groups {
+		errs = append(errs, fmt.Errorf(`required flag(s) "%s" not set, as the %s group is enabled by --%s`, strings.Join(missing[group], `", "`), group, s.gates[group]))
+	}
+
+	return errors.Join(errs...)
+}
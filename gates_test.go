@@ -0,0 +1,60 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestGroupGate() {
+	c := &cobra.Command{Use: "gate"}
+	opts := &gatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Nil(c.ValidateRequiredFlags())
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Contains(c.UsageTemplate(), "Metrics Flags (enable with --enable-metrics):\n      --enable-metrics   enable metrics{{end}}")
+	suite.NotContains(c.UsageTemplate(), "--metrics.endpoint")
+
+	c = &cobra.Command{Use: "gate"}
+	opts = &gatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--enable-metrics"}))
+	suite.EqualError(Unmarshal(c, opts), `required flag(s) "metrics.endpoint" not set, as the Metrics group is enabled by --enable-metrics`)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--metrics.endpoint", "localhost"}))
+	suite.Nil(Unmarshal(c, opts))
+}
+
+func (suite *UnmarshalSuite) TestGroupGates() {
+	c := &cobra.Command{Use: "gates"}
+	opts := &multiGatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--enable-metrics", "--enable-tracing"}))
+	suite.EqualError(Unmarshal(c, opts), "required flag(s) \"metrics.endpoint\" not set, as the Metrics group is enabled by --enable-metrics\n"+
+		"required flag(s) \"tracing.endpoint\" not set, as the Tracing group is enabled by --enable-tracing")
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--metrics.endpoint", "localhost"}))
+	suite.EqualError(Unmarshal(c, opts), `required flag(s) "tracing.endpoint" not set, as the Tracing group is enabled by --enable-tracing`)
+}
+
+type metricsOptions struct {
+	Endpoint string `flagrequired:"true"`
+}
+
+type gatedOptions struct {
+	fixture
+
+	Enabled bool           `flag:"enable-metrics" flagdescr:"enable metrics" flaggroup:"Metrics" flaggroupgate:"Metrics"`
+	Metrics metricsOptions `flaggroup:"Metrics"`
+}
+
+type tracingOptions struct {
+	Endpoint string `flagrequired:"true"`
+}
+
+type multiGatedOptions struct {
+	fixture
+
+	Metrics       metricsOptions `flaggroup:"Metrics"`
+	EnableMetrics bool           `flag:"enable-metrics" flaggroup:"Metrics" flaggroupgate:"Metrics"`
+	Tracing       tracingOptions `flaggroup:"Tracing"`
+	EnableTracing bool           `flag:"enable-tracing" flaggroup:"Tracing" flaggroupgate:"Tracing"`
+}
@@ -1,6 +1,8 @@
 package autoflags
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -45,3 +47,30 @@ func Groups(c *cobra.Command) map[string]*pflag.FlagSet {
 
 	return groups
 }
+
+// groupTitle returns the title the input command already uses for the input group, if any.
+//
+// Titles differing only by case or by surrounding spaces name the same group, so that the options structs
+// defined onto the same command share its help section, titled as the first of them did.
+func groupTitle(c *cobra.Command, title string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return ""
+	}
+	res := ""
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if group, ok := f.Annotations[FlagGroupAnnotation]; ok && res == "" && strings.EqualFold(group[0], title) {
+			res = group[0]
+		}
+	})
+	if res != "" {
+		return res
+	}
+	for group := range getScope(c).gates {
+		if strings.EqualFold(group, title) {
+			return group
+		}
+	}
+
+	return title
+}
@@ -1,11 +1,20 @@
 package autoflags
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"reflect"
+	"strings"
 
+	"github.com/leodido/autoflags/values"
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/exp/slog"
 )
 
 const (
@@ -13,14 +22,117 @@ const (
 )
 
 var decodeHookRegistry = map[string]mapstructure.DecodeHookFunc{
-	"StringToZapcoreLevelHookFunc": StringToZapcoreLevelHookFunc(),
+	"StringToZapcoreLevelHookFunc":    StringToZapcoreLevelHookFunc(),
+	"StringToSlogLevelHookFunc":       StringToSlogLevelHookFunc(),
+	"StringToTriStateHookFunc":        StringToTriStateHookFunc(),
+	"StringToTimeHookFunc":            StringToTimeHookFunc(),
+	"StringToByteSizeHookFunc":        StringToByteSizeHookFunc(),
+	"StringToIPHookFunc":              StringToIPHookFunc(),
+	"StringToIPNetHookFunc":           StringToIPNetHookFunc(),
+	"StringToTextUnmarshalerHookFunc": StringToTextUnmarshalerHookFunc(),
+	"FlagDecoderHookFunc":             FlagDecoderHookFunc(),
 }
 
-func inferDecodeHooks(c *cobra.Command, name, typename string) {
-	switch typename {
-	case "zapcore.Level":
-		_ = c.Flags().SetAnnotation(name, FlagDecodeHookAnnotation, []string{"StringToZapcoreLevelHookFunc"})
+// scalarDecodeHooks maps the scalar types to the names of the decode hooks handling them.
+//
+// It is keyed by the types themselves, since types of different packages can share their names (eg., two Level types).
+var scalarDecodeHooks = map[reflect.Type]string{
+	reflect.TypeOf(zapcore.Level(0)):    "StringToZapcoreLevelHookFunc",
+	reflect.TypeOf(slog.Level(0)):       "StringToSlogLevelHookFunc",
+	reflect.TypeOf(values.TriState("")): "StringToTriStateHookFunc",
+	reflect.TypeOf(values.ByteSize(0)):  "StringToByteSizeHookFunc",
+	reflect.TypeOf(net.IP{}):            "StringToIPHookFunc",
+	reflect.TypeOf(net.IPNet{}):         "StringToIPNetHookFunc",
+}
+
+// inferDecodeHooks annotates the input flag with the decode hook handling its type.
+//
+// The hooks of the scalar types also apply to the slices, arrays, and maps of them (eg., []zapcore.Level, map[string]slog.Level),
+// since the decoding runs them on each element.
+// So does FlagDecoderHookFunc for the types implementing FlagDecoder.
+func inferDecodeHooks(c *cobra.Command, name string, typ reflect.Type) {
+	for {
+		if hook, ok := scalarDecodeHooks[typ]; ok {
+			_ = c.Flags().SetAnnotation(name, FlagDecodeHookAnnotation, []string{hook})
+
+			return
+		}
+		if isFlagDecoder(typ) {
+			_ = c.Flags().SetAnnotation(name, FlagDecodeHookAnnotation, []string{"FlagDecoderHookFunc"})
+
+			return
+		}
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+		default:
+			return
+		}
+	}
+}
+
+// flagDecodeHooks returns the decode hooks annotated on the input flag of the input command.
+func flagDecodeHooks(c *cobra.Command, f *pflag.Flag) []mapstructure.DecodeHookFunc {
+	res := []mapstructure.DecodeHookFunc{}
+	for _, decodeHook := range f.Annotations[FlagDecodeHookAnnotation] {
+		if decodeHookFunc, ok := lookupDecodeHook(c, decodeHook); ok {
+			res = append(res, decodeHookFunc)
+		}
+	}
+
+	return res
+}
+
+// lookupDecodeHook finds the decode hook with the input name among the ones of the input command, or the registered ones.
+func lookupDecodeHook(c *cobra.Command, name string) (mapstructure.DecodeHookFunc, bool) {
+	if s, ok := scopes[c]; ok {
+		if decodeHookFunc, ok := s.hooks[name]; ok {
+			return decodeHookFunc, true
+		}
 	}
+	decodeHookFunc, ok := decodeHookRegistry[name]
+
+	return decodeHookFunc, ok
+}
+
+// withDefaultDecodeHooks appends the viper default decode hooks to the input ones.
+//
+// Viper drops its defaults as soon as a custom decode hook is given, so they need to be added back.
+// It also appends the hook decoding the maps coming from the environment.
+func withDefaultDecodeHooks(hooks []mapstructure.DecodeHookFunc) []mapstructure.DecodeHookFunc {
+	res := append([]mapstructure.DecodeHookFunc{}, hooks...)
+
+	return append(res,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		StringToMapHookFunc(),
+	)
+}
+
+// decodeValue decodes the input value into a new value of the given type, as Unmarshal would with the input decode hooks.
+func decodeValue(c *cobra.Command, f *pflag.Flag, typ reflect.Type, input interface{}, hooks ...mapstructure.DecodeHookFunc) error {
+	out := reflect.New(typ)
+	if layout, ok := timeLayout(f); ok {
+		if val, ok := input.(string); ok {
+			input = layoutTime{value: val, layout: layout, loc: timeZone(f)}
+		}
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(withDefaultDecodeHooks(append(hooks, flagDecodeHooks(c, f)...))...),
+		WeaklyTypedInput: true,
+		Result:           out.Interface(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(input); err != nil {
+		// Strip the empty field name mapstructure reports, the caller knows better what is being decoded
+		msg := strings.TrimPrefix(err.Error(), "error decoding '': ")
+
+		return errors.New(strings.Replace(msg, " '' ", " ", 1))
+	}
+
+	return nil
 }
 
 type DecodeHookFuncType func(reflect.Type, reflect.Type, interface{}) (interface{}, error)
@@ -37,3 +149,169 @@ func StringToZapcoreLevelHookFunc() mapstructure.DecodeHookFunc {
 		return zapcore.ParseLevel(data.(string))
 	}
 }
+
+// StringToSlogLevelHookFunc decodes strings (eg., "debug", "warn+2") into slog.Level.
+func StringToSlogLevelHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(slog.LevelInfo) {
+			return data, nil
+		}
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(reflect.ValueOf(data).String())); err != nil {
+			return nil, err
+		}
+
+		return l, nil
+	}
+}
+
+// StringToTriStateHookFunc decodes strings and bools into values.TriState.
+func StringToTriStateHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(values.Auto) {
+			return data, nil
+		}
+		switch f.Kind() {
+		case reflect.String:
+			return values.ParseTriState(reflect.ValueOf(data).String())
+		case reflect.Bool:
+			if reflect.ValueOf(data).Bool() {
+				return values.On, nil
+			}
+
+			return values.Off, nil
+		}
+
+		return data, nil
+	}
+}
+
+// StringToByteSizeHookFunc decodes strings (eg., "512MiB", "10MB") into values.ByteSize.
+//
+// Numbers decode as they are, in bytes.
+func StringToByteSizeHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(values.Byte) {
+			return data, nil
+		}
+
+		return values.ParseByteSize(reflect.ValueOf(data).String())
+	}
+}
+
+// StringToIPHookFunc decodes strings into net.IP, the empty string being the nil address.
+func StringToIPHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != ipType {
+			return data, nil
+		}
+
+		return parseIP(reflect.ValueOf(data).String())
+	}
+}
+
+// StringToIPNetHookFunc decodes strings in CIDR notation (eg., 10.0.0.0/8) into *net.IPNet, the empty string being the nil network.
+func StringToIPNetHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || (t != ipNetType && t != ipNetType.Elem()) {
+			return data, nil
+		}
+		n, err := parseIPNet(reflect.ValueOf(data).String())
+		if err != nil || n == nil {
+			return nil, err
+		}
+		if t == ipNetType {
+			return n, nil
+		}
+
+		return *n, nil
+	}
+}
+
+// NumberRangeHookFunc checks that the numbers fit the integer and float32 fields they decode into.
+//
+// Numbers out of range fail to decode, rather than silently wrapping around, unless saturate is true.
+// In such case they become the closest value the field can hold (eg., 300 becomes 255 for uint8 fields).
+func NumberRangeHookFunc(saturate bool) mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if t.Kind() == reflect.Float32 {
+			if f.Kind() != reflect.Float64 {
+				return data, nil
+			}
+			n := reflect.ValueOf(data).Float()
+			if math.Abs(n) <= math.MaxFloat32 || math.IsInf(n, 0) {
+				return data, nil
+			}
+			if !saturate {
+				return nil, fmt.Errorf("%v is out of range for %s", data, t)
+			}
+
+			return reflect.ValueOf(math.Copysign(math.MaxFloat32, n)).Convert(t).Interface(), nil
+		}
+
+		var lo, hi *big.Int
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			hi = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(t.Bits()-1)), big.NewInt(1))
+			lo = new(big.Int).Neg(new(big.Int).Add(hi, big.NewInt(1)))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			hi = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(t.Bits())), big.NewInt(1))
+			lo = big.NewInt(0)
+		default:
+			return data, nil
+		}
+
+		n := new(big.Int)
+		v := reflect.ValueOf(data)
+		switch f.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n.SetInt64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n.SetUint64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			if math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0) {
+				return data, nil
+			}
+			big.NewFloat(v.Float()).Int(n)
+		case reflect.String:
+			if _, ok := n.SetString(strings.TrimSpace(v.String()), 0); !ok {
+				// Leave what is not an integer to the decoding
+				return data, nil
+			}
+		default:
+			return data, nil
+		}
+
+		var bound *big.Int
+		switch {
+		case n.Cmp(lo) < 0:
+			bound = lo
+		case n.Cmp(hi) > 0:
+			bound = hi
+		default:
+			return data, nil
+		}
+		if !saturate {
+			return nil, fmt.Errorf("%v is out of range for %s", data, t)
+		}
+		res := reflect.New(t).Elem()
+		if lo.Sign() < 0 {
+			res.SetInt(bound.Int64())
+		} else {
+			res.SetUint(bound.Uint64())
+		}
+
+		return res.Interface(), nil
+	}
+}
+
+// StringToMapHookFunc decodes comma-separated lists of key=value pairs into maps.
+func StringToMapHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t.Kind() != reflect.Map {
+			return data, nil
+		}
+
+		return mapEntries(reflect.ValueOf(data).String())
+	}
+}
@@ -0,0 +1,200 @@
+package autoflags
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/leodido/autoflags/values"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/exp/slog"
+)
+
+func (suite *UnmarshalSuite) TestTriState() {
+	suite.useConfigFile("config.yaml", "color: false\ncompress: true\n")
+	suite.T().Setenv("TTY", "yes")
+
+	c := &cobra.Command{Use: "tristate"}
+	opts := &triStateOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("tristate", c.Flags().Lookup("color").Value.Type())
+	suite.Equal("auto", c.Flags().Lookup("color").DefValue)
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(values.Off, opts.Color)
+	suite.Equal(values.On, opts.TTY)
+	suite.Equal(values.On, opts.Compress)
+	suite.Equal(values.Auto, opts.Other)
+	suite.True(opts.Other.Resolve(func() bool { return true }))
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--color", "on"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(values.On, opts.Color)
+	suite.NotNil(c.Flags().Parse([]string{"--color", "maybe"}))
+
+	suite.T().Setenv("TTY", "maybe")
+	suite.ErrorContains(Unmarshal(c, opts), `invalid value "maybe" for env TTY (flag --tty): invalid tristate "maybe"`)
+}
+
+type byteSizeOptions struct {
+	fixture
+
+	Buffer values.ByteSize `default:"64KiB"`
+	Limit  values.ByteSize `flagenv:"true"`
+	Upload values.ByteSize
+	Chunk  values.ByteSize
+}
+
+func (suite *UnmarshalSuite) TestByteSize() {
+	suite.useConfigFile("config.yaml", "upload: 1048576\nchunk: 1.5 kb\n")
+	suite.T().Setenv("LIMIT", "10MB")
+
+	c := &cobra.Command{Use: "bytesize"}
+	opts := &byteSizeOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("bytesize", c.Flags().Lookup("buffer").Value.Type())
+	suite.Equal("64KiB", c.Flags().Lookup("buffer").DefValue)
+	suite.Contains(c.UsageString(), "--upload bytesize   \n")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(64*values.KiB, opts.Buffer)
+	suite.Equal(10*values.MB, opts.Limit)
+	suite.Equal(values.MiB, opts.Upload)
+	suite.Equal(values.ByteSize(1500), opts.Chunk)
+	suite.Equal("1MiB", opts.Upload.String())
+	suite.Equal("1500B", opts.Chunk.String())
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--buffer", "2gib"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(2*values.GiB, opts.Buffer)
+	suite.EqualError(c.Flags().Parse([]string{"--buffer", "2XB"}), `invalid argument "2XB" for "--buffer" flag: invalid byte size "2XB": unknown unit "XB"`)
+
+	suite.T().Setenv("LIMIT", "many")
+	suite.ErrorContains(Unmarshal(c, opts), `invalid value "many" for env LIMIT (flag --limit): invalid byte size "many": must be a number followed by an optional unit`)
+}
+
+func (suite *UnmarshalSuite) TestScalarDecodeHooksByType() {
+	// Two distinct types sharing their name, as the types of different packages can
+	first := func() reflect.Type {
+		type level string
+
+		return reflect.TypeOf(level(""))
+	}()
+	second := func() reflect.Type {
+		type level string
+
+		return reflect.TypeOf(level(""))
+	}()
+	suite.Require().Equal(first.String(), second.String())
+	scalarDecodeHooks[first] = "StringToTriStateHookFunc"
+	suite.T().Cleanup(func() { delete(scalarDecodeHooks, first) })
+
+	c := &cobra.Command{Use: "levels"}
+	c.Flags().String("first", "", "")
+	c.Flags().String("second", "", "")
+	inferDecodeHooks(c, "first", first)
+	inferDecodeHooks(c, "second", second)
+	suite.Equal([]string{"StringToTriStateHookFunc"}, c.Flags().Lookup("first").Annotations[FlagDecodeHookAnnotation])
+	suite.Empty(c.Flags().Lookup("second").Annotations[FlagDecodeHookAnnotation])
+
+	// Registering both keeps their decode hooks apart
+	delete(scalarDecodeHooks, first)
+	define := func(c *cobra.Command, typename, name, short, descr string) {}
+	decoder := func(res string) mapstructure.DecodeHookFunc {
+		return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+			return res, nil
+		}
+	}
+	RegisterType(first, define, decoder("first"))
+	RegisterType(second, define, decoder("second"))
+	RegisterType(second, define, decoder("second again"))
+	suite.T().Cleanup(func() {
+		for _, typ := range []reflect.Type{first, second} {
+			delete(registeredTypes, typ)
+			delete(decodeHookRegistry, scalarDecodeHooks[typ])
+			delete(scalarDecodeHooks, typ)
+		}
+	})
+	suite.Equal("RegisteredTypeHookFunc[autoflags.level]", scalarDecodeHooks[first])
+	suite.Equal("RegisteredTypeHookFunc[autoflags.level#2]", scalarDecodeHooks[second])
+	for typ, expected := range map[reflect.Type]string{first: "first", second: "second again"} {
+		res, err := mapstructure.DecodeHookExec(decodeHookRegistry[scalarDecodeHooks[typ]], reflect.ValueOf(""), reflect.ValueOf(""))
+		suite.Require().Nil(err)
+		suite.Equal(expected, res)
+	}
+}
+
+func (suite *UnmarshalSuite) TestLevelCollections() {
+	c := &cobra.Command{Use: "levels"}
+	opts := &levelsOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--levels", "debug,error", "--loggers", "db=warn,http=debug+2"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]zapcore.Level{zapcore.DebugLevel, zapcore.ErrorLevel}, opts.Levels)
+	suite.Equal(map[string]slog.Level{"db": slog.LevelWarn, "http": slog.LevelDebug + 2}, opts.Loggers)
+
+	suite.useConfigFile("config.yaml", "levels: [warn]\nloggers:\n  db: error\n")
+	suite.T().Setenv("LEVELS", "info,warn")
+	c = &cobra.Command{Use: "levels"}
+	opts = &levelsOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]zapcore.Level{zapcore.InfoLevel, zapcore.WarnLevel}, opts.Levels)
+	suite.Equal(map[string]slog.Level{"db": slog.LevelError}, opts.Loggers)
+}
+
+type levelsOptions struct {
+	fixture
+
+	Levels  []zapcore.Level       `flagcustom:"true" flagenv:"true"`
+	Loggers map[string]slog.Level `flagcustom:"true"`
+}
+
+func (o *levelsOptions) DefineLevels(c *cobra.Command, typename, name, short, descr string) {
+	c.Flags().StringSliceP(name, short, nil, descr)
+}
+
+func (o *levelsOptions) DefineLoggers(c *cobra.Command, typename, name, short, descr string) {
+	c.Flags().StringToStringP(name, short, nil, descr)
+}
+
+func (suite *UnmarshalSuite) TestNumberRange() {
+	file := suite.useConfigFile("config.yaml", "small: 300\ntiny: -40000\nratio: 1e300\n")
+	c := &cobra.Command{Use: "range"}
+	opts := &rangeOptions{}
+	suite.Require().Nil(Define(c, opts))
+	err := Unmarshal(c, opts)
+	suite.ErrorContains(err, "error decoding 'Small': 300 is out of range for uint8 (from config "+file+":1:1)")
+	suite.ErrorContains(err, "error decoding 'Tiny': -40000 is out of range for int16")
+	suite.ErrorContains(err, "error decoding 'Ratio': 1e+300 is out of range for float32")
+
+	c = &cobra.Command{Use: "range"}
+	opts = &rangeOptions{}
+	suite.Require().Nil(Define(c, opts, WithSaturation()))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(uint8(255), opts.Small)
+	suite.Equal(int16(-32768), opts.Tiny)
+	suite.Equal(float32(math.MaxFloat32), opts.Ratio)
+
+	suite.useConfigFile("config.json", `{"small": 12, "tiny": 1e6}`)
+	c = &cobra.Command{Use: "range"}
+	opts = &rangeOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.ErrorContains(Unmarshal(c, opts), "error decoding 'Tiny': 1e+06 is out of range for int16")
+}
+
+type rangeOptions struct {
+	fixture
+
+	Small uint8
+	Tiny  int16
+	Ratio float32
+}
+
+type triStateOptions struct {
+	fixture
+
+	Color    values.TriState
+	TTY      values.TriState `flagenv:"true"`
+	Compress values.TriState
+	Other    values.TriState
+}
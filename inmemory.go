@@ -0,0 +1,37 @@
+package autoflags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// WithValues sets in memory the values of the flags of the input command, by flag name or struct path (eg., "server.port").
+//
+// The values form the SourceValues source, which has the highest precedence unless WithPrecedence (or the flagprecedence tag) lists it.
+// They let tests and embedding applications set options without touching the global viper instance, the environment, or config files.
+// Call it after Define, and before Unmarshal. Calling it again adds to the values already set.
+func WithValues(c *cobra.Command, values map[string]interface{}) error {
+	unmarshalMu.Lock()
+	defer unmarshalMu.Unlock()
+	s, ok := scopes[c]
+	if !ok {
+		return fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	names := map[string]string{}
+	for key := range values {
+		name := key
+		if c.Flags().Lookup(name) == nil {
+			if name, ok = s.paths[strings.ToLower(key)]; !ok {
+				return fmt.Errorf("couldn't set %s: no such flag", key)
+			}
+		}
+		names[key] = name
+	}
+	for key, val := range values {
+		s.values[names[key]] = val
+	}
+
+	return nil
+}
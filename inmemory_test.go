@@ -0,0 +1,30 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestWithValues() {
+	suite.T().Setenv("SERVER_PORT", "2")
+
+	c := &cobra.Command{Use: "values"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(WithValues(c, map[string]interface{}{"server.port": 9}))
+	suite.Require().Nil(c.Flags().Parse([]string{"--server.port", "3"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(9, opts.Server.Port)
+	provenance, err := Provenance(c)
+	suite.Require().Nil(err)
+	suite.Equal(SourceValues, provenance["server.port"])
+
+	c = &cobra.Command{Use: "values"}
+	opts = &portOptions{}
+	suite.Require().Nil(Define(c, opts, WithPrecedence(SourceEnv, SourceValues)))
+	suite.Require().Nil(WithValues(c, map[string]interface{}{"server.port": "9"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(2, opts.Server.Port)
+
+	suite.EqualError(WithValues(c, map[string]interface{}{"server.host": "x"}), "couldn't set server.host: no such flag")
+	suite.EqualError(WithValues(&cobra.Command{Use: "undefined"}, nil), "couldn't find a scope for undefined")
+}
@@ -0,0 +1,73 @@
+package autoflags
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// SkipReason explains why Define did not generate a flag for a struct field.
+type SkipReason string
+
+const (
+	SkipIgnored         SkipReason = "ignored by flagignore tag"
+	SkipExcluded        SkipReason = "excluded for the command"
+	SkipUnsupported     SkipReason = "unsupported type"
+	SkipMissingHook     SkipReason = "missing custom definition hook"
+	SkipFeatureDisabled SkipReason = "feature disabled"
+)
+
+// SkippedField describes a struct field that has no corresponding flag.
+type SkippedField struct {
+	Path   string
+	Type   string
+	Reason SkipReason
+}
+
+func (s SkippedField) String() string {
+	if s.Type != "" {
+		return fmt.Sprintf("%s (%s): %s", s.Path, s.Type, s.Reason)
+	}
+
+	return fmt.Sprintf("%s: %s", s.Path, s.Reason)
+}
+
+// Inspection is a snapshot of what autoflags knows about a command.
+type Inspection struct {
+	Skipped []SkippedField
+	// Origins maps each flag name to the type of the options struct that defined it
+	Origins map[string]string
+	// Precedence is the active order of the sources, from the highest to the lowest
+	Precedence []Source
+	// Warnings lists the deprecations and the decoding failures tolerated while unmarshalling
+	Warnings []string
+	// MergePolicies maps the flags holding slices or maps to how their command line values combine with the other sources
+	MergePolicies map[string]string
+}
+
+// Inspect returns what Define recorded for the input command.
+func Inspect(c *cobra.Command) (*Inspection, error) {
+	s, ok := scopes[c]
+	if !ok {
+		return nil, fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+
+	res := &Inspection{
+		Skipped:       append([]SkippedField{}, s.skipped...),
+		Origins:       map[string]string{},
+		Precedence:    append([]Source{}, s.precedence()...),
+		Warnings:      append([]string{}, s.warnings...),
+		MergePolicies: map[string]string{},
+	}
+	for name, origin := range s.origins {
+		res.Origins[name] = origin
+	}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if policy, ok := mergePolicy(c, f); ok {
+			res.MergePolicies[f.Name] = policy
+		}
+	})
+
+	return res, nil
+}
@@ -0,0 +1,288 @@
+package autoflags
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagKeyringAnnotation = "___flagkeyring"
+)
+
+// Keyring stores the secrets in a credential manager, by service and account.
+//
+// Get returns the empty string, and no error, when no secret is stored for the service and account.
+type Keyring interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// SystemKeyring is the credential manager of the OS.
+//
+// It runs the security command on macOS, and secret-tool (libsecret) on Linux.
+var SystemKeyring Keyring = commandKeyring{goos: runtime.GOOS}
+
+// keyring is the Keyring in use, if any.
+var keyring Keyring
+
+// UseKeyring makes Unmarshal read the secrets from the input keyring (eg., SystemKeyring).
+//
+// It resolves the keyring://service/account references in the values of the secret flags,
+// and supplies the values of the flags tagged flagkeyring:"service/account" that no source sets.
+func UseKeyring(k Keyring) {
+	keyring = k
+	if k == nil {
+		delete(secretsProviders, "keyring")
+
+		return
+	}
+	RegisterSecretsProvider("keyring", SecretsProviderFunc(func(ref string) (string, error) {
+		_, entry, _ := strings.Cut(ref, "://")
+		service, account, err := parseKeyringRef(entry)
+		if err != nil {
+			return "", err
+		}
+		secret, err := k.Get(service, account)
+		if err != nil {
+			return "", err
+		}
+		if secret == "" {
+			return "", fmt.Errorf("no secret in the keyring for %s/%s", service, account)
+		}
+
+		return secret, nil
+	}))
+}
+
+// parseKeyringRef splits the input "service/account" into its parts.
+func parseKeyringRef(ref string) (service, account string, err error) {
+	service, account, ok := strings.Cut(ref, "/")
+	if !ok || service == "" || account == "" {
+		return "", "", fmt.Errorf("invalid keyring reference %q: must be service/account", ref)
+	}
+
+	return service, account, nil
+}
+
+// defineKeyring annotates the flag of the input field with the keyring entry of its flagkeyring tag, marking it as secret.
+func defineKeyring(c *cobra.Command, f reflect.StructField, path, name string) error {
+	ref := f.Tag.Get("flagkeyring")
+	if ref == "" {
+		return nil
+	}
+	if f.Type.Kind() != reflect.String {
+		return fmt.Errorf("invalid flagkeyring tag on %s: only string fields can have it", path)
+	}
+	if _, _, err := parseKeyringRef(ref); err != nil {
+		return fmt.Errorf("invalid flagkeyring tag on %s: %w", path, err)
+	}
+	_ = c.Flags().SetAnnotation(name, FlagKeyringAnnotation, []string{ref})
+	_ = c.Flags().SetAnnotation(name, FlagSecretAnnotation, []string{"true"})
+
+	return nil
+}
+
+// resolveKeyring sets the settings of the flags tagged with flagkeyring that no source sets to the secrets in the keyring in use.
+func resolveKeyring(c *cobra.Command, settings map[string]interface{}) error {
+	if keyring == nil {
+		return nil
+	}
+	s := getScope(c)
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		ref, ok := f.Annotations[FlagKeyringAnnotation]
+		if err != nil || !ok || winner(lookupSources(c, f), s.precedenceOf(f.Name)) != SourceDefault {
+			return
+		}
+		service, account, _ := parseKeyringRef(ref[0])
+		secret, getErr := keyring.Get(service, account)
+		if getErr != nil {
+			err = fmt.Errorf("couldn't read the keyring for --%s: %w", f.Name, getErr)
+
+			return
+		}
+		if secret == "" {
+			return
+		}
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			m[key] = secret
+		})
+	})
+
+	return err
+}
+
+// commandKeyring is a Keyring running the credential manager commands of the OS.
+type commandKeyring struct {
+	goos string
+}
+
+func (k commandKeyring) Get(service, account string) (string, error) {
+	var cmd *exec.Cmd
+	switch k.goos {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", k.unsupported()
+	}
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && k.notFound(exitErr) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+func (k commandKeyring) Set(service, account, secret string) error {
+	var cmd *exec.Cmd
+	switch k.goos {
+	case "darwin":
+		// The interactive mode reads the command from stdin, keeping the secret off the arguments of the process
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", securityQuote(service), securityQuote(account), securityQuote(secret)))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label", service+"/"+account, "service", service, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return k.unsupported()
+	}
+
+	return runCommand(cmd)
+}
+
+func (k commandKeyring) Delete(service, account string) error {
+	var cmd *exec.Cmd
+	switch k.goos {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", service, "-a", account)
+	case "linux":
+		cmd = exec.Command("secret-tool", "clear", "service", service, "account", account)
+	default:
+		return k.unsupported()
+	}
+
+	return runCommand(cmd)
+}
+
+// securityQuote quotes the input argument for the interactive mode of the security command.
+func securityQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// notFound tells whether the input failure is the one of the lookups of missing entries.
+//
+// The security command exits with errSecItemNotFound (44), while secret-tool exits without any message.
+func (k commandKeyring) notFound(err *exec.ExitError) bool {
+	if k.goos == "darwin" {
+		return err.ExitCode() == 44
+	}
+
+	return len(bytes.TrimSpace(err.Stderr)) == 0
+}
+
+func (k commandKeyring) unsupported() error {
+	return fmt.Errorf("no keyring available on %s", k.goos)
+}
+
+// runCommand runs the input command, reporting its stderr on failure.
+func runCommand(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s", cmd.Path, msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// keyringOrSystem returns the keyring in use, or the one of the OS.
+func keyringOrSystem() Keyring {
+	if keyring != nil {
+		return keyring
+	}
+
+	return SystemKeyring
+}
+
+// readSecret reads a line from the input reader, without echoing it when it is a terminal.
+func readSecret(in io.Reader) (string, error) {
+	var secret string
+	var err error
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		secret, err = readPassword(int(f.Fd()))
+	} else {
+		secret, err = bufio.NewReader(in).ReadString('\n')
+	}
+
+	return strings.TrimRight(secret, "\r\n"), err
+}
+
+// NewAuthCmd returns an "auth" command storing the credentials of the input service in the keyring in use, or in the one of the OS.
+//
+// Its "login ACCOUNT" subcommand reads the secret from stdin (without echoing it on terminals), while its "logout ACCOUNT" subcommand deletes it.
+// Tag the fields with flagkeyring:"service/account" to read them back.
+func NewAuthCmd(service string) *cobra.Command {
+	auth := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage the credentials stored in the keyring",
+	}
+	auth.AddCommand(&cobra.Command{
+		Use:   "login ACCOUNT",
+		Short: "Store the credential of an account, read from stdin, in the keyring",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			c.PrintErrf("Enter the secret for %s/%s: ", service, args[0])
+			secret, err := readSecret(c.InOrStdin())
+			if err != nil && secret == "" {
+				return fmt.Errorf("couldn't read the secret: %w", err)
+			}
+			if secret == "" {
+				return fmt.Errorf("couldn't store an empty secret")
+			}
+			if err := keyringOrSystem().Set(service, args[0], secret); err != nil {
+				return err
+			}
+			c.PrintErrln()
+			c.Printf("Stored the credential of %s/%s\n", service, args[0])
+
+			return nil
+		},
+	})
+	auth.AddCommand(&cobra.Command{
+		Use:   "logout ACCOUNT",
+		Short: "Delete the credential of an account from the keyring",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := keyringOrSystem().Delete(service, args[0]); err != nil {
+				return err
+			}
+			c.Printf("Deleted the credential of %s/%s\n", service, args[0])
+
+			return nil
+		},
+	})
+
+	return auth
+}
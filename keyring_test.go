@@ -0,0 +1,65 @@
+package autoflags
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/leodido/autoflags/autoflagstest"
+	"github.com/spf13/cobra"
+)
+
+type keyringOptions struct {
+	fixture
+
+	Token  string `flagkeyring:"app/token"`
+	APIKey string `flagkeyring:"app/apikey"`
+	DBPass string `flagsecret:"true"`
+}
+
+type badKeyringOptions struct {
+	fixture
+
+	Port int `flagkeyring:"app/port"`
+}
+
+func (suite *UnmarshalSuite) TestKeyring() {
+	k := autoflagstest.NewKeyring(map[string]string{"app/token": "t0k3n", "db/admin": "pa55"})
+	UseKeyring(k)
+	suite.T().Cleanup(func() {
+		UseKeyring(nil)
+	})
+	suite.useConfigFile("config.yaml", "dbpass: keyring://db/admin\n")
+
+	c := &cobra.Command{Use: "keyring"}
+	opts := &keyringOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--apikey", "explicit"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("t0k3n", opts.Token)
+	suite.Equal("explicit", opts.APIKey)
+	suite.Equal("pa55", opts.DBPass)
+	suite.True(isSecretFlag(c.Flags().Lookup("token")))
+
+	suite.useConfigFile("config.yaml", "dbpass: keyring://db/missing\n")
+	suite.EqualError(Unmarshal(c, opts), "couldn't resolve the secret of --dbpass: no secret in the keyring for db/missing")
+
+	auth := NewAuthCmd("app")
+	out := &bytes.Buffer{}
+	auth.SetOut(out)
+	auth.SetErr(&bytes.Buffer{})
+	auth.SetIn(strings.NewReader("rotated\n"))
+	auth.SetArgs([]string{"login", "token"})
+	suite.Require().Nil(auth.Execute())
+	suite.Equal("Stored the credential of app/token\n", out.String())
+	secret, _ := k.Get("app", "token")
+	suite.Equal("rotated", secret)
+	auth.SetArgs([]string{"logout", "token"})
+	suite.Require().Nil(auth.Execute())
+	secret, _ = k.Get("app", "token")
+	suite.Empty(secret)
+
+	suite.EqualError(Define(&cobra.Command{Use: "bad"}, &badKeyringOptions{}), "invalid flagkeyring tag on port: only string fields can have it")
+
+	// The secrets go to the security command via stdin, quoted
+	suite.Equal(`"a \"b\" \\c"`, securityQuote(`a "b" \c`))
+}
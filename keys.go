@@ -0,0 +1,98 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// KeyAliases lists the config keys a flag reads, that config files can use interchangeably.
+//
+// Key is the canonical one, the flag name, that viper keys the value by.
+// Aliases are the struct paths of the field (eg., "nest.deep.deep" for --deep), when they differ from the flag name.
+type KeyAliases struct {
+	Command string   `json:"command"`
+	Key     string   `json:"key"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// ConfigKeyAliases returns the config keys of the flags of the command tree rooted at the input command, by command and flag name.
+//
+// A config file setting either the canonical key or one of its aliases sets the same value.
+// When it sets more than one of them, the canonical key wins, and ValidateConfig reports the ambiguity.
+func ConfigKeyAliases(root *cobra.Command) []KeyAliases {
+	res := []KeyAliases{}
+	for _, c := range walkTree(root) {
+		s, ok := scopes[c]
+		if !ok {
+			continue
+		}
+		c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			res = append(res, KeyAliases{Command: c.CommandPath(), Key: f.Name, Aliases: flagAliases(s, f.Name)})
+		})
+	}
+
+	return res
+}
+
+// flagAliases returns the struct paths aliasing the input flag, sorted.
+func flagAliases(s *scope, name string) []string {
+	res := []string{}
+	for path, n := range s.aliases {
+		if n == name {
+			res = append(res, path)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// ambiguousKeys returns the config keys of the input flag the input settings set, when they set more than one.
+func ambiguousKeys(c *cobra.Command, f *pflag.Flag, settings map[string]interface{}) []string {
+	s := getScope(c)
+	typ, ok := s.types[f.Name]
+	maps := ok && typ.Kind() == reflect.Map
+	res := []string{}
+	for _, key := range append([]string{f.Name}, flagAliases(s, f.Name)...) {
+		if _, ok := lookupSetting(settings, key, maps); ok {
+			res = append(res, key)
+		}
+	}
+	if len(res) < 2 {
+		return nil
+	}
+
+	return res
+}
+
+// NewConfigKeysCmd returns a "keys" command printing the config keys of the flags of its root command, along with their aliases.
+//
+// Add it under the config command of the application (eg., "app config keys").
+func NewConfigKeysCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keys",
+		Short: "List the config keys and their aliases",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			command := ""
+			for _, k := range ConfigKeyAliases(rootOf(c)) {
+				if k.Command != command {
+					command = k.Command
+					c.Println(command)
+				}
+				line := fmt.Sprintf("  %s", k.Key)
+				if len(k.Aliases) > 0 {
+					line += fmt.Sprintf(" (aliases: %s)", strings.Join(k.Aliases, ", "))
+				}
+				c.Println(line)
+			}
+
+			return nil
+		},
+	}
+}
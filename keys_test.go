@@ -0,0 +1,58 @@
+package autoflags
+
+import (
+	"bytes"
+
+	"github.com/spf13/cobra"
+)
+
+type aliasServerOptions struct {
+	Port int `flag:"port"`
+}
+
+type aliasOptions struct {
+	fixture
+
+	Server aliasServerOptions
+	Host   string
+}
+
+func (suite *UnmarshalSuite) TestConfigKeyAliases() {
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "serve"}
+	root.AddCommand(sub)
+	opts := &aliasOptions{}
+	suite.Require().Nil(Define(sub, opts))
+	suite.Equal([]KeyAliases{
+		{Command: "app serve", Key: "host", Aliases: []string{}},
+		{Command: "app serve", Key: "port", Aliases: []string{"server.port"}},
+	}, ConfigKeyAliases(root))
+
+	// Config files can use either form
+	for _, content := range []string{"port: 8\n", "server:\n  port: 8\n"} {
+		suite.useConfigFile("config.yaml", content)
+		opts := &aliasOptions{}
+		suite.Require().Nil(Unmarshal(sub, opts))
+		suite.Equal(8, opts.Server.Port)
+		suite.True(ValidateConfig(root).OK())
+	}
+
+	suite.useConfigFile("config.yaml", "port: 8\nserver:\n  port: 9\n")
+	suite.Require().Nil(Unmarshal(sub, opts))
+	suite.Equal(8, opts.Server.Port)
+	report := ValidateConfig(root)
+	suite.Equal([]AmbiguousConfigKey{{Command: "app serve", Flag: "port", Keys: []string{"port", "server.port"}}}, report.Ambiguous)
+	suite.Equal("app serve: --port is set by more than one key (port, server.port), \"port\" wins\n", report.String())
+
+	config := &cobra.Command{Use: "config"}
+	config.AddCommand(NewConfigKeysCmd(), NewConfigValidateCmd())
+	root.AddCommand(config)
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetErr(out)
+	root.SetArgs([]string{"config", "keys"})
+	suite.Require().Nil(root.Execute())
+	suite.Equal("app serve\n  host\n  port (aliases: server.port)\n", out.String())
+	root.SetArgs([]string{"config", "validate"})
+	suite.EqualError(root.Execute(), "invalid config: 0 unknown keys, 0 missing values, 1 ambiguous values")
+}
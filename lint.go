@@ -0,0 +1,64 @@
+package autoflags
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/maps"
+)
+
+// Lint walks a fully assembled command tree and reports ambiguous configurations.
+//
+// It reports:
+//   - flag aliases clashing with the struct path of another flag on the same command
+//   - environment variables bound to differently named flags
+//   - local flags shadowing a persistent flag of an ancestor command
+//
+// It is meant to run in CI (eg., from a test) once every command has been defined.
+func Lint(root *cobra.Command) []error {
+	errs := []error{}
+	envs := map[string]string{}
+
+	for _, c := range walkTree(root) {
+		// Ambiguous aliases
+		if s, ok := scopes[c]; ok {
+			paths := maps.Keys(s.paths)
+			sort.Strings(paths)
+			for _, path := range paths {
+				name := s.paths[path]
+				if name == path {
+					continue
+				}
+				if other, ok := s.aliases[name]; ok {
+					errs = append(errs, fmt.Errorf("%s: alias --%s of %s clashes with the path of flag --%s", c.CommandPath(), name, path, other))
+				}
+			}
+		}
+
+		// Duplicate environment variables
+		c.Flags().VisitAll(func(f *pflag.Flag) {
+			for _, env := range f.Annotations[FlagEnvsAnnotation] {
+				bound := fmt.Sprintf("--%s", f.Name)
+				if prev, ok := envs[env]; ok && prev != bound {
+					errs = append(errs, fmt.Errorf("%s: env %s binds to --%s but it already binds to %s", c.CommandPath(), env, f.Name, prev))
+
+					continue
+				}
+				envs[env] = bound
+			}
+		})
+
+		// Shadowed persistent flags
+		for p := c.Parent(); p != nil; p = p.Parent() {
+			p.PersistentFlags().VisitAll(func(pf *pflag.Flag) {
+				if f := c.Flags().Lookup(pf.Name); f != nil && f != pf {
+					errs = append(errs, fmt.Errorf("%s: flag --%s shadows the persistent flag of %s", c.CommandPath(), f.Name, p.CommandPath()))
+				}
+			})
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,79 @@
+package autoflags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagLockedAnnotation = "___flaglocked"
+)
+
+// lockDown marks the input flags, by name or by struct path, as settable by the system config file only.
+func lockDown(c *cobra.Command, names []string) error {
+	return annotateFlags(c, names, FlagLockedAnnotation, "couldn't lock down %s")
+}
+
+// annotateFlags sets the input annotation on the input flags, by name or by struct path.
+//
+// The input format describes the failure to annotate a flag, given its name, when the command does not have it.
+func annotateFlags(c *cobra.Command, names []string, annotation, format string) error {
+	s := getScope(c)
+	for _, name := range names {
+		flagName := strings.ToLower(name)
+		if byPath, ok := s.paths[flagName]; ok {
+			flagName = byPath
+		}
+		if c.Flags().Lookup(flagName) == nil {
+			return fmt.Errorf(format+": no such flag", name)
+		}
+		_ = c.Flags().SetAnnotation(flagName, annotation, []string{"true"})
+	}
+
+	return nil
+}
+
+// isLockedFlag tells whether the input flag can only be set by the system config file.
+func isLockedFlag(f *pflag.Flag) bool {
+	_, ok := f.Annotations[FlagLockedAnnotation]
+
+	return ok
+}
+
+// checkLockdown errors when the value of a locked flag comes from a source other than the system config file.
+func checkLockdown(c *cobra.Command) error {
+	s := getScope(c)
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || !isLockedFlag(f) {
+			return
+		}
+		switch winner(lookupSources(c, f), s.precedenceOf(f.Name)) {
+		case SourceFlag, SourceEnv:
+		case SourceConfig:
+			if fromSystemConfig(c, f) {
+				return
+			}
+		default:
+			return
+		}
+		err = fmt.Errorf("flag --%s is locked down: only the system config file can set it, not %s", f.Name, origin(c, f))
+	})
+
+	return err
+}
+
+// fromSystemConfig tells whether the config value of the input flag comes from a system-wide config file.
+func fromSystemConfig(c *cobra.Command, f *pflag.Flag) bool {
+	if projectFile != "" {
+		if _, _, found := lookupFlagSetting(c, f, projectSettings); found {
+			return false
+		}
+	}
+	category, ok := ConfigCategory()
+
+	return ok && category == SearchPathSystem
+}
@@ -0,0 +1,50 @@
+package autoflags
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestLockdown() {
+	programData := suite.T().TempDir()
+	suite.T().Setenv("PROGRAMDATA", programData)
+	suite.T().Setenv("SERVER_PORT", "")
+	goos = "windows"
+	suite.T().Cleanup(func() {
+		goos = runtime.GOOS
+		searchApp = ""
+		viper.Reset()
+		configSettings = nil
+	})
+	suite.Require().Nil(os.MkdirAll(filepath.Join(programData, "app"), 0o755))
+	suite.Require().Nil(os.WriteFile(filepath.Join(programData, "app", "config.yaml"), []byte("server:\n  port: 8\n"), 0o600))
+	viper.SetConfigName("config")
+	UseSearchPaths("app")
+	found, _ := UseConfig(nil)
+	suite.Require().True(found)
+
+	suite.EqualError(Define(&cobra.Command{Use: "unknown"}, &portOptions{}, WithLockdown("port")), "couldn't lock down port: no such flag")
+
+	c := &cobra.Command{Use: "locked"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts, WithLockdown("server.port")))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(8, opts.Server.Port)
+
+	suite.T().Setenv("SERVER_PORT", "9")
+	suite.EqualError(Unmarshal(c, opts), "flag --server.port is locked down: only the system config file can set it, not env SERVER_PORT")
+	suite.T().Setenv("SERVER_PORT", "")
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--server.port", "9"}))
+	suite.EqualError(Unmarshal(c, opts), "flag --server.port is locked down: only the system config file can set it, not flag")
+
+	file := suite.useConfigFile("config.yaml", "server:\n  port: 7\n")
+	c = &cobra.Command{Use: "local"}
+	opts = &portOptions{}
+	suite.Require().Nil(Define(c, opts, WithLockdown("server.port")))
+	suite.EqualError(Unmarshal(c, opts), "flag --server.port is locked down: only the system config file can set it, not config "+file+":2:3")
+}
@@ -0,0 +1,56 @@
+package autoflags
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+// LogOptions logs the resolved options of the input command at the info level, for printing the configuration on startup.
+//
+// The attributes are groups mirroring the struct paths of the options (eg., server.port),
+// with each flag being a group holding its value and its source (eg., "env MYAPP_PORT").
+// The values of secret flags are redacted.
+// The values are the ones the fields hold, while the sources are looked up when logging: before Unmarshal, they disagree.
+func LogOptions(logger *slog.Logger, c *cobra.Command) error {
+	fields, err := specFields(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	attrs := append([]slog.Attr{slog.String("command", c.CommandPath())}, optionAttrs(c, fields)...)
+	logger.LogAttrs(ctx, slog.LevelInfo, "resolved options", attrs...)
+
+	return nil
+}
+
+// optionAttrs returns the attributes of the input fields, nesting the ones of the nested structs into groups.
+func optionAttrs(c *cobra.Command, fields []specField) []slog.Attr {
+	res := []slog.Attr{}
+	for _, f := range fields {
+		if f.flag == nil {
+			nested := []any{}
+			for _, attr := range optionAttrs(c, f.fields) {
+				nested = append(nested, attr)
+			}
+			res = append(res, slog.Group(f.key, nested...))
+
+			continue
+		}
+		var value interface{} = "***"
+		if !isSecretFlag(f.flag) {
+			if field, ok := fieldValue(c, f.flag.Name); ok {
+				value = flagValue(f.flag, field)
+			} else {
+				value = f.flag.Value.String()
+			}
+		}
+		res = append(res, slog.Group(f.key, slog.Any("value", value), slog.String("source", origin(c, f.flag))))
+	}
+
+	return res
+}
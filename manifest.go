@@ -0,0 +1,217 @@
+package autoflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagIDAnnotation = "___flagid"
+)
+
+// FlagManifest describes the public surface of a flag.
+//
+// Its ID identifies the flag independently of its name, so that localization and documentation systems can track it across renames:
+// it is the value of the flagid tag, or the struct path of the field (eg., "server.port"), or the name of the flags without field.
+type FlagManifest struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Shorthand  string   `json:"shorthand,omitempty"`
+	Type       string   `json:"type"`
+	Default    string   `json:"default,omitempty"`
+	Envs       []string `json:"envs,omitempty"`
+	ConfigKeys []string `json:"config_keys,omitempty"`
+	Group      string   `json:"group,omitempty"`
+	Required   bool     `json:"required,omitempty"`
+}
+
+// GroupManifest describes a group of the flags local to a command.
+//
+// A group collects the flags of every options struct using its title.
+type GroupManifest struct {
+	Name  string   `json:"name"`
+	Gate  string   `json:"gate,omitempty"`
+	Flags []string `json:"flags"`
+}
+
+// CommandManifest describes the flags local to a command.
+type CommandManifest struct {
+	Path   string          `json:"path"`
+	Flags  []FlagManifest  `json:"flags"`
+	Groups []GroupManifest `json:"groups,omitempty"`
+}
+
+// Manifest describes the flags, environment variables, and config keys of a whole command tree.
+type Manifest struct {
+	Commands []CommandManifest `json:"commands"`
+}
+
+// NewManifest builds the manifest of the command tree rooted at the input command.
+func NewManifest(root *cobra.Command) *Manifest {
+	res := &Manifest{}
+	for _, c := range walkTree(root) {
+		configKeys := map[string][]string{}
+		if s, ok := scopes[c]; ok {
+			for path, name := range s.aliases {
+				configKeys[name] = append(configKeys[name], path)
+			}
+		}
+
+		cmd := CommandManifest{Path: c.CommandPath(), Flags: []FlagManifest{}}
+		c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			flag := FlagManifest{
+				ID:         flagID(c, f),
+				Name:       f.Name,
+				Shorthand:  f.Shorthand,
+				Type:       f.Value.Type(),
+				Default:    f.DefValue,
+				Envs:       f.Annotations[FlagEnvsAnnotation],
+				ConfigKeys: append([]string{f.Name}, configKeys[f.Name]...),
+			}
+			if group, ok := f.Annotations[FlagGroupAnnotation]; ok {
+				flag.Group = group[0]
+			}
+			if required, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok {
+				flag.Required = required[0] == "true"
+			}
+			sort.Strings(flag.ConfigKeys[1:])
+			cmd.Flags = append(cmd.Flags, flag)
+		})
+		cmd.Groups = groupManifests(c)
+		res.Commands = append(res.Commands, cmd)
+	}
+
+	return res
+}
+
+// flagID returns the stable identifier of the input flag (see FlagManifest).
+func flagID(c *cobra.Command, f *pflag.Flag) string {
+	if id, ok := f.Annotations[FlagIDAnnotation]; ok {
+		return id[0]
+	}
+	if s, ok := scopes[c]; ok {
+		if path, ok := s.flagPath(f.Name); ok {
+			return path
+		}
+	}
+
+	return f.Name
+}
+
+// groupManifests describes the groups of the flags local to the input command, sorted by name.
+func groupManifests(c *cobra.Command) []GroupManifest {
+	res := []GroupManifest{}
+	groups := Groups(c)
+	delete(groups, localGroupID)
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g := GroupManifest{Name: name, Flags: []string{}}
+		g.Gate, _ = gateOf(c, name)
+		groups[name].VisitAll(func(f *pflag.Flag) {
+			g.Flags = append(g.Flags, f.Name)
+		})
+		res = append(res, g)
+	}
+
+	return res
+}
+
+// ReadManifest decodes a manifest previously written with Write.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	res := &Manifest{}
+	if err := json.NewDecoder(r).Decode(res); err != nil {
+		return nil, fmt.Errorf("couldn't read the manifest: %w", err)
+	}
+
+	return res, nil
+}
+
+// Write encodes the manifest as indented JSON.
+func (m *Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(m)
+}
+
+// CompareManifests reports the backward incompatible changes from the baseline manifest to the current one.
+//
+// Removed commands, flags, shorthands, environment variables, config keys, and flag type changes are incompatible.
+// Additions are not.
+// Flags missing by name but not by ID are reported as renamed.
+func CompareManifests(baseline, current *Manifest) []error {
+	errs := []error{}
+
+	commands := map[string]CommandManifest{}
+	for _, cmd := range current.Commands {
+		commands[cmd.Path] = cmd
+	}
+
+	for _, prev := range baseline.Commands {
+		cmd, ok := commands[prev.Path]
+		if !ok {
+			errs = append(errs, fmt.Errorf("command %s was removed", prev.Path))
+
+			continue
+		}
+		flags := map[string]FlagManifest{}
+		ids := map[string]FlagManifest{}
+		for _, f := range cmd.Flags {
+			flags[f.Name] = f
+			if f.ID != "" {
+				ids[f.ID] = f
+			}
+		}
+		for _, prevFlag := range prev.Flags {
+			flag, ok := flags[prevFlag.Name]
+			if !ok {
+				if renamed, ok := ids[prevFlag.ID]; ok && prevFlag.ID != "" {
+					errs = append(errs, fmt.Errorf("%s: flag --%s was renamed to --%s", prev.Path, prevFlag.Name, renamed.Name))
+				} else {
+					errs = append(errs, fmt.Errorf("%s: flag --%s was removed", prev.Path, prevFlag.Name))
+				}
+
+				continue
+			}
+			if flag.Type != prevFlag.Type {
+				errs = append(errs, fmt.Errorf("%s: flag --%s changed type from %s to %s", prev.Path, prevFlag.Name, prevFlag.Type, flag.Type))
+			}
+			if prevFlag.Shorthand != "" && flag.Shorthand != prevFlag.Shorthand {
+				errs = append(errs, fmt.Errorf("%s: flag --%s lost shorthand -%s", prev.Path, prevFlag.Name, prevFlag.Shorthand))
+			}
+			for _, env := range missing(prevFlag.Envs, flag.Envs) {
+				errs = append(errs, fmt.Errorf("%s: flag --%s no longer binds env %s", prev.Path, prevFlag.Name, env))
+			}
+			for _, key := range missing(prevFlag.ConfigKeys, flag.ConfigKeys) {
+				errs = append(errs, fmt.Errorf("%s: flag --%s no longer binds config key %s", prev.Path, prevFlag.Name, key))
+			}
+		}
+	}
+
+	return errs
+}
+
+// missing returns the elements of prev not in curr.
+func missing(prev, curr []string) []string {
+	res := []string{}
+	set := map[string]bool{}
+	for _, s := range curr {
+		set[s] = true
+	}
+	for _, s := range prev {
+		if !set[s] {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,246 @@
+package autoflags
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+)
+
+func TestManifestCompatibility(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	require.Nil(t, Define(root, &testOptions{}))
+
+	var buf bytes.Buffer
+	require.Nil(t, NewManifest(root).Write(&buf))
+	baseline, err := ReadManifest(&buf)
+	require.Nil(t, err)
+	assert.Empty(t, CompareManifests(baseline, NewManifest(root)))
+
+	var logLevel *FlagManifest
+	for i, f := range baseline.Commands[0].Flags {
+		if f.Name == "log-level" {
+			logLevel = &baseline.Commands[0].Flags[i]
+		}
+	}
+	require.NotNil(t, logLevel)
+	assert.Equal(t, []string{"log-level", "configflags.loglevel"}, logLevel.ConfigKeys)
+	assert.Equal(t, "Configuration", logLevel.Group)
+
+	changed := &cobra.Command{Use: "app"}
+	require.Nil(t, Define(changed, &testOptions{}, WithExclusions("log-level")))
+	changed.Flags().Lookup("deep").Shorthand = ""
+	errs := CompareManifests(baseline, NewManifest(changed))
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "app: flag --deep lost shorthand -d")
+	assert.EqualError(t, errs[1], "app: flag --log-level was removed")
+}
+
+type idOptions struct {
+	fixture
+
+	Port   int `flag:"listen-port" flagid:"port"`
+	Server struct {
+		Host string `flag:"host"`
+	}
+	Timeout time.Duration
+}
+
+type renamedIDOptions struct {
+	fixture
+
+	Port   int `flag:"port" flagid:"port"`
+	Server struct {
+		Host string `flag:"hostname"`
+	}
+	Timeout time.Duration
+}
+
+type duplicateIDOptions struct {
+	fixture
+
+	A string `flagid:"same"`
+	B string `flagid:"same"`
+}
+
+func TestManifestIDs(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	require.Nil(t, Define(root, &idOptions{}))
+	ids := map[string]string{}
+	for _, f := range NewManifest(root).Commands[0].Flags {
+		ids[f.Name] = f.ID
+	}
+	assert.Equal(t, map[string]string{"listen-port": "port", "host": "server.host", "timeout": "timeout"}, ids)
+
+	renamed := &cobra.Command{Use: "app"}
+	require.Nil(t, Define(renamed, &renamedIDOptions{}))
+	errs := CompareManifests(NewManifest(root), NewManifest(renamed))
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "app: flag --host was renamed to --hostname")
+	assert.EqualError(t, errs[1], "app: flag --listen-port was renamed to --port")
+
+	assert.EqualError(t, Define(&cobra.Command{Use: "dup"}, &duplicateIDOptions{}), "invalid flagid tag on b: --a has id same already")
+}
+
+type envBlockOptions struct {
+	fixture
+
+	Host  string   `flagenv:"true" default:"localhost" flagdescr:"the host to bind"`
+	Hosts []string `flagenv:"true" default:"a,b"`
+	Token string   `flagenv:"true" flagsecret:"true" default:"changeme"`
+	Other string
+}
+
+func TestEnvBlock(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	serve := &cobra.Command{Use: "serve"}
+	root.AddCommand(serve)
+	require.Nil(t, Define(serve, &envBlockOptions{}))
+
+	block, err := EnvBlock(root, EnvFormatDockerfile)
+	require.Nil(t, err)
+	assert.Equal(t, `# the host to bind (app serve --host)
+ENV HOST="localhost"
+# (app serve --hosts)
+ENV HOSTS="a,b"
+# (app serve --token, secret)
+ENV TOKEN=""
+`, block)
+
+	block, err = EnvBlock(root, EnvFormatKubernetes)
+	require.Nil(t, err)
+	assert.Contains(t, block, "env:\n  # the host to bind (app serve --host)\n  - name: HOST\n    value: \"localhost\"\n")
+
+	block, err = EnvBlock(root, EnvFormatCompose)
+	require.Nil(t, err)
+	assert.Contains(t, block, "environment:\n  # the host to bind (app serve --host)\n  HOST: \"localhost\"\n")
+
+	_, err = EnvBlock(root, "helm")
+	assert.EqualError(t, err, `unknown env format "helm"`)
+
+	root.AddCommand(NewEnvBlockCmd())
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"env", "--format", "compose"})
+	require.Nil(t, root.Execute())
+	assert.Contains(t, out.String(), "  TOKEN: \"\"\n")
+}
+
+func TestExportShell(t *testing.T) {
+	c := &cobra.Command{Use: "serve"}
+	opts := &envBlockOptions{}
+	require.Nil(t, Define(c, opts))
+	require.Nil(t, c.Flags().Parse([]string{"--host", "it's me", "--hosts", "x", "--hosts", "y"}))
+	require.Nil(t, Unmarshal(c, opts))
+
+	var out bytes.Buffer
+	require.Nil(t, ExportShell(c, &out))
+	assert.Equal(t, `export HOST='it'\''s me'
+export HOSTS=x,y
+export OTHER=''
+`, out.String())
+
+	assert.EqualError(t, ExportShell(&cobra.Command{Use: "none"}, &out), "couldn't find a scope for none")
+}
+
+type apiOptions struct {
+	fixture
+
+	Host    string        `default:"localhost" flagdescr:"the host to bind" flagrequired:"true"`
+	Timeout time.Duration `default:"5s"`
+	Token   string        `flagsecret:"true"`
+	Ratios  []float64
+	Server  apiServerOptions
+}
+
+type apiServerOptions struct {
+	Port    int `default:"8080" flagdescr:"the port"`
+	Verbose bool
+}
+
+func TestAPISpec(t *testing.T) {
+	c := &cobra.Command{Use: "serve"}
+	require.Nil(t, Define(c, &apiOptions{}))
+
+	proto, err := ProtoMessage(c, "ServeConfig")
+	require.Nil(t, err)
+	assert.Equal(t, `message ServeConfig {
+  // the host to bind (required, default localhost)
+  string host = 1;
+  // (default 5s)
+  string timeout = 2;
+  // (secret)
+  string token = 3;
+  repeated double ratios = 4;
+  message Server {
+    // the port (default 8080)
+    int64 port = 1;
+    bool verbose = 2;
+  }
+  Server server = 5;
+}
+`, proto)
+
+	component, err := OpenAPIComponent(c)
+	require.Nil(t, err)
+	out, err := json.Marshal(component)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"required": ["host"],
+		"properties": {
+			"host": {"type": "string", "description": "the host to bind", "default": "localhost"},
+			"timeout": {"type": "string", "format": "duration", "default": "5s"},
+			"token": {"type": "string", "writeOnly": true},
+			"ratios": {"type": "array", "items": {"type": "number", "format": "double"}},
+			"server": {
+				"type": "object",
+				"properties": {
+					"port": {"type": "integer", "format": "int64", "description": "the port", "default": 8080},
+					"verbose": {"type": "boolean"}
+				}
+			}
+		}
+	}`, string(out))
+
+	_, err = ProtoMessage(&cobra.Command{Use: "none"}, "None")
+	assert.EqualError(t, err, "couldn't find a scope for none")
+}
+
+func TestLogOptions(t *testing.T) {
+	c := &cobra.Command{Use: "serve"}
+	opts := &apiOptions{}
+	require.Nil(t, Define(c, opts))
+	require.Nil(t, c.Flags().Parse([]string{"--server.port", "9090", "--token", "s3cr3t"}))
+	require.Nil(t, Unmarshal(c, opts))
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+
+		return a
+	}}))
+	require.Nil(t, LogOptions(logger, c))
+	assert.JSONEq(t, `{
+		"level": "INFO",
+		"msg": "resolved options",
+		"command": "serve",
+		"host": {"value": "localhost", "source": "default"},
+		"timeout": {"value": "5s", "source": "default"},
+		"token": {"value": "***", "source": "flag"},
+		"ratios": {"value": [], "source": "default"},
+		"server": {
+			"port": {"value": 9090, "source": "flag"},
+			"verbose": {"value": false, "source": "default"}
+		}
+	}`, out.String())
+
+	assert.EqualError(t, LogOptions(logger, &cobra.Command{Use: "none"}), "couldn't find a scope for none")
+}
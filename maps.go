@@ -0,0 +1,144 @@
+package autoflags
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// mapState tracks the command line entries of a map flag.
+//
+// The flag shares its memory with the options field, so its value changes on unmarshal.
+type mapState struct {
+	entries map[string]interface{}
+	last    string
+}
+
+// mergePolicy returns the merge policy of the input flag, if it holds a slice or a map.
+func mergePolicy(c *cobra.Command, f *pflag.Flag) (string, bool) {
+	typ, ok := getScope(c).types[f.Name]
+	if !ok || (typ.Kind() != reflect.Slice && typ.Kind() != reflect.Map) {
+		return "", false
+	}
+	if policy := f.Annotations[FlagMergeAnnotation]; len(policy) > 0 {
+		return policy[0], true
+	}
+
+	return MergeReplace, true
+}
+
+// checkMergePolicy validates the flagmerge tag for a field of the given type.
+func checkMergePolicy(policy string, typ reflect.Type) error {
+	switch typ.Kind() {
+	case reflect.Slice:
+		if policy != MergeAppend && policy != MergeReplace {
+			return fmt.Errorf("unknown policy %q for slices", policy)
+		}
+	case reflect.Map:
+		if policy != MergeKeys && policy != MergeReplace {
+			return fmt.Errorf("unknown policy %q for maps", policy)
+		}
+	default:
+		return fmt.Errorf("only slices and maps can be merged")
+	}
+
+	return nil
+}
+
+// mergeMaps makes the command line entries of the map flags with the merge policy override the entries from the environment or the config file key by key.
+//
+// The command line entries merge into the ones of the highest-precedence source among env and config.
+// When a custom precedence order ranks such a source above the command line, its entries win as they are.
+func mergeMaps(c *cobra.Command, settings map[string]interface{}) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if policy, _ := mergePolicy(c, f); policy != MergeKeys || !f.Changed {
+			return
+		}
+		state, ok := s.maps[f.Name]
+		if !ok || f.Value.String() != state.last {
+			field, ok := fieldValue(c, f.Name)
+			if !ok || field.Kind() != reflect.Map {
+				return
+			}
+			// Copy the entries, since the flag holds the memory of the field
+			state = &mapState{entries: map[string]interface{}{}}
+			iter := field.MapRange()
+			for iter.Next() {
+				state.entries[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+			}
+			s.maps[f.Name] = state
+		}
+
+		values := lookupSources(c, f)
+		order := s.precedenceOf(f.Name)
+		if winner(values, order) != SourceFlag {
+			return
+		}
+		delete(values, SourceFlag)
+		base := winner(values, order)
+		if base == SourceDefault {
+			return
+		}
+		entries, err := mapEntries(values[base])
+		if err != nil {
+			// Leave the invalid value to the decoding errors
+			return
+		}
+		for key, val := range state.entries {
+			entries[key] = val
+		}
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			m[key] = entries
+		})
+	})
+}
+
+// recordMaps stores the values the map flags with the merge policy got from the last unmarshal.
+func recordMaps(c *cobra.Command) {
+	s := getScope(c)
+	for name, state := range s.maps {
+		if f := c.Flags().Lookup(name); f != nil {
+			state.last = f.Value.String()
+		}
+	}
+}
+
+// mapEntries converts the raw value of a source into map entries.
+//
+// Strings are comma-separated lists of key=value pairs, as for the pflag string to string flags.
+func mapEntries(val interface{}) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	switch v := val.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return res, nil
+		}
+		pairs, err := csv.NewReader(strings.NewReader(v)).Read()
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			key, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("%q must be formatted as key=value", pair)
+			}
+			res[key] = val
+		}
+	default:
+		rv := reflect.ValueOf(val)
+		if rv.Kind() != reflect.Map {
+			return nil, fmt.Errorf("%v is not a map", val)
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			res[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+	}
+
+	return res, nil
+}
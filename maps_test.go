@@ -0,0 +1,86 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestMapMerge() {
+	suite.useConfigFile("config.yaml", "labels:\n  team: core\n  env: prod\nannotations:\n  a: b\n")
+
+	c := &cobra.Command{Use: "merge"}
+	opts := &labelsOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--labels", "env=dev,tier=web", "--annotations", "c=d"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(map[string]string{"team": "core", "env": "dev", "tier": "web"}, opts.Labels)
+	suite.Equal(map[string]string{"c": "d"}, opts.Annotations)
+	// Unmarshalling again keeps the command line entries
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(map[string]string{"team": "core", "env": "dev", "tier": "web"}, opts.Labels)
+
+	inspection, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Equal(map[string]string{"labels": MergeKeys, "annotations": MergeReplace}, inspection.MergePolicies)
+}
+
+func (suite *UnmarshalSuite) TestMapMergeFromEnv() {
+	suite.T().Setenv("LABELS", "team=core,env=prod")
+
+	c := &cobra.Command{Use: "merge"}
+	opts := &labelsOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--labels", "env=dev"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(map[string]string{"team": "core", "env": "dev"}, opts.Labels)
+}
+
+type stringMapOptions struct {
+	fixture
+
+	Labels map[string]string `flagshort:"l" flagenv:"true" flagmerge:"merge"`
+	Env    map[string]string `flagdescr:"the environment" default:"a=1"`
+	Ports  map[string]int
+}
+
+func (suite *UnmarshalSuite) TestStringMaps() {
+	suite.T().Setenv("LABELS", "tier=web")
+	suite.useConfigFile("config.yaml", "labels:\n  team: core\nenv:\n  b: \"2\"\n")
+
+	c := &cobra.Command{Use: "maps"}
+	opts := &stringMapOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("stringToString", c.Flags().Lookup("labels").Value.Type())
+	suite.Nil(c.Flags().Lookup("ports"))
+	inspection, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Equal([]SkippedField{{Path: "ports", Type: "map[string]int", Reason: SkipUnsupported}}, inspection.Skipped)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"-l", "env=dev", "--labels", "team=infra"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(map[string]string{"team": "infra", "tier": "web", "env": "dev"}, opts.Labels)
+	suite.Equal(map[string]string{"b": "2"}, opts.Env)
+
+	c = &cobra.Command{Use: "defaults"}
+	viper.Reset()
+	configSettings = nil
+	opts = &stringMapOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(map[string]string{"a": "1"}, opts.Env)
+}
+
+func (suite *UnmarshalSuite) TestMapMergeInvalid() {
+	c := &cobra.Command{Use: "merge"}
+	suite.ErrorContains(Define(c, &invalidMapMergeOptions{}), `invalid flagmerge tag on labels: unknown policy "append" for maps`)
+}
+
+type invalidMapMergeOptions struct {
+	fixture
+
+	Labels map[string]string `flagcustom:"true" flagmerge:"append"`
+}
+
+func (o *invalidMapMergeOptions) DefineLabels(c *cobra.Command, typename, name, short, descr string) {
+	c.Flags().StringToStringVarP(&o.Labels, name, short, nil, descr)
+}
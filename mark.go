@@ -1,13 +1,52 @@
 package autoflags
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagSecretAnnotation = "___flagsecret"
 )
 
+func isSecret(f reflect.StructField) bool {
+	val := f.Tag.Get("flagsecret")
+	secret, _ := strconv.ParseBool(val)
+
+	return secret
+}
+
+// isSecretFlag tells whether the input flag was marked as secret via the flagsecret tag.
+func isSecretFlag(f *pflag.Flag) bool {
+	_, ok := f.Annotations[FlagSecretAnnotation]
+
+	return ok
+}
+
 func isMandatory(f reflect.StructField) bool {
 	val := f.Tag.Get("flagrequired")
 	req, _ := strconv.ParseBool(val)
 
 	return req
 }
+
+// checkDeprecated records a warning for every deprecated flag (see the flagdeprecated tag) some source other than the command line sets.
+//
+// The command line parsing already prints the deprecation of the flags it meets, but not the one of the values from env and config.
+func checkDeprecated(c *cobra.Command) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Deprecated == "" {
+			return
+		}
+		switch winner(lookupSources(c, f), s.precedenceOf(f.Name)) {
+		case SourceDefault, SourceFlag:
+			return
+		}
+		s.warn(fmt.Sprintf("flag --%s is deprecated, %s (set by %s)", f.Name, f.Deprecated, origin(c, f)))
+	})
+}
@@ -0,0 +1,73 @@
+package autoflags
+
+import (
+	"bytes"
+
+	"github.com/spf13/cobra"
+)
+
+type hiddenOptions struct {
+	fixture
+
+	Host         string `flagdescr:"the host"`
+	Experimental bool   `flaghidden:"true" flagenv:"true" flaggroup:"Internal"`
+	Tuning       int    `flaghidden:"true" flaggroup:"Internal"`
+}
+
+func (suite *UnmarshalSuite) TestHiddenFlags() {
+	suite.T().Setenv("EXPERIMENTAL", "true")
+	suite.useConfigFile("config.yaml", "tuning: 3\n")
+
+	c := &cobra.Command{Use: "hidden"}
+	opts := &hiddenOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.True(c.Flags().Lookup("experimental").Hidden)
+	suite.NotContains(c.UsageTemplate(), "experimental")
+	suite.NotContains(c.UsageTemplate(), "Internal Flags")
+	suite.Contains(c.UsageTemplate(), "Flags:\n      --host string   the host")
+
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.True(opts.Experimental)
+	suite.Equal(3, opts.Tuning)
+	suite.Require().Nil(c.Flags().Parse([]string{"--tuning", "4"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(4, opts.Tuning)
+}
+
+type deprecatedOptions struct {
+	fixture
+
+	Name    string `flagdeprecated:"use --new-name instead" flagenv:"true"`
+	NewName string
+}
+
+func (suite *UnmarshalSuite) TestDeprecatedFlags() {
+	suite.T().Setenv("NAME", "")
+	c := &cobra.Command{Use: "deprecated"}
+	opts := &deprecatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.NotContains(c.UsageTemplate(), "--name ")
+	suite.Require().Nil(Unmarshal(c, opts))
+	inspection, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Empty(inspection.Warnings)
+
+	suite.T().Setenv("NAME", "env")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("env", opts.Name)
+	inspection, err = Inspect(c)
+	suite.Require().Nil(err)
+	suite.Equal([]string{"flag --name is deprecated, use --new-name instead (set by env NAME)"}, inspection.Warnings)
+
+	out := &bytes.Buffer{}
+	c = &cobra.Command{Use: "deprecated"}
+	c.Flags().SetOutput(out)
+	opts = &deprecatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--name", "cli"}))
+	suite.Equal("Flag --name has been deprecated, use --new-name instead\n", out.String())
+	suite.Require().Nil(Unmarshal(c, opts))
+	inspection, err = Inspect(c)
+	suite.Require().Nil(err)
+	suite.Empty(inspection.Warnings, "pflag warns about the command line already")
+}
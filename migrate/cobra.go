@@ -0,0 +1,350 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cobraTypes maps the pflag definition methods (eg., StringVarP) to the Go types of their values.
+var cobraTypes = map[string]string{
+	"String":         "string",
+	"Bool":           "bool",
+	"Int":            "int",
+	"Int8":           "int8",
+	"Int16":          "int16",
+	"Int32":          "int32",
+	"Int64":          "int64",
+	"Uint":           "uint",
+	"Uint8":          "uint8",
+	"Uint16":         "uint16",
+	"Uint32":         "uint32",
+	"Uint64":         "uint64",
+	"Float32":        "float32",
+	"Float64":        "float64",
+	"Duration":       "time.Duration",
+	"Count":          "int",
+	"StringSlice":    "[]string",
+	"StringArray":    "[]string",
+	"IntSlice":       "[]int",
+	"Int64Slice":     "[]int64",
+	"UintSlice":      "[]uint",
+	"Float64Slice":   "[]float64",
+	"BoolSlice":      "[]bool",
+	"StringToString": "map[string]string",
+}
+
+var durationUnits = map[string]time.Duration{
+	"Nanosecond":  time.Nanosecond,
+	"Microsecond": time.Microsecond,
+	"Millisecond": time.Millisecond,
+	"Second":      time.Second,
+	"Minute":      time.Minute,
+	"Hour":        time.Hour,
+}
+
+// FromCobra converts the flags the input Go source defines via cobra into options structs, one for each function defining flags.
+//
+// The struct names come from the function names (eg., newServeCmd gives ServeOptions).
+// It recognizes the pflag definition methods (eg., String, StringP, StringVar, StringVarP) called on Flags() or PersistentFlags(),
+// along with MarkFlagRequired.
+func FromCobra(src []byte) ([]Struct, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the source: %w", err)
+	}
+
+	res := []Struct{}
+	names := map[string]int{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		s := cobraStruct(fset, fn)
+		if len(s.Fields) == 0 {
+			continue
+		}
+		names[s.Name]++
+		if n := names[s.Name]; n > 1 {
+			s.Name = fmt.Sprintf("%s%d", s.Name, n)
+		}
+		res = append(res, s)
+	}
+
+	return res, nil
+}
+
+// cobraStruct collects the flags the input function defines.
+func cobraStruct(fset *token.FileSet, fn *ast.FuncDecl) Struct {
+	res := Struct{Name: structName(fn.Name.Name)}
+	// flagSets tracks the variables holding flag sets (eg., flags := c.Flags())
+	flagSets := map[string]bool{}
+	fields := map[string]int{}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok {
+			for i, rhs := range assign.Rhs {
+				if _, ok := flagSetCall(rhs, nil); ok && i < len(assign.Lhs) {
+					if id, ok := assign.Lhs[i].(*ast.Ident); ok {
+						flagSets[id.Name] = true
+					}
+				}
+			}
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		switch sel.Sel.Name {
+		case "MarkFlagRequired", "MarkPersistentFlagRequired":
+			if name, ok := stringLit(call.Args, 0); ok {
+				if i, ok := fields[name]; ok {
+					res.Fields[i].Required = true
+				}
+			}
+
+			return true
+		case "MarkHidden", "MarkDeprecated", "MarkShorthandDeprecated":
+			if name, ok := stringLit(call.Args, 0); ok {
+				if i, ok := fields[name]; ok {
+					res.Fields[i].Notes = append(res.Fields[i].Notes, fmt.Sprintf("the original definition calls %s", sel.Sel.Name))
+				}
+			}
+
+			return true
+		}
+		persistent, ok := flagSetCall(sel.X, flagSets)
+		if !ok {
+			return true
+		}
+		f, ok := cobraField(fset, sel.Sel.Name, call.Args)
+		if !ok {
+			return true
+		}
+		if persistent {
+			f.Notes = append(f.Notes, "the original flag is persistent, define these options on the parent command")
+		}
+		fields[f.Flag] = len(res.Fields)
+		res.Fields = append(res.Fields, f)
+
+		return true
+	})
+
+	return res
+}
+
+// flagSetCall tells whether the input expression is a flag set (eg., c.Flags()), and whether it is the persistent one.
+func flagSetCall(expr ast.Expr, flagSets map[string]bool) (bool, bool) {
+	if id, ok := expr.(*ast.Ident); ok {
+		return false, flagSets[id.Name]
+	}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false, false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false, false
+	}
+	switch sel.Sel.Name {
+	case "Flags", "LocalFlags":
+		return false, true
+	case "PersistentFlags":
+		return true, true
+	}
+
+	return false, false
+}
+
+// cobraField converts the input pflag definition call into a field.
+func cobraField(fset *token.FileSet, method string, args []ast.Expr) (Field, bool) {
+	base := method
+	short := strings.HasSuffix(base, "P")
+	base = strings.TrimSuffix(base, "P")
+	isVar := strings.HasSuffix(base, "Var")
+	base = strings.TrimSuffix(base, "Var")
+	typ, ok := cobraTypes[base]
+	if !ok {
+		return Field{}, false
+	}
+
+	var ptr ast.Expr
+	if isVar {
+		if len(args) == 0 {
+			return Field{}, false
+		}
+		ptr, args = args[0], args[1:]
+	}
+	name, ok := stringLit(args, 0)
+	if !ok {
+		return Field{}, false
+	}
+	args = args[1:]
+	f := Field{Name: fieldName(name), Type: typ, Flag: name, Count: base == "Count"}
+	if ptr != nil {
+		f.Name = pointedName(ptr, f.Name)
+	}
+	if short {
+		f.Short, _ = stringLit(args, 0)
+		if len(args) > 0 {
+			args = args[1:]
+		}
+	}
+	if !f.Count && len(args) > 0 {
+		def, ok := defaultValue(args[0])
+		if d, isDuration := durationValue(args[0]); isDuration && typ == "time.Duration" {
+			def, ok = "", true
+			if d != 0 {
+				def = d.String()
+			}
+		}
+		if !ok {
+			f.Notes = append(f.Notes, fmt.Sprintf("the original default value is %s", exprString(fset, args[0])))
+		}
+		f.Default = def
+		args = args[1:]
+	}
+	if descr, ok := stringLit(args, 0); ok {
+		f.Descr = descr
+	} else if len(args) > 0 {
+		f.Notes = append(f.Notes, fmt.Sprintf("the original usage is %s", exprString(fset, args[0])))
+	}
+
+	return f, true
+}
+
+// pointedName returns the name of the field or variable the input pointer points to (eg., &o.Host gives Host).
+func pointedName(ptr ast.Expr, fallback string) string {
+	unary, ok := ptr.(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return fallback
+	}
+	switch x := unary.X.(type) {
+	case *ast.SelectorExpr:
+		return fieldName(x.Sel.Name)
+	case *ast.Ident:
+		return fieldName(x.Name)
+	}
+
+	return fallback
+}
+
+// defaultValue converts the input default value literal into the value of a default tag.
+//
+// Zero values give an empty string, that omits the tag.
+func defaultValue(expr ast.Expr) (string, bool) {
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		if x.Kind == token.STRING {
+			s, err := strconv.Unquote(x.Value)
+
+			return s, err == nil
+		}
+		if x.Value == "0" || x.Value == "0.0" {
+			return "", true
+		}
+
+		return x.Value, true
+	case *ast.Ident:
+		switch x.Name {
+		case "true":
+			return "true", true
+		case "false", "nil":
+			return "", true
+		}
+	case *ast.CompositeLit:
+		items := []string{}
+		for _, elt := range x.Elts {
+			item, ok := defaultValue(elt)
+			if !ok {
+				return "", false
+			}
+			items = append(items, item)
+		}
+
+		return strings.Join(items, ","), true
+	}
+
+	return "", false
+}
+
+// durationValue evaluates the input duration expression (eg., 5 * time.Second).
+func durationValue(expr ast.Expr) (time.Duration, bool) {
+	switch x := expr.(type) {
+	case *ast.SelectorExpr:
+		if pkg, ok := x.X.(*ast.Ident); ok && pkg.Name == "time" {
+			d, ok := durationUnits[x.Sel.Name]
+
+			return d, ok
+		}
+	case *ast.BasicLit:
+		if x.Kind == token.INT {
+			n, err := strconv.ParseInt(x.Value, 0, 64)
+
+			return time.Duration(n), err == nil
+		}
+	case *ast.ParenExpr:
+		return durationValue(x.X)
+	case *ast.BinaryExpr:
+		if x.Op != token.MUL {
+			return 0, false
+		}
+		l, ok := durationValue(x.X)
+		if !ok {
+			return 0, false
+		}
+		r, ok := durationValue(x.Y)
+		if !ok {
+			return 0, false
+		}
+
+		return l * r, true
+	}
+
+	return 0, false
+}
+
+// stringLit returns the string literal at the input position of the arguments, if any.
+func stringLit(args []ast.Expr, i int) (string, bool) {
+	if i >= len(args) {
+		return "", false
+	}
+	lit, ok := args[i].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+
+	return s, err == nil
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, fset, expr)
+
+	return b.String()
+}
+
+// structName derives the name of the options struct from the name of the function defining the flags.
+func structName(fn string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(fn, "new"), "New")
+	for _, suffix := range []string{"Command", "Cmd"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	if name == "" || name == "init" {
+		return "Options"
+	}
+
+	return fieldName(name) + "Options"
+}
@@ -0,0 +1,131 @@
+package migrate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FromKong converts the kong structs the input Go source declares into options structs.
+//
+// Structs count as kong ones when any of their fields has a kong tag (eg., help, short, env).
+// The generated structs keep their names, with the Options suffix.
+// Command and argument fields have no flag equivalent, so they become notes.
+func FromKong(src []byte) ([]Struct, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the source: %w", err)
+	}
+
+	res := []Struct{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok || !isKongStruct(st) {
+			return true
+		}
+		name := spec.Name.Name
+		if !strings.HasSuffix(name, "Options") {
+			name = strings.TrimSuffix(name, "Cmd") + "Options"
+		}
+		s := Struct{Name: fieldName(name)}
+		for _, field := range st.Fields.List {
+			s.Fields = append(s.Fields, kongFields(fset, field)...)
+		}
+		res = append(res, s)
+
+		return true
+	})
+
+	return res, nil
+}
+
+// kongTags are the tags telling kong structs apart.
+var kongTags = []string{"help", "short", "env", "default", "required", "cmd", "arg", "name", "enum", "placeholder", "hidden", "type"}
+
+func isKongStruct(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		tag := fieldTag(field)
+		for _, key := range kongTags {
+			if _, ok := tag.Lookup(key); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// kongFields converts the input kong field into the fields of the options struct.
+func kongFields(fset *token.FileSet, field *ast.Field) []Field {
+	tag := fieldTag(field)
+	typ := exprString(fset, field.Type)
+	res := []Field{}
+	for _, id := range field.Names {
+		if !id.IsExported() {
+			continue
+		}
+		f := Field{Name: id.Name, Type: typ, Flag: kebabCase(id.Name)}
+		if name, ok := tag.Lookup("name"); ok && name != "" {
+			f.Flag = name
+		}
+		f.Short = tag.Get("short")
+		f.Default = tag.Get("default")
+		f.Descr = tag.Get("help")
+		f.Group = tag.Get("group")
+		f.Required = isSet(tag, "required")
+		f.Count = tag.Get("type") == "counter"
+		if env := tag.Get("env"); env != "" {
+			f.Env = true
+			if derived := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(f.Flag)); env != derived {
+				f.Notes = append(f.Notes, fmt.Sprintf("the original env is %s, autoflags derives %s (plus the env prefix, if any)", env, derived))
+			}
+		}
+		if isSet(tag, "cmd") || isSet(tag, "arg") {
+			f.Notes = append(f.Notes, "the original field is a kong command or argument, not a flag")
+		}
+		if enum := tag.Get("enum"); enum != "" {
+			f.Notes = append(f.Notes, fmt.Sprintf("the original value is one of %s", enum))
+		}
+		if isSet(tag, "hidden") {
+			f.Notes = append(f.Notes, "the original flag is hidden")
+		}
+		res = append(res, f)
+	}
+
+	return res
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+
+	return reflect.StructTag(tag)
+}
+
+// isSet tells whether the input boolean kong tag is set (eg., `required:""` or `required:"true"`).
+func isSet(tag reflect.StructTag, key string) bool {
+	val, ok := tag.Lookup(key)
+	if !ok {
+		return false
+	}
+	if val == "" {
+		return true
+	}
+	b, _ := strconv.ParseBool(val)
+
+	return b
+}
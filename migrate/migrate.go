@@ -0,0 +1,168 @@
+// Package migrate converts existing flag definitions into autoflags options structs.
+//
+// It reads Go sources either defining cobra flags by hand (eg., c.Flags().StringVarP(&o.Host, "host", "H", "localhost", "the host"))
+// or declaring kong structs, and generates the equivalent structs carrying the autoflags tags.
+//
+// The conversion is best effort: what has no autoflags equivalent becomes a comment on the generated field, to review by hand.
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Field is a field of a generated options struct.
+type Field struct {
+	Name string
+	// Type is the Go type of the field (eg., "time.Duration")
+	Type string
+	// Flag is the name of the flag
+	Flag     string
+	Short    string
+	Default  string
+	Descr    string
+	Group    string
+	Count    bool
+	Env      bool
+	Required bool
+	// Notes lists what the conversion could not express with tags
+	Notes []string
+}
+
+// Struct is a generated options struct.
+type Struct struct {
+	Name   string
+	Fields []Field
+}
+
+// tags returns the autoflags tags of the field.
+func (f Field) tags() string {
+	tags := []string{}
+	add := func(key, value string) {
+		tags = append(tags, fmt.Sprintf("%s:%s", key, strconv.Quote(value)))
+	}
+	if f.Flag != "" && f.Flag != strings.ToLower(f.Name) {
+		add("flag", f.Flag)
+	}
+	if f.Short != "" {
+		add("flagshort", f.Short)
+	}
+	if f.Default != "" {
+		add("default", f.Default)
+	}
+	if f.Descr != "" {
+		add("flagdescr", f.Descr)
+	}
+	if f.Group != "" {
+		add("flaggroup", f.Group)
+	}
+	if f.Count {
+		add("type", "count")
+	}
+	if f.Env {
+		add("flagenv", "true")
+	}
+	if f.Required {
+		add("flagrequired", "true")
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+
+	return "`" + strings.Join(tags, " ") + "`"
+}
+
+// Generate renders the input structs as the Go source of the given package.
+//
+// Each struct gets the Attach method making it an options.Options.
+func Generate(pkg string, structs []Struct) ([]byte, error) {
+	var body bytes.Buffer
+	imports := map[string]bool{"github.com/spf13/cobra": true}
+	for _, s := range structs {
+		fmt.Fprintf(&body, "\ntype %s struct {\n", s.Name)
+		for _, f := range s.Fields {
+			for _, note := range f.Notes {
+				fmt.Fprintf(&body, "\t// TODO: %s\n", note)
+			}
+			fmt.Fprintf(&body, "\t%s %s %s\n", f.Name, f.Type, f.tags())
+			if strings.HasPrefix(strings.TrimPrefix(f.Type, "[]"), "time.") {
+				imports["time"] = true
+			}
+		}
+		fmt.Fprintf(&body, "}\n\nfunc (o *%s) Attach(c *cobra.Command) {}\n", s.Name)
+	}
+
+	// Standard library imports go first, as goimports groups them
+	std, others := []string{}, []string{}
+	for path := range imports {
+		if strings.Contains(path, ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by autoflags-migrate. Review the TODO comments, then remove this line.\n\npackage %s\n\nimport (\n", pkg)
+	for _, path := range std {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	if len(std) > 0 {
+		out.WriteString("\n")
+	}
+	for _, path := range others {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+
+	res, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't format the generated code: %w", err)
+	}
+
+	return res, nil
+}
+
+// fieldName turns the input flag name into an exported Go identifier (eg., "log-level" into "LogLevel").
+func fieldName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '-' || r == '_' || r == '.' {
+			upper = true
+
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// kebabCase turns the input Go identifier into the flag name kong derives from it (eg., "LogLevel" into "log-level").
+func kebabCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cobraSource = `package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd() *cobra.Command {
+	opts := &serveOpts{}
+	c := &cobra.Command{Use: "serve"}
+	c.Flags().StringVarP(&opts.Host, "host", "H", "localhost", "the host to bind")
+	c.Flags().Duration("read-timeout", 5*time.Second, "the read timeout")
+	flags := c.Flags()
+	flags.CountP("verbose", "v", "the verbosity")
+	flags.StringSlice("tags", []string{"a", "b"}, usage)
+	c.PersistentFlags().Int("port", defaultPort, "the port")
+	c.MarkFlagRequired("host")
+	_ = c.Flags().MarkHidden("tags")
+
+	return c
+}
+`
+
+func TestFromCobra(t *testing.T) {
+	structs, err := FromCobra([]byte(cobraSource))
+	require.Nil(t, err)
+	assert.Equal(t, []Struct{{
+		Name: "ServeOptions",
+		Fields: []Field{
+			{Name: "Host", Type: "string", Flag: "host", Short: "H", Default: "localhost", Descr: "the host to bind", Required: true},
+			{Name: "ReadTimeout", Type: "time.Duration", Flag: "read-timeout", Default: "5s", Descr: "the read timeout"},
+			{Name: "Verbose", Type: "int", Flag: "verbose", Short: "v", Descr: "the verbosity", Count: true},
+			{Name: "Tags", Type: "[]string", Flag: "tags", Default: "a,b", Notes: []string{"the original usage is usage", "the original definition calls MarkHidden"}},
+			{Name: "Port", Type: "int", Flag: "port", Descr: "the port", Notes: []string{"the original default value is defaultPort", "the original flag is persistent, define these options on the parent command"}},
+		},
+	}}, structs)
+
+	out, err := Generate("cmd", structs)
+	require.Nil(t, err)
+	assert.Equal(t, `// Code generated by autoflags-migrate. Review the TODO comments, then remove this line.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type ServeOptions struct {
+	Host        string        `+"`"+`flagshort:"H" default:"localhost" flagdescr:"the host to bind" flagrequired:"true"`+"`"+`
+	ReadTimeout time.Duration `+"`"+`flag:"read-timeout" default:"5s" flagdescr:"the read timeout"`+"`"+`
+	Verbose     int           `+"`"+`flagshort:"v" flagdescr:"the verbosity" type:"count"`+"`"+`
+	// TODO: the original usage is usage
+	// TODO: the original definition calls MarkHidden
+	Tags []string `+"`"+`default:"a,b"`+"`"+`
+	// TODO: the original default value is defaultPort
+	// TODO: the original flag is persistent, define these options on the parent command
+	Port int `+"`"+`flagdescr:"the port"`+"`"+`
+}
+
+func (o *ServeOptions) Attach(c *cobra.Command) {}
+`, string(out))
+}
+
+func TestFromKong(t *testing.T) {
+	structs, err := FromKong([]byte("package cmd\n\n" +
+		"type ServeCmd struct {\n" +
+		"\tLogLevel string `help:\"the logging level\" short:\"l\" default:\"info\" enum:\"debug,info\" env:\"LOG_LEVEL\"`\n" +
+		"\tVerbose int `type:\"counter\" short:\"v\"`\n" +
+		"\tToken string `name:\"api-token\" required:\"\" env:\"APP_TOKEN\"`\n" +
+		"\tPath string `arg:\"\"`\n" +
+		"}\n\n" +
+		"type plain struct {\n\tName string `json:\"name\"`\n}\n"))
+	require.Nil(t, err)
+	assert.Equal(t, []Struct{{
+		Name: "ServeOptions",
+		Fields: []Field{
+			{Name: "LogLevel", Type: "string", Flag: "log-level", Short: "l", Default: "info", Descr: "the logging level", Env: true, Notes: []string{"the original value is one of debug,info"}},
+			{Name: "Verbose", Type: "int", Flag: "verbose", Short: "v", Count: true},
+			{Name: "Token", Type: "string", Flag: "api-token", Env: true, Required: true, Notes: []string{"the original env is APP_TOKEN, autoflags derives API_TOKEN (plus the env prefix, if any)"}},
+			{Name: "Path", Type: "string", Flag: "path", Notes: []string{"the original field is a kong command or argument, not a flag"}},
+		},
+	}}, structs)
+}
+
+func TestFromCobraInvalid(t *testing.T) {
+	_, err := FromCobra([]byte("package"))
+	assert.ErrorContains(t, err, "couldn't parse the source")
+}
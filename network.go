@@ -0,0 +1,128 @@
+package autoflags
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	ipType    = reflect.TypeOf(net.IP{})
+	ipNetType = reflect.TypeOf(&net.IPNet{})
+)
+
+// ipValue is a net.IP flag rendering the nil address as the empty string, rather than as "<nil>" like pflag does.
+type ipValue struct {
+	ref *net.IP
+}
+
+var _ pflag.Value = (*ipValue)(nil)
+
+func (v *ipValue) Set(input string) error {
+	ip, err := parseIP(input)
+	if err != nil {
+		return err
+	}
+	*v.ref = ip
+
+	return nil
+}
+
+func (v *ipValue) bind(field reflect.Value) pflag.Value {
+	return &ipValue{ref: field.Addr().Interface().(*net.IP)}
+}
+
+func (v *ipValue) Type() string {
+	return "ip"
+}
+
+func (v *ipValue) String() string {
+	if *v.ref == nil {
+		return ""
+	}
+
+	return v.ref.String()
+}
+
+// ipNetValue is a *net.IPNet flag, the nil network being the empty string.
+type ipNetValue struct {
+	ref **net.IPNet
+}
+
+var _ pflag.Value = (*ipNetValue)(nil)
+
+func (v *ipNetValue) Set(input string) error {
+	n, err := parseIPNet(input)
+	if err != nil {
+		return err
+	}
+	*v.ref = n
+
+	return nil
+}
+
+func (v *ipNetValue) bind(field reflect.Value) pflag.Value {
+	return &ipNetValue{ref: field.Addr().Interface().(**net.IPNet)}
+}
+
+func (v *ipNetValue) Type() string {
+	return "ipNet"
+}
+
+func (v *ipNetValue) String() string {
+	if *v.ref == nil {
+		return ""
+	}
+
+	return (*v.ref).String()
+}
+
+// defineNetwork defines a flag for the input field when it holds an IP address, a network, or a list of IP addresses.
+func defineNetwork(c *cobra.Command, field reflect.Value, name, short, descr string) bool {
+	ptr := unsafe.Pointer(field.UnsafeAddr())
+	switch field.Type() {
+	case ipType:
+		c.Flags().VarP(&ipValue{ref: (*net.IP)(ptr)}, name, short, descr)
+	case ipNetType:
+		c.Flags().VarP(&ipNetValue{ref: (**net.IPNet)(ptr)}, name, short, descr)
+	case reflect.SliceOf(ipType):
+		c.Flags().IPSliceVarP((*[]net.IP)(ptr), name, short, *(*[]net.IP)(ptr), descr)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// parseIP parses the input IP address, the empty string being the nil address.
+func parseIP(input string) (net.IP, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(input)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", input)
+	}
+
+	return ip, nil
+}
+
+// parseIPNet parses the input network in CIDR notation (eg., 10.0.0.0/8), the empty string being the nil network.
+func parseIPNet(input string) (*net.IPNet, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+	_, n, err := net.ParseCIDR(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network %q: must be in CIDR notation", input)
+	}
+
+	return n, nil
+}
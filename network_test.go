@@ -0,0 +1,41 @@
+package autoflags
+
+import (
+	"net"
+
+	"github.com/spf13/cobra"
+)
+
+type addressOptions struct {
+	fixture
+
+	Bind    net.IP     `flagenv:"true"`
+	Subnet  *net.IPNet `flagdescr:"the subnet"`
+	Peers   []net.IP
+	Gateway net.IP
+}
+
+func (suite *UnmarshalSuite) TestNetwork() {
+	suite.T().Setenv("BIND", "10.0.0.1")
+	suite.useConfigFile("config.yaml", "subnet: 10.0.0.0/8\npeers: [\"10.0.0.2\", \"::1\"]\n")
+
+	c := &cobra.Command{Use: "network"}
+	opts := &addressOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Contains(c.UsageString(), "--subnet ipNet")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(net.ParseIP("10.0.0.1"), opts.Bind)
+	suite.Equal("10.0.0.0/8", opts.Subnet.String())
+	suite.Equal([]net.IP{net.ParseIP("10.0.0.2"), net.ParseIP("::1")}, opts.Peers)
+	suite.Nil(opts.Gateway)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--peers", "192.168.1.1,192.168.1.2", "--subnet", "192.168.0.0/16", "--gateway", "192.168.1.254"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]net.IP{net.ParseIP("192.168.1.1"), net.ParseIP("192.168.1.2")}, opts.Peers)
+	suite.Equal("192.168.0.0/16", opts.Subnet.String())
+	suite.Equal(net.ParseIP("192.168.1.254"), opts.Gateway)
+
+	suite.T().Setenv("BIND", "10.0.0")
+	suite.ErrorContains(Unmarshal(c, opts), `invalid IP address "10.0.0"`)
+	suite.EqualError(c.Flags().Parse([]string{"--subnet", "10.0.0.0"}), `invalid argument "10.0.0.0" for "--subnet" flag: invalid network "10.0.0.0": must be in CIDR notation`)
+}
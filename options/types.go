@@ -15,6 +15,13 @@ type ValidatableOptions interface {
 	Validate() []error
 }
 
+// ContextValidatableOptions are options whose validation depends on the command running them.
+//
+// It lets validation consider which subcommand runs and which flags were explicitly changed (eg., via cmd.Flags().Changed), not just the final values.
+type ContextValidatableOptions interface {
+	ValidateContext(ctx context.Context, cmd *cobra.Command) []error
+}
+
 type TransformableOptions interface {
 	Transform(context.Context) error
 }
@@ -22,3 +29,43 @@ type TransformableOptions interface {
 type CommonOptions interface {
 	Context(context.Context) context.Context
 }
+
+// DynamicOptions are options whose flags come from the fields of another value, like a struct type built at runtime.
+//
+// Target must return a pointer to such struct.
+type DynamicOptions interface {
+	Options
+	Target() interface{}
+}
+
+// VirtualFlag is a flag without a backing field, whose resolved value goes to a setter.
+type VirtualFlag struct {
+	// Name is the name of the flag, and its config key
+	Name  string
+	Short string
+	Usage string
+	// Default is the value of the flag when no source sets it
+	Default string
+	// Env binds the flag to the environment variable named after it
+	Env bool
+	// Set receives the resolved value (eg., the SetLogLevel(string) error method of the options)
+	Set func(string) error
+}
+
+// VirtualOptions are options exposing computed-only state as flags, through setters rather than struct fields.
+type VirtualOptions interface {
+	VirtualFlags() []VirtualFlag
+}
+
+// Example is a usage example of a command.
+type Example struct {
+	// Description tells what the example does (eg., "Serve on a custom port")
+	Description string
+	// Command is the command line as users type it (eg., "app serve --port 8080")
+	Command string
+}
+
+// ExampleOptions are options documenting themselves via usage examples, that feed the Example of the commands they attach to.
+type ExampleOptions interface {
+	Examples() []Example
+}
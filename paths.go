@@ -0,0 +1,259 @@
+package autoflags
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// SearchPathCategory tells who owns a config search path.
+type SearchPathCategory int
+
+const (
+	// SearchPathLocal is the working directory
+	SearchPathLocal SearchPathCategory = iota
+	// SearchPathUser is a directory owned by the current user
+	SearchPathUser
+	// SearchPathSystem is a system-wide directory, usually managed by administrators or package managers
+	SearchPathSystem
+)
+
+func (c SearchPathCategory) String() string {
+	switch c {
+	case SearchPathUser:
+		return "user"
+	case SearchPathSystem:
+		return "system"
+	default:
+		return "local"
+	}
+}
+
+// SearchPath is a directory where to look for the config file.
+type SearchPath struct {
+	Dir      string
+	Category SearchPathCategory
+}
+
+var (
+	// goos is the operating system the search paths are computed for
+	goos = runtime.GOOS
+	// searchApp is the application name UseSearchPaths was called with
+	searchApp = ""
+	// vcsMarkers are the entries marking the root of a repository
+	vcsMarkers = []string{".git", ".hg", ".svn"}
+	// projectExts are the extensions of the project config files, by priority
+	projectExts = []string{"yaml", "yml", "json", "toml"}
+	// projectFile is the project config file loaded by UseProjectConfig
+	projectFile = ""
+	// projectSettings holds the settings of the project config file
+	projectSettings map[string]interface{}
+)
+
+// SearchPaths returns the directories where to look for the config file of the input application, by priority.
+//
+// On Windows they are the working directory, %APPDATA%\<app> (user), and %PROGRAMDATA%\<app> (system).
+// Elsewhere they are the working directory, $HOME/.<app> and $XDG_CONFIG_HOME/<app> (user, defaulting to $HOME/.config/<app>), and /etc/<app> (system).
+// Directories whose base cannot be determined are omitted.
+func SearchPaths(app string) []SearchPath {
+	ret := []SearchPath{{Dir: ".", Category: SearchPathLocal}}
+	home, _ := os.UserHomeDir()
+
+	if goos == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			ret = append(ret, SearchPath{Dir: filepath.Join(appData, app), Category: SearchPathUser})
+		}
+		if programData := os.Getenv("PROGRAMDATA"); programData != "" {
+			ret = append(ret, SearchPath{Dir: filepath.Join(programData, app), Category: SearchPathSystem})
+		}
+
+		return ret
+	}
+
+	if home != "" {
+		ret = append(ret, SearchPath{Dir: filepath.Join(home, "."+app), Category: SearchPathUser})
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		ret = append(ret, SearchPath{Dir: filepath.Join(xdg, app), Category: SearchPathUser})
+	} else if home != "" {
+		ret = append(ret, SearchPath{Dir: filepath.Join(home, ".config", app), Category: SearchPathUser})
+	}
+
+	return append(ret, SearchPath{Dir: filepath.Join("/etc", app), Category: SearchPathSystem})
+}
+
+// UseSearchPaths makes viper look for the config file into the search paths of the input application.
+func UseSearchPaths(app string) {
+	searchApp = app
+	for _, p := range SearchPaths(app) {
+		viper.AddConfigPath(p.Dir)
+	}
+}
+
+// SearchPathGitRoot looks for the project config file of the input application (eg., .myapp.yaml),
+// walking up from the working directory to the nearest repository root (marked by .git, .hg, or .svn).
+//
+// The nearest file wins. Outside of repositories only the working directory is considered.
+// Symbolic links are resolved, so that the traversal visits each directory at most once.
+func SearchPathGitRoot(app string) (string, bool) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	start, err := filepath.EvalSymlinks(wd)
+	if err != nil {
+		return "", false
+	}
+
+	candidates := []string{}
+	visited := map[string]bool{}
+	for dir := start; !visited[dir]; dir = filepath.Dir(dir) {
+		visited[dir] = true
+		for _, ext := range projectExts {
+			file := filepath.Join(dir, fmt.Sprintf(".%s.%s", app, ext))
+			if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+				candidates = append(candidates, file)
+
+				break
+			}
+		}
+		if isVCSRoot(dir) {
+			if len(candidates) > 0 {
+				return candidates[0], true
+			}
+
+			return "", false
+		}
+	}
+
+	// Not within a repository
+	if len(candidates) > 0 && filepath.Dir(candidates[0]) == start {
+		return candidates[0], true
+	}
+
+	return "", false
+}
+
+// isVCSRoot tells whether the input directory is the root of a repository.
+func isVCSRoot(dir string) bool {
+	for _, marker := range vcsMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UseProjectConfig merges the project config file SearchPathGitRoot finds over the config file loaded by UseConfig.
+//
+// Call it after UseConfig, so that the project settings override the user-level (or system-wide) ones.
+// It returns the path of the project config file, empty when there is none.
+// The provenance of the values it provides names it (eg., "config /src/repo/.myapp.yaml:3:1").
+func UseProjectConfig(app string) (string, error) {
+	projectFile, projectSettings = "", nil
+	file, ok := SearchPathGitRoot(app)
+	if !ok {
+		return "", nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	content, _, err = decryptConfig(content)
+	if err != nil {
+		return "", err
+	}
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return "", fmt.Errorf("couldn't read %s: %w", MaskPath(file), err)
+	}
+	settings, _, _ := splitContexts(v.AllSettings())
+	if err := viper.MergeConfigMap(settings); err != nil {
+		return "", err
+	}
+	if configSettings == nil {
+		configSettings = map[string]interface{}{}
+	}
+	configSettings = mergeSettings(configSettings, settings)
+	projectFile, projectSettings = file, settings
+
+	return file, nil
+}
+
+// ConfigCategory returns the category of the search path the loaded config file comes from.
+//
+// It returns false when no config file was loaded or when it does not come from the search paths set by UseSearchPaths.
+func ConfigCategory() (SearchPathCategory, bool) {
+	file := viper.ConfigFileUsed()
+	if file == "" || searchApp == "" {
+		return SearchPathLocal, false
+	}
+	dir := filepath.Dir(file)
+	for _, p := range SearchPaths(searchApp) {
+		abs, err := filepath.Abs(p.Dir)
+		if err != nil {
+			continue
+		}
+		if filepath.Clean(dir) == abs {
+			return p.Category, true
+		}
+	}
+
+	return SearchPathLocal, false
+}
+
+// MaskPath renders the input path for display, hiding the user specific parts.
+//
+// The home directory becomes $HOME (%USERPROFILE% on Windows) and the directory of the executable becomes {executable_dir}.
+func MaskPath(p string) string {
+	if exe, err := os.Executable(); err == nil {
+		if dir := filepath.Dir(exe); hasPathPrefix(p, dir) {
+			return "{executable_dir}" + p[len(dir):]
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" && hasPathPrefix(p, home) {
+		if goos == "windows" {
+			return "%USERPROFILE%" + p[len(home):]
+		}
+
+		return "$HOME" + p[len(home):]
+	}
+
+	return p
+}
+
+// hasPathPrefix tells whether the input path is within the input directory.
+//
+// Windows paths are compared case-insensitively.
+func hasPathPrefix(p, dir string) bool {
+	if len(p) < len(dir) {
+		return false
+	}
+	head, rest := p[:len(dir)], p[len(dir):]
+	if rest != "" && !os.IsPathSeparator(rest[0]) {
+		return false
+	}
+	if goos == "windows" {
+		return strings.EqualFold(head, dir)
+	}
+
+	return head == dir
+}
+
+// envKey normalizes environment variable names for comparisons.
+//
+// Environment variables are case-insensitive on Windows.
+func envKey(name string) string {
+	if goos == "windows" {
+		return strings.ToUpper(name)
+	}
+
+	return name
+}
@@ -0,0 +1,154 @@
+package autoflags
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchPaths(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	assert.Equal(t, []SearchPath{
+		{".", SearchPathLocal},
+		{"/home/user/.app", SearchPathUser},
+		{"/home/user/.config/app", SearchPathUser},
+		{"/etc/app", SearchPathSystem},
+	}, SearchPaths("app"))
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	assert.Equal(t, SearchPath{"/xdg/app", SearchPathUser}, SearchPaths("app")[2])
+
+	goos = "windows"
+	defer func() { goos = runtime.GOOS }()
+	t.Setenv("APPDATA", "/appdata")
+	t.Setenv("PROGRAMDATA", "/programdata")
+	assert.Equal(t, []SearchPath{
+		{".", SearchPathLocal},
+		{filepath.Join("/appdata", "app"), SearchPathUser},
+		{filepath.Join("/programdata", "app"), SearchPathSystem},
+	}, SearchPaths("app"))
+}
+
+func TestConfigCategory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	require.Nil(t, os.MkdirAll(filepath.Join(home, ".app"), 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(home, ".app", "config.yaml"), []byte("a: 1\n"), 0o600))
+	defer func() {
+		viper.Reset()
+		configSettings = nil
+		searchApp = ""
+	}()
+
+	_, ok := ConfigCategory()
+	assert.False(t, ok)
+
+	viper.SetConfigName("config")
+	UseSearchPaths("app")
+	found, msg := UseConfig(nil)
+	require.True(t, found)
+	assert.Equal(t, "Using config file: $HOME/.app/config.yaml", msg)
+	category, ok := ConfigCategory()
+	assert.True(t, ok)
+	assert.Equal(t, SearchPathUser, category)
+}
+
+func TestMaskPath(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	assert.Equal(t, "$HOME/.app/config.yaml", MaskPath("/home/user/.app/config.yaml"))
+	assert.Equal(t, "/home/username/config.yaml", MaskPath("/home/username/config.yaml"))
+	assert.Equal(t, "/etc/app/config.yaml", MaskPath("/etc/app/config.yaml"))
+}
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
+
+func TestSearchPathGitRoot(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.Nil(t, err)
+	repo := filepath.Join(root, "repo")
+	sub := filepath.Join(repo, "pkg", "sub")
+	require.Nil(t, os.MkdirAll(sub, 0o755))
+	require.Nil(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(root, ".app.yaml"), []byte("a: 0\n"), 0o600))
+
+	chdir(t, sub)
+	_, ok := SearchPathGitRoot("app")
+	assert.False(t, ok, "the traversal stops at the repository root")
+
+	require.Nil(t, os.WriteFile(filepath.Join(repo, ".app.toml"), []byte("a = 1\n"), 0o600))
+	file, ok := SearchPathGitRoot("app")
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(repo, ".app.toml"), file)
+
+	require.Nil(t, os.WriteFile(filepath.Join(repo, "pkg", ".app.yaml"), []byte("a: 2\n"), 0o600))
+	file, ok = SearchPathGitRoot("app")
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(repo, "pkg", ".app.yaml"), file)
+
+	// Symbolic links resolve to the real directories
+	link := filepath.Join(root, "link")
+	require.Nil(t, os.Symlink(sub, link))
+	chdir(t, link)
+	file, ok = SearchPathGitRoot("app")
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(repo, "pkg", ".app.yaml"), file)
+
+	// Outside of repositories only the working directory counts
+	outside := filepath.Join(root, "outside")
+	require.Nil(t, os.Mkdir(outside, 0o755))
+	chdir(t, outside)
+	_, ok = SearchPathGitRoot("app")
+	assert.False(t, ok)
+	chdir(t, root)
+	file, ok = SearchPathGitRoot("app")
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(root, ".app.yaml"), file)
+}
+
+func TestUseProjectConfig(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.Nil(t, err)
+	require.Nil(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	project := filepath.Join(root, ".app.yaml")
+	require.Nil(t, os.WriteFile(project, []byte("# project\nserver:\n  port: 2\n"), 0o600))
+	user := filepath.Join(t.TempDir(), "config.yaml")
+	require.Nil(t, os.WriteFile(user, []byte("server:\n  port: 1\nother: true\n"), 0o600))
+	defer func() {
+		viper.Reset()
+		configSettings = nil
+		projectFile, projectSettings = "", nil
+	}()
+
+	chdir(t, root)
+	viper.SetConfigFile(user)
+	found, _ := UseConfig(nil)
+	require.True(t, found)
+	file, err := UseProjectConfig("app")
+	require.Nil(t, err)
+	assert.Equal(t, project, file)
+	assert.Equal(t, 2, viper.GetInt("server.port"))
+	assert.True(t, viper.GetBool("other"))
+
+	c := &cobra.Command{Use: "project"}
+	opts := &portOptions{}
+	require.Nil(t, Define(c, opts))
+	require.Nil(t, Unmarshal(c, opts))
+	assert.Equal(t, 2, opts.Server.Port)
+	assert.Equal(t, "config "+project+":3:3", origin(c, c.Flags().Lookup("server.port")))
+}
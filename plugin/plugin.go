@@ -0,0 +1,142 @@
+// Package plugin lets external binaries declare flags that a host command line registers as its own.
+//
+// The protocol is minimal:
+//   - the host runs `<plugin> describe`, that prints a JSON Description to stdout
+//   - the host registers a command with the described flags, via the schema package
+//   - when such command runs, the host runs `<plugin> <args>` with the resolved values, as a JSON object keyed by flag name, in the ValuesEnv environment variable
+//
+// Plugins implement their side of the protocol with Serve.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/leodido/autoflags"
+	"github.com/leodido/autoflags/schema"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DescribeArg is the argument making plugins print their description
+	DescribeArg = "describe"
+	// ValuesEnv is the environment variable carrying the resolved values to the plugins
+	ValuesEnv = "AUTOFLAGS_PLUGIN_VALUES"
+)
+
+// Description is what plugins print when run with DescribeArg.
+type Description struct {
+	// Name is the name of the command the host registers
+	Name  string              `json:"name"`
+	Short string              `json:"short,omitempty"`
+	Flags []schema.Definition `json:"flags"`
+}
+
+// Describe runs the input plugin binary to get its description.
+func Describe(ctx context.Context, path string) (*Description, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, path, DescribeArg)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("couldn't describe plugin %s: %w: %s", path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	res := &Description{}
+	if err := json.Unmarshal(stdout.Bytes(), res); err != nil {
+		return nil, fmt.Errorf("couldn't parse the description of plugin %s: %w", path, err)
+	}
+	if res.Name == "" {
+		return nil, fmt.Errorf("plugin %s has no name", path)
+	}
+
+	return res, nil
+}
+
+// NewCommand creates the command running the input plugin binary, with the flags of its description.
+//
+// The command resolves the values of the flags from every source, as any other command defined via autoflags,
+// then runs the plugin with them and with its positional arguments.
+func NewCommand(path string, desc *Description) (*cobra.Command, error) {
+	s, err := schema.FromDefinitions(desc.Flags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid description of plugin %s: %w", path, err)
+	}
+	opts, err := s.Build()
+	if err != nil {
+		return nil, fmt.Errorf("invalid description of plugin %s: %w", path, err)
+	}
+
+	c := &cobra.Command{
+		Use:   desc.Name,
+		Short: desc.Short,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := autoflags.Unmarshal(c, opts); err != nil {
+				return err
+			}
+			values, err := encodeValues(opts.Map())
+			if err != nil {
+				return err
+			}
+			cmd := exec.CommandContext(c.Context(), path, args...)
+			cmd.Env = append(os.Environ(), ValuesEnv+"="+values)
+			cmd.Stdin = c.InOrStdin()
+			cmd.Stdout = c.OutOrStdout()
+			cmd.Stderr = c.ErrOrStderr()
+
+			return cmd.Run()
+		},
+	}
+	if err := autoflags.Define(c, opts); err != nil {
+		return nil, fmt.Errorf("couldn't define the flags of plugin %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// encodeValues encodes the input values as JSON, with durations in their textual form.
+func encodeValues(values map[string]interface{}) (string, error) {
+	res := make(map[string]interface{}, len(values))
+	for name, val := range values {
+		if d, ok := val.(time.Duration); ok {
+			val = d.String()
+		}
+		res[name] = val
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return "", fmt.Errorf("couldn't encode the values for the plugin: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// Serve implements the plugin side of the protocol.
+//
+// When the first argument is DescribeArg, it prints the input description.
+// Otherwise it calls run with the values the host resolved and with the arguments.
+func Serve(desc *Description, run func(values map[string]interface{}, args []string) error) error {
+	return serve(desc, os.Args[1:], os.Stdout, run)
+}
+
+func serve(desc *Description, args []string, stdout io.Writer, run func(map[string]interface{}, []string) error) error {
+	if len(args) > 0 && args[0] == DescribeArg {
+		return json.NewEncoder(stdout).Encode(desc)
+	}
+
+	values := map[string]interface{}{}
+	if data := os.Getenv(ValuesEnv); data != "" {
+		if err := json.Unmarshal([]byte(data), &values); err != nil {
+			return fmt.Errorf("couldn't parse the values from the host: %w", err)
+		}
+	}
+
+	return run(values, args)
+}
@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/leodido/autoflags/schema"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var greet = &Description{
+	Name:  "greet",
+	Short: "Greet someone",
+	Flags: []schema.Definition{
+		{Name: "name", Type: "string", Default: "world", Env: true},
+		{Name: "times", Type: "int", Default: "1"},
+		{Name: "pause", Type: "duration", Default: "1s"},
+	},
+}
+
+// TestMain makes the test binary act as the greet plugin when asked to.
+func TestMain(m *testing.M) {
+	if os.Getenv("AUTOFLAGS_TEST_PLUGIN") == "1" {
+		err := Serve(greet, func(values map[string]interface{}, args []string) error {
+			fmt.Printf("hello %s x%v after %s %s\n", values["name"], values["times"], values["pause"], strings.Join(args, " "))
+
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestPlugin(t *testing.T) {
+	t.Setenv("AUTOFLAGS_TEST_PLUGIN", "1")
+	t.Setenv("NAME", "gopher")
+	bin, err := os.Executable()
+	require.Nil(t, err)
+
+	desc, err := Describe(context.Background(), bin)
+	require.Nil(t, err)
+	assert.Equal(t, greet, desc)
+
+	c, err := NewCommand(bin, desc)
+	require.Nil(t, err)
+	assert.Equal(t, "greet", c.Name())
+	assert.NotNil(t, c.Flags().Lookup("times"))
+
+	root := &cobra.Command{Use: "host"}
+	root.AddCommand(c)
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetArgs([]string{"greet", "--times", "3", "now"})
+	require.Nil(t, root.Execute())
+	assert.Equal(t, "hello gopher x3 after 1s now\n", out.String())
+}
+
+func TestServeDescribe(t *testing.T) {
+	out := &bytes.Buffer{}
+	require.Nil(t, serve(greet, []string{DescribeArg}, out, nil))
+	assert.Contains(t, out.String(), `{"name":"greet","short":"Greet someone","flags":[{"name":"name","type":"string","default":"world","env":true}`)
+}
+
+func TestNewCommandInvalid(t *testing.T) {
+	_, err := NewCommand("bin", &Description{Name: "bad", Flags: []schema.Definition{{Name: "x", Type: "float"}}})
+	assert.EqualError(t, err, `invalid description of plugin bin: unknown type "float" for flag --x`)
+}
@@ -0,0 +1,151 @@
+package autoflags
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagPlumbingAnnotation = "___flagplumbing"
+)
+
+// PlumbingGroup is the group of the flags marked via MarkPlumbing.
+const PlumbingGroup = "Plumbing"
+
+// DefaultHelpAllFlag is the name of the flag SetupPlumbing adds to show the help with the plumbing flags.
+const DefaultHelpAllFlag = "help-all"
+
+// MarkPlumbing hides the input flags of the command (eg., the debugging ones), grouping them into the Plumbing group.
+//
+// They keep working, but the help only lists them when the flags SetupPlumbing adds ask so,
+// so that the default help stays focused on the user-facing options.
+// It looks for the flags among the local and persistent flags of the command.
+func MarkPlumbing(c *cobra.Command, names ...string) error {
+	for _, name := range names {
+		f := c.Flags().Lookup(name)
+		if f == nil {
+			f = c.PersistentFlags().Lookup(name)
+		}
+		if f == nil {
+			return fmt.Errorf("couldn't mark --%s as plumbing: no such flag", name)
+		}
+		if f.Annotations == nil {
+			f.Annotations = map[string][]string{}
+		}
+		f.Annotations[FlagPlumbingAnnotation] = []string{"true"}
+		f.Annotations[FlagGroupAnnotation] = []string{PlumbingGroup}
+		f.Hidden = !showAllFlags()
+	}
+
+	return nil
+}
+
+// isPlumbingFlag tells whether the input flag was marked via MarkPlumbing.
+func isPlumbingFlag(f *pflag.Flag) bool {
+	_, ok := f.Annotations[FlagPlumbingAnnotation]
+
+	return ok
+}
+
+// showAllFlags tells whether the <PREFIX>SHOW_ALL_FLAGS environment variable asks for the plumbing flags.
+func showAllFlags() bool {
+	if prefix == "" {
+		return false
+	}
+	show, _ := strconv.ParseBool(os.Getenv(prefix + "SHOW_ALL_FLAGS"))
+
+	return show
+}
+
+// helpAllValue is the flag asking for the help with the plumbing flags.
+//
+// Setting it also sets the help flag of the commands, so that cobra prints the help rather than running the command.
+type helpAllValue struct {
+	rootC *cobra.Command
+	value bool
+}
+
+var _ pflag.Value = (*helpAllValue)(nil)
+
+func (v *helpAllValue) Set(input string) error {
+	val, err := strconv.ParseBool(input)
+	if err != nil {
+		return err
+	}
+	v.value = val
+	if !val {
+		return nil
+	}
+	for _, c := range walkTree(v.rootC) {
+		if help := c.Flags().Lookup("help"); help != nil {
+			_ = help.Value.Set("true")
+		}
+	}
+
+	return nil
+}
+
+func (v *helpAllValue) Type() string {
+	return "bool"
+}
+
+func (v *helpAllValue) String() string {
+	return strconv.FormatBool(v.value)
+}
+
+func (v *helpAllValue) IsBoolFlag() bool {
+	return true
+}
+
+// SetupPlumbing adds the --help-all flag to the input root command, printing the help along with the plumbing flags (see MarkPlumbing).
+//
+// So does the help when the <PREFIX>SHOW_ALL_FLAGS environment variable is true (eg., APP_SHOW_ALL_FLAGS=1).
+// Call it after marking the plumbing flags.
+func SetupPlumbing(rootC *cobra.Command) error {
+	if rootC.PersistentFlags().Lookup(DefaultHelpAllFlag) != nil {
+		return fmt.Errorf("flag --%s is already defined", DefaultHelpAllFlag)
+	}
+	helpAll := &helpAllValue{rootC: rootC}
+	rootC.PersistentFlags().Var(helpAll, DefaultHelpAllFlag, "help, including the plumbing flags")
+	rootC.PersistentFlags().Lookup(DefaultHelpAllFlag).NoOptDefVal = "true"
+
+	help := rootC.HelpFunc()
+	rootC.SetHelpFunc(func(c *cobra.Command, args []string) {
+		if !helpAll.value && !showAllFlags() {
+			help(c, args)
+
+			return
+		}
+		// The flags get hidden again once printed, so that later helps in the same process do not show them
+		hidden := []*pflag.Flag{}
+		reveal := func(f *pflag.Flag) {
+			if isPlumbingFlag(f) && f.Hidden {
+				f.Hidden = false
+				hidden = append(hidden, f)
+			}
+		}
+		c.Flags().VisitAll(reveal)
+		c.InheritedFlags().VisitAll(reveal)
+		usage := c.UsageTemplate()
+		setUsage(c)
+		help(c, args)
+		for _, f := range hidden {
+			f.Hidden = true
+		}
+		c.SetUsageTemplate(usage)
+		if helpAll.value {
+			helpAll.value = false
+			for _, cmd := range walkTree(rootC) {
+				if f := cmd.Flags().Lookup("help"); f != nil {
+					_ = f.Value.Set("false")
+				}
+			}
+		}
+	})
+
+	return nil
+}
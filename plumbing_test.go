@@ -0,0 +1,63 @@
+package autoflags
+
+import (
+	"bytes"
+
+	"github.com/leodido/autoflags/setup"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestPlumbing() {
+	suite.T().Cleanup(func() {
+		viper.Reset()
+		configSettings = nil
+		contextFlag = nil
+		prefix = ""
+	})
+
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "serve", Run: func(c *cobra.Command, args []string) {}}
+	root.AddCommand(sub)
+	suite.Require().Nil(Define(sub, &dryRunOptions{}))
+	suite.Require().Nil(Setup(root, setup.Options{Config: &setup.Config{}, Debug: &setup.Debug{}, Usage: true, Plumbing: true}))
+	suite.EqualError(MarkPlumbing(root, "missing"), "couldn't mark --missing as plumbing: no such flag")
+	suite.EqualError(SetupPlumbing(root), "flag --help-all is already defined")
+
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetArgs([]string{"serve", "--help"})
+	suite.Require().Nil(root.Execute())
+	suite.Contains(out.String(), "--timeout duration")
+	suite.NotContains(out.String(), "--config")
+	suite.NotContains(out.String(), "--dry-run-config")
+	suite.Contains(out.String(), "--help-all")
+
+	out.Reset()
+	root.SetArgs([]string{"serve", "--help-all"})
+	suite.Require().Nil(root.Execute())
+	suite.Contains(out.String(), "--config string")
+	suite.Contains(out.String(), "--dry-run-config")
+
+	out.Reset()
+	root.SetArgs([]string{"--help"})
+	suite.Require().Nil(root.Execute())
+	suite.NotContains(out.String(), "--config")
+	suite.NotContains(out.String(), "Plumbing Flags:")
+
+	out.Reset()
+	root.SetArgs([]string{"serve", "--help"})
+	suite.Require().Nil(root.Execute())
+	suite.NotContains(out.String(), "--dry-run-config")
+
+	out.Reset()
+	root.SetArgs([]string{"--help-all"})
+	suite.Require().Nil(root.Execute())
+	suite.Contains(out.String(), "Plumbing Flags:\n      --config string")
+
+	SetEnvPrefix("APP")
+	suite.T().Setenv("APP_SHOW_ALL_FLAGS", "1")
+	other := &cobra.Command{Use: "other", Run: func(c *cobra.Command, args []string) {}}
+	suite.Require().Nil(Setup(other, setup.Options{Debug: &setup.Debug{}, Plumbing: true}))
+	suite.False(other.PersistentFlags().Lookup("dry-run-config").Hidden)
+}
@@ -0,0 +1,132 @@
+package autoflags
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Policy vetoes resolved values before the commands run (eg., forbidding --insecure in the prod context).
+//
+// Organization-wide policies can be Go functions (see NewPolicy) or wrap a policy engine like OPA,
+// to which PolicyInput marshals as JSON.
+type Policy interface {
+	// ID identifies the policy in the violations
+	ID() string
+	// Evaluate returns the violations of the input values.
+	// Wrap them with NewFieldError to point to the offending flag.
+	// The error is for failing to evaluate the policy.
+	Evaluate(ctx context.Context, input PolicyInput) ([]error, error)
+}
+
+// PolicyInput is what policies evaluate.
+type PolicyInput struct {
+	// Command is the path of the command (eg., "app serve")
+	Command string `json:"command"`
+	// Context is the named context of the config file in use, if any
+	Context string `json:"context,omitempty"`
+	// Values maps the flag names to their resolved values, with the secret ones masked
+	Values map[string]interface{} `json:"values"`
+	// Sources maps the flag names to the sources of their values (ie., "flag", "env", "config", "default")
+	Sources map[string]string `json:"sources"`
+}
+
+// PolicyError is a violation of a policy.
+type PolicyError struct {
+	Policy string
+	Err    error
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("policy %s: %s", e.Policy, e.Err.Error())
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+type policyFunc struct {
+	id string
+	fn func(context.Context, PolicyInput) []error
+}
+
+func (p *policyFunc) ID() string {
+	return p.id
+}
+
+func (p *policyFunc) Evaluate(ctx context.Context, input PolicyInput) ([]error, error) {
+	return p.fn(ctx, input), nil
+}
+
+// NewPolicy returns the policy with the given identifier whose violations the input function returns.
+func NewPolicy(id string, fn func(ctx context.Context, input PolicyInput) []error) Policy {
+	return &policyFunc{id: id, fn: fn}
+}
+
+var policies = []Policy{}
+
+// RegisterPolicy makes Unmarshal evaluate the input policy once the options are resolved, before their validation.
+//
+// Violations make Unmarshal return a ValidationError, whose issues carry the identifier of the policy.
+func RegisterPolicy(p Policy) {
+	policies = append(policies, p)
+}
+
+// checkPolicies evaluates the registered policies against the resolved options.
+func checkPolicies(c *cobra.Command, opts interface{}) error {
+	if len(policies) == 0 {
+		return nil
+	}
+	input := policyInput(c, opts)
+	ctx := c.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	violations := []error{}
+	for _, p := range policies {
+		errs, err := p.Evaluate(ctx, input)
+		if err != nil {
+			return fmt.Errorf("couldn't evaluate policy %s: %w", p.ID(), err)
+		}
+		for _, e := range errs {
+			violations = append(violations, &PolicyError{Policy: p.ID(), Err: e})
+		}
+	}
+	if len(violations) > 0 {
+		return newValidationError(c, opts, violations)
+	}
+
+	return nil
+}
+
+// policyInput collects the resolved values of the flags bound to the input options.
+func policyInput(c *cobra.Command, opts interface{}) PolicyInput {
+	s := getScope(c)
+	res := PolicyInput{
+		Command: c.CommandPath(),
+		Context: CurrentContext(),
+		Values:  map[string]interface{}{},
+		Sources: map[string]string{},
+	}
+	root := reflect.ValueOf(target(opts))
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		path, ok := s.flagPath(f.Name)
+		if !ok {
+			return
+		}
+		field, ok := fieldByPath(root, path)
+		if !ok {
+			return
+		}
+		res.Values[f.Name] = field.Interface()
+		if isSecretFlag(f) && !field.IsZero() {
+			res.Values[f.Name] = "***"
+		}
+		res.Sources[f.Name] = winner(lookupSources(c, f), s.precedenceOf(f.Name)).String()
+	})
+
+	return res
+}
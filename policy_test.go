@@ -0,0 +1,66 @@
+package autoflags
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestPolicies() {
+	suite.T().Cleanup(func() {
+		policies = []Policy{}
+	})
+	var got PolicyInput
+	RegisterPolicy(NewPolicy("no-insecure", func(ctx context.Context, input PolicyInput) []error {
+		got = input
+		if insecure, _ := input.Values["insecure"].(bool); insecure && input.Sources["insecure"] == "env" {
+			return []error{NewFieldError("insecure", errors.New("insecure connections must not be enabled by the environment"))}
+		}
+
+		return nil
+	}))
+
+	c := &cobra.Command{Use: "policy"}
+	opts := &vetoOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--insecure", "--token", "s3cr3t"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(PolicyInput{
+		Command: "policy",
+		Values:  map[string]interface{}{"insecure": true, "token": "***"},
+		Sources: map[string]string{"insecure": "flag", "token": "flag"},
+	}, got)
+
+	suite.T().Setenv("INSECURE", "true")
+	c = &cobra.Command{Use: "policy"}
+	opts = &vetoOptions{}
+	suite.Require().Nil(Define(c, opts))
+	var validationErr *ValidationError
+	suite.Require().ErrorAs(Unmarshal(c, opts), &validationErr)
+	suite.Require().Len(validationErr.Issues, 1)
+	suite.Equal("no-insecure", validationErr.Issues[0].Policy)
+	suite.Equal("policy no-insecure: insecure connections must not be enabled by the environment (from env INSECURE; --insecure, env INSECURE, config key insecure)", validationErr.Issues[0].String())
+
+	RegisterPolicy(&failingPolicy{id: "opa"})
+	suite.EqualError(Unmarshal(c, opts), "couldn't evaluate policy opa: unreachable")
+}
+
+type failingPolicy struct {
+	id string
+}
+
+func (p *failingPolicy) ID() string {
+	return p.id
+}
+
+func (p *failingPolicy) Evaluate(ctx context.Context, input PolicyInput) ([]error, error) {
+	return nil, errors.New("unreachable")
+}
+
+type vetoOptions struct {
+	fixture
+
+	Insecure bool   `flagenv:"true"`
+	Token    string `flagsecret:"true"`
+}
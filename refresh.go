@@ -0,0 +1,161 @@
+package autoflags
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+)
+
+// RefreshFunc returns the current value of a secret (eg., a freshly issued token), given the one in use.
+type RefreshFunc func(current string) (string, error)
+
+// refresher is a RefreshFunc along with the interval to call it at.
+type refresher struct {
+	every time.Duration
+	fn    RefreshFunc
+	next  time.Time
+}
+
+// RegisterRefresh makes WatchSecrets call the input function every given interval to refresh the value of a secret flag, by flag name or struct path.
+//
+// Only the string flags tagged with flagsecret (or flagkeyring) can be refreshed.
+// Call it after Define, and before WatchSecrets.
+func RegisterRefresh(c *cobra.Command, key string, every time.Duration, fn RefreshFunc) error {
+	s, ok := scopes[c]
+	if !ok {
+		return fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	name := key
+	if c.Flags().Lookup(name) == nil {
+		if name, ok = s.paths[strings.ToLower(key)]; !ok {
+			return fmt.Errorf("couldn't refresh %s: no such flag", key)
+		}
+	}
+	if !isSecretFlag(c.Flags().Lookup(name)) || s.types[name].Kind() != reflect.String {
+		return fmt.Errorf("couldn't refresh --%s: only string secret flags can be refreshed", name)
+	}
+	if every <= 0 {
+		return fmt.Errorf("couldn't refresh --%s: the interval must be positive", name)
+	}
+	unmarshalMu.Lock()
+	s.refreshes[name] = &refresher{every: every, fn: fn}
+	unmarshalMu.Unlock()
+
+	return nil
+}
+
+// WatchSecrets resolves the input options type as Resolve does, then keeps refreshing the secrets registered via RegisterRefresh until the context is done.
+//
+// It returns a function loading the options last resolved, safe for concurrent use, and a function stopping the refreshes.
+// Whenever a refresh function returns a new value, it sets it via WithValues and atomically swaps in the options resolved again,
+// so that long-running commands (eg., daemons) pick up the rotated credentials without restarting.
+// Treat the loaded options as read-only: the next refresh replaces them as a whole rather than updating them.
+// Failures keep the previous options, and go to the input error handler (if any): the secret refreshes again at its next interval.
+// The refreshes unmarshal under the same lock as Unmarshal, so that the command can be unmarshalled concurrently.
+// Stop returns once no refresh is running anymore: call it before discarding the command (eg., deferring it).
+func WatchSecrets[T options.Options](ctx context.Context, c *cobra.Command, onError func(error)) (load func() T, stop func(), err error) {
+	opts, err := Resolve[T](c)
+	if err != nil {
+		return nil, nil, err
+	}
+	var snapshot atomic.Pointer[T]
+	snapshot.Store(&opts)
+	load = func() T {
+		return *snapshot.Load()
+	}
+
+	s := getScope(c)
+	unmarshalMu.Lock()
+	refreshes := map[string]*refresher{}
+	for name, r := range s.refreshes {
+		refreshes[name] = &refresher{every: r.every, fn: r.fn}
+	}
+	unmarshalMu.Unlock()
+	if len(refreshes) == 0 {
+		return load, func() {}, nil
+	}
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+	now := time.Now()
+	for _, r := range refreshes {
+		r.next = now.Add(r.every)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	stop = func() {
+		cancel()
+		<-done
+	}
+	go func() {
+		defer close(done)
+		for {
+			next := time.Time{}
+			for _, r := range refreshes {
+				if next.IsZero() || r.next.Before(next) {
+					next = r.next
+				}
+			}
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return
+			case now := <-timer.C:
+				values := map[string]interface{}{}
+				for name, r := range refreshes {
+					if r.next.After(now) {
+						continue
+					}
+					r.next = now.Add(r.every)
+					current := currentSecret(s, load(), name)
+					secret, err := r.fn(current)
+					if err != nil {
+						report(fmt.Errorf("couldn't refresh --%s: %w", name, err))
+
+						continue
+					}
+					if secret != current {
+						values[name] = secret
+					}
+				}
+				if len(values) == 0 || ctx.Err() != nil {
+					continue
+				}
+				_ = WithValues(c, values)
+				opts, err := Resolve[T](c)
+				if err != nil {
+					report(err)
+
+					continue
+				}
+				snapshot.Store(&opts)
+			}
+		}
+	}()
+
+	return load, stop, nil
+}
+
+// currentSecret returns the value of the input secret flag in the input options.
+func currentSecret(s *scope, opts options.Options, name string) string {
+	path, ok := s.flagPath(name)
+	if !ok {
+		return ""
+	}
+	field, ok := fieldByPath(reflect.ValueOf(opts), path)
+	if !ok {
+		return ""
+	}
+
+	return field.String()
+}
@@ -0,0 +1,56 @@
+package autoflags
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestWatchSecrets() {
+	suite.T().Setenv("TOKEN", "t0")
+
+	c := &cobra.Command{Use: "watch"}
+	suite.Require().Nil(Define(c, &secretRefOptions{}))
+	suite.EqualError(RegisterRefresh(c, "endpoint", time.Millisecond, nil), "couldn't refresh --endpoint: only string secret flags can be refreshed")
+	suite.EqualError(RegisterRefresh(c, "missing", time.Millisecond, nil), "couldn't refresh missing: no such flag")
+	suite.EqualError(RegisterRefresh(c, "token", 0, nil), "couldn't refresh --token: the interval must be positive")
+
+	var calls atomic.Int32
+	suite.Require().Nil(RegisterRefresh(c, "token", 10*time.Millisecond, func(current string) (string, error) {
+		if calls.Add(1) == 1 {
+			return "", fmt.Errorf("expired")
+		}
+
+		return current + "+", nil
+	}))
+	errs := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	load, stop, err := WatchSecrets[*secretRefOptions](ctx, c, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	suite.Require().Nil(err)
+	defer stop()
+	suite.Equal("t0", load().Token)
+	suite.EqualError(<-errs, "couldn't refresh --token: expired")
+	// Unmarshalling concurrently with the refreshes is safe
+	for i := 0; i < 5; i++ {
+		suite.Require().Nil(Unmarshal(c, &secretRefOptions{}))
+	}
+	suite.Eventually(func() bool {
+		return strings.HasPrefix(load().Token, "t0++")
+	}, time.Second, 5*time.Millisecond)
+
+	// No refresh runs once stopped
+	stop()
+	stopped := calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	suite.Equal(stopped, calls.Load())
+}
@@ -0,0 +1,38 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+)
+
+// DefineHookFunc defines the flag of a field whose type is registered via RegisterType.
+//
+// It has the signature of the Define<Field> methods the flagcustom tag calls.
+type DefineHookFunc func(c *cobra.Command, typename, name, short, descr string)
+
+// registeredTypes maps the types registered via RegisterType to their define hooks.
+var registeredTypes = map[reflect.Type]DefineHookFunc{}
+
+// RegisterType makes Define and Unmarshal handle the fields of the input type via the given hooks, wherever they appear.
+//
+// It spares tagging every such field with flagcustom and duplicating the hooks on each options struct.
+// The define hook creates the flag, while the decode hook turns the values of the flag (eg., strings from env or config) into the type.
+// The decode hook also applies to the slices and maps of the type defined via flagcustom.
+// The flagcustom tag still takes precedence over the registered hooks.
+// Call it before Define, typically from an init function.
+func RegisterType(typ reflect.Type, define DefineHookFunc, decode mapstructure.DecodeHookFunc) {
+	registeredTypes[typ] = define
+	hook, ok := scalarDecodeHooks[typ]
+	if !ok {
+		// Types of different packages can share their names, so the names of their hooks get a suffix when taken
+		hook = fmt.Sprintf("RegisteredTypeHookFunc[%s]", typ)
+		for n := 2; decodeHookRegistry[hook] != nil; n++ {
+			hook = fmt.Sprintf("RegisteredTypeHookFunc[%s#%d]", typ, n)
+		}
+	}
+	decodeHookRegistry[hook] = decode
+	scalarDecodeHooks[typ] = hook
+}
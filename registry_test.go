@@ -0,0 +1,61 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/spf13/cobra"
+)
+
+type point struct {
+	X, Y int
+}
+
+type registeredOptions struct {
+	fixture
+
+	Origin point   `flagenv:"true"`
+	Target point   `default:"1,1"`
+	Path   []point `flagcustom:"true"`
+}
+
+func (o *registeredOptions) DefinePath(c *cobra.Command, typename, name, short, descr string) {
+	c.Flags().StringSliceP(name, short, nil, descr)
+}
+
+func (suite *UnmarshalSuite) TestRegisterType() {
+	typ := reflect.TypeOf(point{})
+	RegisterType(typ, func(c *cobra.Command, typename, name, short, descr string) {
+		c.Flags().StringP(name, short, "", descr)
+	}, func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != typ {
+			return data, nil
+		}
+		p := point{}
+		if _, err := fmt.Sscanf(data.(string), "%d,%d", &p.X, &p.Y); err != nil {
+			return nil, fmt.Errorf("invalid point %q", data)
+		}
+
+		return p, nil
+	})
+	suite.T().Cleanup(func() {
+		delete(registeredTypes, typ)
+		delete(decodeHookRegistry, scalarDecodeHooks[typ])
+		delete(scalarDecodeHooks, typ)
+	})
+	suite.useConfigFile("config.yaml", "path: [\"0,0\", \"2,3\"]\n")
+	suite.T().Setenv("ORIGIN", "4,5")
+
+	c := &cobra.Command{Use: "registered"}
+	opts := &registeredOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Nil(c.Flags().Lookup("origin.x"))
+	suite.Require().Nil(c.Flags().Parse([]string{"--target", "7,8"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(point{4, 5}, opts.Origin)
+	suite.Equal(point{7, 8}, opts.Target)
+	suite.Equal([]point{{0, 0}, {2, 3}}, opts.Path)
+
+	suite.T().Setenv("ORIGIN", "here")
+	suite.ErrorContains(Unmarshal(c, opts), `invalid point "here"`)
+}
@@ -0,0 +1,201 @@
+package autoflags
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagDependsOnAnnotation     = "___flagdependson"
+	FlagConflictsWithAnnotation = "___flagconflictswith"
+	FlagTogetherAnnotation      = "___flagtogether"
+	FlagOneOfAnnotation         = "___flagoneof"
+)
+
+// parseFlagList splits the comma separated value of tags like flagdependson.
+func parseFlagList(tag string) []string {
+	res := []string{}
+	for _, name := range strings.Split(tag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			res = append(res, name)
+		}
+	}
+
+	return res
+}
+
+// lookupRelated finds the flag the input name (a flag name or a struct path) refers to.
+func lookupRelated(c *cobra.Command, name string) *pflag.Flag {
+	if f := c.Flags().Lookup(name); f != nil {
+		return f
+	}
+	if s, ok := scopes[c]; ok {
+		if flagName, ok := s.paths[strings.ToLower(name)]; ok {
+			return c.Flags().Lookup(flagName)
+		}
+	}
+
+	return nil
+}
+
+// checkRelatedNames errors when the flags the input command defined, but the input existing ones,
+// depend on or conflict with flags that the command does not have, or form groups of a single flag.
+//
+// The related flags can be defined by the same options struct, or by the ones defined before it.
+func checkRelatedNames(c *cobra.Command, existing map[string]bool) error {
+	var err error
+	tags := []struct{ tag, annotation string }{
+		{"flagdependson", FlagDependsOnAnnotation},
+		{"flagconflictswith", FlagConflictsWithAnnotation},
+	}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || existing[f.Name] {
+			return
+		}
+		for _, t := range tags {
+			for _, name := range f.Annotations[t.annotation] {
+				if lookupRelated(c, name) == nil {
+					err = fmt.Errorf("invalid %s tag on --%s: no such flag %s", t.tag, f.Name, name)
+
+					return
+				}
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	// Groups of a single flag are likely typos of the names of the other ones
+	for _, t := range []struct{ tag, annotation string }{
+		{"flagtogether", FlagTogetherAnnotation},
+		{"flagoneof", FlagOneOfAnnotation},
+	} {
+		groups, members := flagGroups(c, t.annotation)
+		for _, group := range groups {
+			if f := members[group][0]; len(members[group]) < 2 && !existing[f.Name] {
+				return fmt.Errorf("invalid %s tag on --%s: no other flag in the %s group", t.tag, f.Name, group)
+			}
+		}
+	}
+
+	return nil
+}
+
+// alternatives describes all the inputs that can set the input flag.
+func alternatives(f *pflag.Flag) string {
+	inputs := []string{fmt.Sprintf("--%s", f.Name)}
+	for _, env := range f.Annotations[FlagEnvsAnnotation] {
+		inputs = append(inputs, fmt.Sprintf("env %s", env))
+	}
+	inputs = append(inputs, fmt.Sprintf("config key %s", f.Name))
+	if len(inputs) == 2 {
+		return strings.Join(inputs, " or ")
+	}
+
+	return fmt.Sprintf("%s, or %s", strings.Join(inputs[:len(inputs)-1], ", "), inputs[len(inputs)-1])
+}
+
+// flagGroups collects the flags of the command by the groups the input annotation (eg., FlagTogetherAnnotation) puts them in.
+//
+// It also returns the names of the groups, sorted, so that the checks report them in a stable order.
+func flagGroups(c *cobra.Command, annotation string) ([]string, map[string][]*pflag.Flag) {
+	groups := map[string][]*pflag.Flag{}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		for _, group := range f.Annotations[annotation] {
+			groups[group] = append(groups[group], f)
+		}
+	})
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, groups
+}
+
+// checkRelations errors when flags are set without the flags they depend on, or along with the flags they conflict with,
+// when the flags sharing a flagtogether group are not set together, and when none of the flags sharing a flagoneof group is set.
+//
+// Flags count as set when their value comes from any source other than the defaults.
+// That is why the groups are not marked to cobra (ie., via MarkFlagsRequiredTogether and MarkFlagsOneRequired), which only knows about the command line:
+// they are enforced by Unmarshal alone, and neither cobra's help nor its completion knows about them.
+// It reports all the violations at once.
+func checkRelations(c *cobra.Command) error {
+	provenance, err := Provenance(c)
+	if err != nil {
+		return err
+	}
+	errs := []error{}
+	conflicts := map[string]bool{}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if provenance[f.Name] == SourceDefault {
+			return
+		}
+		for _, dep := range f.Annotations[FlagDependsOnAnnotation] {
+			other := lookupRelated(c, dep)
+			if other == nil {
+				errs = append(errs, fmt.Errorf("flag --%s depends on unknown flag %s", f.Name, dep))
+
+				continue
+			}
+			if provenance[other.Name] == SourceDefault {
+				errs = append(errs, fmt.Errorf("flag --%s (from %s) requires %s to be set too (via %s)", f.Name, origin(c, f), other.Name, alternatives(other)))
+			}
+		}
+		for _, conflict := range f.Annotations[FlagConflictsWithAnnotation] {
+			other := lookupRelated(c, conflict)
+			if other == nil {
+				errs = append(errs, fmt.Errorf("flag --%s conflicts with unknown flag %s", f.Name, conflict))
+
+				continue
+			}
+			if provenance[other.Name] == SourceDefault || conflicts[other.Name+"\x00"+f.Name] {
+				continue
+			}
+			conflicts[f.Name+"\x00"+other.Name] = true
+			errs = append(errs, fmt.Errorf("flag --%s (from %s) conflicts with --%s (from %s)", f.Name, origin(c, f), other.Name, origin(c, other)))
+		}
+	})
+	groups, members := flagGroups(c, FlagTogetherAnnotation)
+	for _, group := range groups {
+		var set *pflag.Flag
+		for _, f := range members[group] {
+			if provenance[f.Name] != SourceDefault {
+				set = f
+
+				break
+			}
+		}
+		if set == nil {
+			continue
+		}
+		for _, f := range members[group] {
+			if provenance[f.Name] == SourceDefault {
+				errs = append(errs, fmt.Errorf("flag --%s (from %s) requires %s to be set too, as they form the %s group (via %s)", set.Name, origin(c, set), f.Name, group, alternatives(f)))
+			}
+		}
+	}
+	groups, members = flagGroups(c, FlagOneOfAnnotation)
+	for _, group := range groups {
+		inputs := []string{}
+		for _, f := range members[group] {
+			if provenance[f.Name] != SourceDefault {
+				inputs = nil
+
+				break
+			}
+			inputs = append(inputs, fmt.Sprintf("%s (via %s)", f.Name, alternatives(f)))
+		}
+		if inputs != nil {
+			errs = append(errs, fmt.Errorf("one of the flags of the %s group must be set: %s", group, strings.Join(inputs, "; ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
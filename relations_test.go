@@ -0,0 +1,154 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestDependsOn() {
+	c := &cobra.Command{Use: "tls"}
+	opts := &tlsOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--tls-key", "key.pem"}))
+	suite.EqualError(Unmarshal(c, opts), "flag --tls-key (from flag) requires tls-cert to be set too (via --tls-cert, env CERT, env TLS_CERT, or config key tls-cert)")
+
+	suite.T().Setenv("TLS_CERT", "cert.pem")
+	suite.Nil(Unmarshal(c, opts))
+
+	c = &cobra.Command{Use: "typo"}
+	suite.EqualError(Define(c, &typoDependsOptions{}), "invalid flagdependson tag on --key: no such flag crt")
+}
+
+func (suite *UnmarshalSuite) TestConflictsWith() {
+	file := suite.useConfigFile("config.yaml", "json: true\n")
+
+	c := &cobra.Command{Use: "output"}
+	opts := &outputOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--yaml"}))
+	suite.EqualError(Unmarshal(c, opts), "flag --json (from config "+file+":1:1) conflicts with --yaml (from flag)")
+
+	// All the violations are reported
+	c = &cobra.Command{Use: "all"}
+	tls := &tlsOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Define(c, tls))
+	suite.Require().Nil(c.Flags().Parse([]string{"--yaml", "--tls-key", "key.pem"}))
+	suite.EqualError(Unmarshal(c, opts), "flag --json (from config "+file+":1:1) conflicts with --yaml (from flag)\n"+
+		"flag --tls-key (from flag) requires tls-cert to be set too (via --tls-cert, env CERT, env TLS_CERT, or config key tls-cert)")
+
+	c = &cobra.Command{Use: "typo"}
+	suite.EqualError(Define(c, &typoConflictsOptions{}), "invalid flagconflictswith tag on --json: no such flag yml")
+}
+
+func (suite *UnmarshalSuite) TestRequiredTogether() {
+	c := &cobra.Command{Use: "pair"}
+	opts, ca := &pairOptions{}, &caOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Define(c, ca))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--tls-cert", "cert.pem"}))
+	suite.T().Setenv("TLS_KEY", "key.pem")
+	suite.EqualError(Unmarshal(c, opts), "flag --tls-cert (from flag) requires tls-ca to be set too, as they form the tls group (via --tls-ca or config key tls-ca)")
+	suite.useConfigFile("config.yaml", "tls-ca: ca.pem\n")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("key.pem", opts.Key)
+
+	// The env alone sets the group too
+	c = &cobra.Command{Use: "pair"}
+	opts = &pairOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.useConfigFile("config.yaml", "proxy-port: 3128\n")
+	suite.ErrorContains(Unmarshal(c, opts), "flag --proxy-port (from config ")
+	suite.ErrorContains(Unmarshal(c, opts), ") requires proxy-host to be set too, as they form the proxy group")
+	suite.useConfigFile("config.yaml", "proxy-port: 3128\nproxy-host: proxy\n")
+	suite.EqualError(Unmarshal(c, opts), "flag --tls-key (from env TLS_KEY) requires tls-cert to be set too, as they form the tls group (via --tls-cert or config key tls-cert)")
+	suite.useConfigFile("config.yaml", "proxy-port: 3128\nproxy-host: proxy\ntls-cert: cert.pem\n")
+	suite.Nil(Unmarshal(c, opts))
+
+	// Groups need two flags at least, the ones of the options defined before included
+	c = &cobra.Command{Use: "typo"}
+	suite.EqualError(Define(c, ca), "invalid flagtogether tag on --tls-ca: no other flag in the tls group")
+}
+
+func (suite *UnmarshalSuite) TestOneRequired() {
+	c := &cobra.Command{Use: "auth"}
+	opts := &authOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.EqualError(Unmarshal(c, opts), "one of the flags of the auth group must be set: token (via --token, env TOKEN, or config key token); username (via --username or config key username)")
+
+	suite.T().Setenv("TOKEN", "from-env")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("from-env", opts.Token)
+
+	c = &cobra.Command{Use: "auth"}
+	opts = &authOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--username", "me"}))
+	suite.Nil(Unmarshal(c, opts))
+
+	c = &cobra.Command{Use: "typo"}
+	suite.EqualError(Define(c, &typoOneOfOptions{}), "invalid flagoneof tag on --username: no other flag in the auht group")
+}
+
+type typoOneOfOptions struct {
+	fixture
+
+	Token    string `flagoneof:"auth"`
+	Username string `flagoneof:"auht"`
+}
+
+type pairOptions struct {
+	fixture
+
+	Cert      string `flag:"tls-cert" flagtogether:"tls"`
+	Key       string `flag:"tls-key" flagtogether:"tls" flagenv:"true"`
+	ProxyHost string `flag:"proxy-host" flagtogether:"proxy"`
+	ProxyPort int    `flag:"proxy-port" flagtogether:"proxy"`
+}
+
+type caOptions struct {
+	fixture
+
+	CA string `flag:"tls-ca" flagtogether:"tls"`
+}
+
+type authOptions struct {
+	fixture
+
+	Token    string `flagoneof:"auth" flagenv:"true"`
+	Username string `flagoneof:"auth"`
+	Password string
+}
+
+type outputOptions struct {
+	fixture
+
+	JSON bool `flagconflictswith:"yaml"`
+	YAML bool `flagconflictswith:"json"`
+}
+
+type typoConflictsOptions struct {
+	fixture
+
+	JSON bool `flagconflictswith:"yml"`
+	YAML bool
+}
+
+type tlsOptions struct {
+	fixture
+
+	Cert string `flag:"tls-cert" flagenv:"true"`
+	Key  string `flag:"tls-key" flagdependson:"tls-cert"`
+}
+
+type typoDependsOptions struct {
+	fixture
+
+	Cert string
+	Key  string `flagdependson:"crt"`
+}
@@ -0,0 +1,66 @@
+package autoflags
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ConfigFetcher retrieves the content of a config file from a remote location.
+//
+// The object storage fetchers live in their own modules, so that autoflags does not depend on the SDKs of their providers:
+// github.com/leodido/autoflags/remote/s3 handles the s3:// URIs, and github.com/leodido/autoflags/remote/gs the gs:// ones.
+// Programs register them, or their own fetchers, via RegisterConfigFetcher.
+type ConfigFetcher interface {
+	Fetch(ctx context.Context, uri *url.URL) (io.ReadCloser, error)
+}
+
+// ConfigFetcherFunc is an adapter to use ordinary functions as ConfigFetcher.
+type ConfigFetcherFunc func(ctx context.Context, uri *url.URL) (io.ReadCloser, error)
+
+func (f ConfigFetcherFunc) Fetch(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+	return f(ctx, uri)
+}
+
+var fetchers = map[string]ConfigFetcher{}
+
+// RegisterConfigFetcher makes UseConfigURI handle the URIs with the given scheme (eg., "s3", "gs") via the input fetcher.
+//
+// UseConfigURI errors on the URIs of the schemes without a registered fetcher.
+func RegisterConfigFetcher(scheme string, f ConfigFetcher) {
+	fetchers[strings.ToLower(scheme)] = f
+}
+
+// UseConfigURI reads the config file at the input URI via the fetcher registered for its scheme.
+//
+// The config format follows the extension of the URI path.
+// Encrypted contents are decrypted like UseConfig does.
+func UseConfigURI(ctx context.Context, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("invalid config URI %s: %w", uri, err)
+	}
+	f, ok := fetchers[strings.ToLower(u.Scheme)]
+	if !ok {
+		return fmt.Errorf("no config fetcher registered for scheme %q", u.Scheme)
+	}
+	format := strings.TrimPrefix(path.Ext(u.Path), ".")
+	if format == "" {
+		return fmt.Errorf("couldn't infer the config format of %s", uri)
+	}
+
+	rc, err := f.Fetch(ctx, u)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch the config from %s: %w", uri, err)
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch the config from %s: %w", uri, err)
+	}
+
+	return readConfig(content, format, true)
+}
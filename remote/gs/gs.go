@@ -0,0 +1,52 @@
+// Package gs fetches the config files stored in Google Cloud Storage, for autoflags.UseConfigURI.
+//
+// It is a module on its own, so that autoflags does not depend on the Google Cloud SDK.
+// Programs opt in by registering the fetcher, that handles the gs://bucket/object URIs:
+//
+//	if err := gs.Register(ctx); err != nil {
+//		return err
+//	}
+//	if err := autoflags.UseConfigURI(ctx, "gs://bucket/app.yaml"); err != nil {
+//		return err
+//	}
+package gs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/leodido/autoflags"
+	"google.golang.org/api/option"
+)
+
+// Scheme is the URI scheme the fetcher handles.
+const Scheme = "gs"
+
+// Register makes autoflags.UseConfigURI fetch the gs:// URIs with a client using the application default credentials.
+//
+// The input options customize the client (eg., option.WithCredentialsFile).
+func Register(ctx context.Context, opts ...option.ClientOption) error {
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("couldn't create the storage client: %w", err)
+	}
+	autoflags.RegisterConfigFetcher(Scheme, NewFetcher(client))
+
+	return nil
+}
+
+// NewFetcher returns a fetcher getting the gs://bucket/object URIs via the input client.
+func NewFetcher(client *storage.Client) autoflags.ConfigFetcher {
+	return autoflags.ConfigFetcherFunc(func(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+		bucket, object := uri.Host, strings.TrimPrefix(uri.Path, "/")
+		if bucket == "" || object == "" {
+			return nil, fmt.Errorf("the URI must have the form gs://bucket/object")
+		}
+
+		return client.Bucket(bucket).Object(object).NewReader(ctx)
+	})
+}
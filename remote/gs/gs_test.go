@@ -0,0 +1,25 @@
+package gs
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestFetcherURI(t *testing.T) {
+	client, err := storage.NewClient(context.Background(), option.WithoutAuthentication())
+	require.Nil(t, err)
+	f := NewFetcher(client)
+
+	for _, str := range []string{"gs://bucket", "gs:///app.yaml"} {
+		uri, err := url.Parse(str)
+		require.Nil(t, err)
+		_, err = f.Fetch(context.Background(), uri)
+		assert.EqualError(t, err, "the URI must have the form gs://bucket/object")
+	}
+}
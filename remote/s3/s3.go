@@ -0,0 +1,62 @@
+// Package s3 fetches the config files stored in Amazon S3, for autoflags.UseConfigURI.
+//
+// It is a module on its own, so that autoflags does not depend on the AWS SDK.
+// Programs opt in by registering the fetcher, that handles the s3://bucket/key URIs:
+//
+//	if err := s3.Register(ctx); err != nil {
+//		return err
+//	}
+//	if err := autoflags.UseConfigURI(ctx, "s3://bucket/app.yaml"); err != nil {
+//		return err
+//	}
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/leodido/autoflags"
+)
+
+// Scheme is the URI scheme the fetcher handles.
+const Scheme = "s3"
+
+// GetObjectAPI is the part of the S3 client the fetcher uses.
+type GetObjectAPI interface {
+	GetObject(ctx context.Context, params *awss3.GetObjectInput, optFns ...func(*awss3.Options)) (*awss3.GetObjectOutput, error)
+}
+
+// Register makes autoflags.UseConfigURI fetch the s3:// URIs with a client using the credentials of the default AWS SDK chain.
+//
+// The input options customize the loading of the AWS config (eg., config.WithRegion).
+func Register(ctx context.Context, optFns ...func(*config.LoadOptions) error) error {
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return fmt.Errorf("couldn't load the AWS config: %w", err)
+	}
+	autoflags.RegisterConfigFetcher(Scheme, NewFetcher(awss3.NewFromConfig(cfg)))
+
+	return nil
+}
+
+// NewFetcher returns a fetcher getting the s3://bucket/key URIs via the input client.
+func NewFetcher(client GetObjectAPI) autoflags.ConfigFetcher {
+	return autoflags.ConfigFetcherFunc(func(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+		bucket, key := uri.Host, strings.TrimPrefix(uri.Path, "/")
+		if bucket == "" || key == "" {
+			return nil, fmt.Errorf("the URI must have the form s3://bucket/key")
+		}
+		out, err := client.GetObject(ctx, &awss3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		if err != nil {
+			return nil, err
+		}
+
+		return out.Body, nil
+	})
+}
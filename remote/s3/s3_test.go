@@ -0,0 +1,43 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	bucket, key string
+}
+
+func (c *fakeClient) GetObject(ctx context.Context, params *awss3.GetObjectInput, optFns ...func(*awss3.Options)) (*awss3.GetObjectOutput, error) {
+	c.bucket, c.key = *params.Bucket, *params.Key
+
+	return &awss3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("port: 7\n"))}, nil
+}
+
+func TestFetcher(t *testing.T) {
+	client := &fakeClient{}
+	f := NewFetcher(client)
+
+	uri, err := url.Parse("s3://bucket/dir/app.yaml")
+	require.Nil(t, err)
+	rc, err := f.Fetch(context.Background(), uri)
+	require.Nil(t, err)
+	content, err := io.ReadAll(rc)
+	require.Nil(t, err)
+	assert.Equal(t, "port: 7\n", string(content))
+	assert.Equal(t, "bucket", client.bucket)
+	assert.Equal(t, "dir/app.yaml", client.key)
+
+	uri, err = url.Parse("s3://bucket")
+	require.Nil(t, err)
+	_, err = f.Fetch(context.Background(), uri)
+	assert.EqualError(t, err, "the URI must have the form s3://bucket/key")
+}
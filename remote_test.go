@@ -0,0 +1,33 @@
+package autoflags
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestConfigURI() {
+	RegisterConfigFetcher("mem", ConfigFetcherFunc(func(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+		suite.Equal("bucket", uri.Host)
+
+		return io.NopCloser(strings.NewReader(`{"server": {"port": 7}}`)), nil
+	}))
+	suite.T().Cleanup(func() {
+		delete(fetchers, "mem")
+		viper.Reset()
+		configSettings = nil
+	})
+
+	suite.EqualError(UseConfigURI(context.Background(), "nope://bucket/app.json"), `no config fetcher registered for scheme "nope"`)
+	suite.Require().Nil(UseConfigURI(context.Background(), "mem://bucket/app.json"))
+
+	c := &cobra.Command{Use: "remote"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(7, opts.Server.Port)
+}
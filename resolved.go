@@ -0,0 +1,83 @@
+package autoflags
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+)
+
+// flagPath returns the struct path of the input flag name.
+func (s *scope) flagPath(name string) (string, bool) {
+	for path, n := range s.paths {
+		if n == name {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// fieldValue returns the field bound to the input flag, looking into the options attached to the command by reference.
+func fieldValue(c *cobra.Command, name string) (reflect.Value, bool) {
+	s, ok := scopes[c]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	path, ok := s.flagPath(name)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	for _, opts := range s.options {
+		opts := target(opts)
+		if reflect.TypeOf(opts).Kind() != reflect.Ptr {
+			continue
+		}
+		if field, ok := fieldByPath(reflect.ValueOf(opts), path); ok {
+			return field, true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// Resolve returns a newly allocated value of the input options type, fully resolved as Unmarshal would do.
+//
+// It never unmarshals into the options passed to Define, so that they can serve as a prototype across reloads:
+// compare the previous value with the new one via Diff.
+// The prototype still gets the command line values, since its fields back the flags.
+// The options type must be a pointer to a struct, attached to the command via Define, and not dynamic options.
+func Resolve[T options.Options](c *cobra.Command) (T, error) {
+	var res T
+	s, ok := scopes[c]
+	if !ok {
+		return res, fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return res, fmt.Errorf("couldn't resolve %s: not a pointer to a struct", typ)
+	}
+	if typ.Implements(reflect.TypeOf((*options.DynamicOptions)(nil)).Elem()) {
+		return res, fmt.Errorf("couldn't resolve %s: dynamic options have no zero value to start from", typ)
+	}
+	defined := false
+	for _, o := range s.options {
+		if reflect.TypeOf(o) == typ {
+			defined = true
+
+			break
+		}
+	}
+	if !defined {
+		return res, fmt.Errorf("couldn't resolve %s: not defined for %s", typ, c.Name())
+	}
+	res = reflect.New(typ.Elem()).Interface().(T)
+	if err := Unmarshal(c, res); err != nil {
+		var zero T
+
+		return zero, err
+	}
+
+	return res, nil
+}
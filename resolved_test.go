@@ -0,0 +1,29 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestResolve() {
+	suite.useConfigFile("config.yaml", "server:\n  port: 8\n")
+	c := &cobra.Command{Use: "resolve"}
+	proto := &portOptions{}
+	suite.Require().Nil(Define(c, proto))
+
+	first, err := Resolve[*portOptions](c)
+	suite.Require().Nil(err)
+	suite.Equal(8, first.Server.Port)
+	suite.Equal(0, proto.Server.Port)
+
+	suite.T().Setenv("SERVER_PORT", "9")
+	second, err := Resolve[*portOptions](c)
+	suite.Require().Nil(err)
+	suite.NotSame(first, second)
+	suite.Equal(8, first.Server.Port)
+	suite.Equal([]FieldChange{{Path: "server.port", Old: 8, New: 9}}, Diff(first, second))
+
+	_, err = Resolve[*validatedOptions](c)
+	suite.EqualError(err, "couldn't resolve *autoflags.validatedOptions: not defined for resolve")
+	_, err = Resolve[*portOptions](&cobra.Command{Use: "none"})
+	suite.EqualError(err, "couldn't find a scope for none")
+}
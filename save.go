@@ -0,0 +1,238 @@
+package autoflags
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// SaveOption customizes the behavior of SaveConfig.
+type SaveOption func(*saveConfig)
+
+type saveConfig struct {
+	secrets     bool
+	onlyChanged bool
+}
+
+// WithSecrets makes SaveConfig write the values of the flags marked with the flagsecret tag too.
+func WithSecrets() SaveOption {
+	return func(cfg *saveConfig) {
+		cfg.secrets = true
+	}
+}
+
+// WithOnlyChanged makes SaveConfig only write the values not coming from the defaults.
+func WithOnlyChanged() SaveOption {
+	return func(cfg *saveConfig) {
+		cfg.onlyChanged = true
+	}
+}
+
+// SaveConfig writes the current values of the input options to the config file at the given path.
+//
+// The format follows the file extension (eg., yaml, json, toml).
+// Secret values are omitted unless WithSecrets is given, which also restricts the file to its owner.
+// Encrypted files are not supported.
+// Existing YAML files keep their comments, and the keys the options do not write.
+// Other existing files are overwritten.
+func SaveConfig(c *cobra.Command, opts interface{}, path string, saveOpts ...SaveOption) error {
+	cfg := &saveConfig{}
+	for _, opt := range saveOpts {
+		opt(cfg)
+	}
+	s, ok := scopes[c]
+	if !ok {
+		return fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	provenance, _ := Provenance(c)
+
+	out := viper.New()
+	root := reflect.Indirect(reflect.ValueOf(target(opts)))
+	for fieldPath, name := range s.paths {
+		f := c.Flags().Lookup(name)
+		if f == nil || (isSecretFlag(f) && !cfg.secrets) {
+			continue
+		}
+		if cfg.onlyChanged && provenance[name] == SourceDefault {
+			continue
+		}
+		field, ok := fieldByPath(root, fieldPath)
+		if !ok {
+			continue
+		}
+		out.Set(name, flagValue(f, field))
+	}
+
+	perm := os.FileMode(0o644)
+	if cfg.secrets {
+		perm = 0o600
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	content, err := os.ReadFile(path)
+	if err == nil {
+		// Writing plain text values over an encrypted file would leave it neither encrypted nor valid
+		if _, encrypted, err := decryptConfig(content); err != nil || encrypted {
+			return fmt.Errorf("couldn't save the config to %s: encrypted config files are not supported", path)
+		}
+	}
+	if err == nil && (ext == ".yaml" || ext == ".yml") {
+		err = saveYAML(path, content, out.AllSettings(), perm)
+	} else {
+		out.SetConfigPermissions(perm)
+		err = out.WriteConfigAs(path)
+	}
+	if err == nil && cfg.secrets {
+		// The permissions of existing files do not change on write
+		err = os.Chmod(path, perm)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't save the config to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// saveYAML writes the input settings over the input content of an existing YAML file,
+// round-tripping it through its nodes so that its comments and the keys the settings do not have stay.
+func saveYAML(path string, content []byte, settings map[string]interface{}, perm os.FileMode) error {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(content, doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("the existing content is not a mapping")
+	}
+	if err := mergeYAML(doc.Content[0], settings); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), perm)
+}
+
+// mergeYAML sets the input settings into the input mapping node, keeping the comments of the values it replaces.
+//
+// Keys match case insensitively, as viper reads them.
+func mergeYAML(node *yaml.Node, settings map[string]interface{}) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		val := settings[key]
+		var existing *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.EqualFold(node.Content[i].Value, key) {
+				existing = node.Content[i+1]
+
+				break
+			}
+		}
+		if nested, ok := val.(map[string]interface{}); ok && existing != nil && existing.Kind == yaml.MappingNode {
+			if err := mergeYAML(existing, nested); err != nil {
+				return err
+			}
+
+			continue
+		}
+		replacement := &yaml.Node{}
+		if err := replacement.Encode(val); err != nil {
+			return err
+		}
+		if existing == nil {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, replacement)
+
+			continue
+		}
+		replacement.HeadComment = existing.HeadComment
+		replacement.LineComment = existing.LineComment
+		replacement.FootComment = existing.FootComment
+		*existing = *replacement
+	}
+
+	return nil
+}
+
+// fieldByPath returns the field of the input struct value at the given lowercase struct path.
+func fieldByPath(val reflect.Value, path string) (reflect.Value, bool) {
+	for _, part := range strings.Split(path, ".") {
+		val = reflect.Indirect(val)
+		if val.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		found := false
+		for i := 0; i < val.NumField(); i++ {
+			if strings.ToLower(val.Type().Field(i).Name) == part {
+				val = val.Field(i)
+				found = true
+
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+
+	return val, val.CanInterface()
+}
+
+// flagValue converts the input field value of the given flag to the form users write it in config files.
+//
+// Durations honor the unit of their flag, if any, and times its layout and time zone.
+func flagValue(f *pflag.Flag, val reflect.Value) interface{} {
+	if unit, ok := durationUnit(f); ok && val.Type() == durationType {
+		return formatDuration(time.Duration(val.Int()), unit)
+	}
+	if layout, ok := timeLayout(f); ok && val.Type() == timeType {
+		return formatTime(val.Interface().(time.Time), layout, timeZone(f))
+	}
+
+	return configValue(val)
+}
+
+// configValue converts the input field value to the form users write it in config files.
+func configValue(val reflect.Value) interface{} {
+	v := val.Interface()
+	switch x := v.(type) {
+	case time.Duration:
+		return x.String()
+	case *net.IPNet:
+		if x == nil {
+			return ""
+		}
+
+		return x.String()
+	case encoding.TextMarshaler:
+		if text, err := x.MarshalText(); err == nil {
+			return string(text)
+		}
+	case pflag.Value:
+		return x.String()
+	}
+
+	return v
+}
@@ -0,0 +1,76 @@
+package autoflags
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestSaveConfig() {
+	c := &cobra.Command{Use: "save"}
+	opts := &saveOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--token", "s3cr3t", "--server.timeout", "1m"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	dir := suite.T().TempDir()
+	file := filepath.Join(dir, "all.yaml")
+	suite.Require().Nil(SaveConfig(c, opts, file))
+	content, err := os.ReadFile(file)
+	suite.Require().Nil(err)
+	suite.Equal("level: info\nserver:\n    timeout: 1m0s\n", string(content))
+
+	// Existing YAML files keep their comments and the other keys
+	suite.Require().Nil(os.WriteFile(file, []byte("# the app\nlevel: debug # verbose\nserver:\n    # the timeout\n    timeout: 5s\nother: 1\n"), 0o600))
+	suite.Require().Nil(SaveConfig(c, opts, file))
+	content, err = os.ReadFile(file)
+	suite.Require().Nil(err)
+	suite.Equal("# the app\nlevel: info # verbose\nserver:\n    # the timeout\n    timeout: 1m0s\nother: 1\n", string(content))
+
+	file = filepath.Join(dir, "changed.json")
+	suite.Require().Nil(SaveConfig(c, opts, file, WithOnlyChanged(), WithSecrets()))
+	content, err = os.ReadFile(file)
+	suite.Require().Nil(err)
+	suite.JSONEq(`{"server": {"timeout": "1m0s"}, "token": "s3cr3t"}`, string(content))
+	info, err := os.Stat(file)
+	suite.Require().Nil(err)
+	suite.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	// Existing files get restricted too when they get the secrets
+	file = filepath.Join(dir, "existing.yaml")
+	suite.Require().Nil(os.WriteFile(file, []byte("level: debug\n"), 0o644))
+	suite.Require().Nil(SaveConfig(c, opts, file, WithSecrets()))
+	info, err = os.Stat(file)
+	suite.Require().Nil(err)
+	suite.Equal(os.FileMode(0o600), info.Mode().Perm())
+}
+
+func (suite *UnmarshalSuite) TestSaveConfigEncrypted() {
+	c := &cobra.Command{Use: "save"}
+	opts := &saveOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--token", "plain-secret"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	file := filepath.Join(suite.T().TempDir(), "config.yaml")
+	encrypted := "token: ENC[AES256_GCM,data:abc,type:str]\nsops:\n    mac: ENC[AES256_GCM,data:def,type:str]\n"
+	suite.Require().Nil(os.WriteFile(file, []byte(encrypted), 0o600))
+	suite.EqualError(SaveConfig(c, opts, file, WithSecrets()), "couldn't save the config to "+file+": encrypted config files are not supported")
+	content, err := os.ReadFile(file)
+	suite.Require().Nil(err)
+	suite.Equal(encrypted, string(content))
+}
+
+type saveServerOptions struct {
+	Timeout time.Duration
+}
+
+type saveOptions struct {
+	fixture
+
+	Level  string `default:"info"`
+	Token  string `flagsecret:"true"`
+	Server saveServerOptions
+}
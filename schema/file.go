@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Definition describes a flag in a schema file.
+type Definition struct {
+	Name string `yaml:"name" json:"name"`
+	// Type is one of string, bool, int, count, uint, int64, duration, and strings
+	Type        string `yaml:"type" json:"type"`
+	Short       string `yaml:"short,omitempty" json:"short,omitempty"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Group       string `yaml:"group,omitempty" json:"group,omitempty"`
+	Env         bool   `yaml:"env,omitempty" json:"env,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	Secret      bool   `yaml:"secret,omitempty" json:"secret,omitempty"`
+	// Tags sets any other tag (eg., flagsep)
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// File is the content of a schema file.
+//
+// For example:
+//
+//	flags:
+//	  - name: host
+//	    type: string
+//	    default: localhost
+//	    env: true
+//	    group: Server
+type File struct {
+	Flags []Definition `yaml:"flags" json:"flags"`
+}
+
+// types maps the types of the schema files to the builder methods.
+var types = map[string]func(*Schema, string) *Field{
+	"string":   (*Schema).String,
+	"bool":     (*Schema).Bool,
+	"int":      (*Schema).Int,
+	"count":    (*Schema).Count,
+	"uint":     (*Schema).Uint,
+	"int64":    (*Schema).Int64,
+	"duration": (*Schema).Duration,
+	"strings":  (*Schema).Strings,
+}
+
+// Parse creates a schema from the YAML or JSON content of a schema file.
+func Parse(data []byte) (*Schema, error) {
+	file := &File{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil {
+		return nil, fmt.Errorf("couldn't parse the schema: %w", err)
+	}
+
+	return FromDefinitions(file.Flags)
+}
+
+// FromDefinitions creates a schema from the input flag definitions.
+func FromDefinitions(defs []Definition) (*Schema, error) {
+	res := New()
+	for _, def := range defs {
+		add, ok := types[def.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q for flag --%s", def.Type, def.Name)
+		}
+		f := add(res, def.Name)
+		if def.Short != "" {
+			f.Short(def.Short)
+		}
+		if def.Default != "" {
+			f.Default(def.Default)
+		}
+		if def.Description != "" {
+			f.Descr(def.Description)
+		}
+		if def.Group != "" {
+			f.Group(def.Group)
+		}
+		if def.Env {
+			f.Env()
+		}
+		if def.Required {
+			f.Required()
+		}
+		if def.Secret {
+			f.Secret()
+		}
+		keys := make([]string, 0, len(def.Tags))
+		for key := range def.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			f.Tag(key, def.Tags[key])
+		}
+	}
+
+	return res, nil
+}
+
+// Load creates a schema from the input YAML or JSON schema file.
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the schema: %w", err)
+	}
+
+	return Parse(data)
+}
@@ -0,0 +1,277 @@
+// Package schema builds options at runtime, as an alternative to the struct tags.
+//
+// The builder produces a struct type carrying the same tags a hand-written options struct would,
+// so that Define, Unmarshal, the manifests, and the usage messages treat both the same way.
+//
+//	opts, err := schema.New().
+//		String("host").Default("localhost").Env().Group("Server").
+//		Int("port").Default("8080").Env().Group("Server").
+//		Build()
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+)
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-_]*$`)
+
+// Schema is the list of the flags to build the options with.
+type Schema struct {
+	fields []*Field
+}
+
+// New creates an empty schema.
+func New() *Schema {
+	return &Schema{}
+}
+
+// Field is a flag of the schema.
+//
+// Its methods set the tags of the field and return it, so that calls chain.
+// Since it embeds the schema, chains can move on to the next field.
+type Field struct {
+	*Schema
+	name string
+	typ  reflect.Type
+	tags []tag
+}
+
+type tag struct {
+	key   string
+	value string
+}
+
+func (s *Schema) add(name string, typ reflect.Type) *Field {
+	f := &Field{Schema: s, name: name, typ: typ}
+	s.fields = append(s.fields, f)
+
+	return f
+}
+
+// String adds a string flag.
+func (s *Schema) String(name string) *Field {
+	return s.add(name, reflect.TypeOf(""))
+}
+
+// Bool adds a bool flag.
+func (s *Schema) Bool(name string) *Field {
+	return s.add(name, reflect.TypeOf(false))
+}
+
+// Int adds an int flag.
+func (s *Schema) Int(name string) *Field {
+	return s.add(name, reflect.TypeOf(0))
+}
+
+// Count adds an int flag incremented by each occurrence (eg., -vvv).
+func (s *Schema) Count(name string) *Field {
+	return s.add(name, reflect.TypeOf(0)).Tag("type", "count")
+}
+
+// Uint adds an uint flag.
+func (s *Schema) Uint(name string) *Field {
+	return s.add(name, reflect.TypeOf(uint(0)))
+}
+
+// Int64 adds an int64 flag.
+func (s *Schema) Int64(name string) *Field {
+	return s.add(name, reflect.TypeOf(int64(0)))
+}
+
+// Duration adds a time.Duration flag.
+func (s *Schema) Duration(name string) *Field {
+	return s.add(name, reflect.TypeOf(time.Duration(0)))
+}
+
+// Strings adds a string slice flag.
+func (s *Schema) Strings(name string) *Field {
+	return s.add(name, reflect.TypeOf([]string{}))
+}
+
+// Tag sets any tag on the field (eg., Tag("flagsep", ";")), for the ones without a dedicated method.
+func (f *Field) Tag(key, value string) *Field {
+	for i, t := range f.tags {
+		if t.key == key {
+			f.tags[i].value = value
+
+			return f
+		}
+	}
+	f.tags = append(f.tags, tag{key: key, value: value})
+
+	return f
+}
+
+// Short sets the shorthand of the flag (ie., `flagshort`).
+func (f *Field) Short(short string) *Field {
+	return f.Tag("flagshort", short)
+}
+
+// Default sets the default value of the flag, as it would be written on the command line (ie., `default`).
+func (f *Field) Default(value string) *Field {
+	return f.Tag("default", value)
+}
+
+// Descr sets the description of the flag (ie., `flagdescr`).
+func (f *Field) Descr(descr string) *Field {
+	return f.Tag("flagdescr", descr)
+}
+
+// Env binds the flag to its environment variable (ie., `flagenv`).
+func (f *Field) Env() *Field {
+	return f.Tag("flagenv", "true")
+}
+
+// Group sets the group of the flag in the usage message (ie., `flaggroup`).
+func (f *Field) Group(group string) *Field {
+	return f.Tag("flaggroup", group)
+}
+
+// Required marks the flag as mandatory (ie., `flagrequired`).
+func (f *Field) Required() *Field {
+	return f.Tag("flagrequired", "true")
+}
+
+// Secret marks the value of the flag as sensitive (ie., `flagsecret`).
+func (f *Field) Secret() *Field {
+	return f.Tag("flagsecret", "true")
+}
+
+// Build creates the options described by the schema.
+//
+// It errors when flag names are invalid or when they clash.
+func (s *Schema) Build() (*Options, error) {
+	fields := make([]reflect.StructField, 0, len(s.fields))
+	names := map[string]string{}
+	goNames := map[string]string{}
+	for _, f := range s.fields {
+		if !nameRe.MatchString(f.name) {
+			return nil, fmt.Errorf("invalid flag name %q", f.name)
+		}
+		if _, ok := names[f.name]; ok {
+			return nil, fmt.Errorf("flag --%s is defined more than once", f.name)
+		}
+		goName := fieldName(f.name)
+		if other, ok := goNames[strings.ToLower(goName)]; ok {
+			return nil, fmt.Errorf("flag --%s clashes with flag --%s", f.name, other)
+		}
+		names[f.name] = goName
+		goNames[strings.ToLower(goName)] = f.name
+
+		tags := []string{fmt.Sprintf("flag:%s", strconv.Quote(f.name))}
+		for _, t := range f.tags {
+			tags = append(tags, fmt.Sprintf("%s:%s", t.key, strconv.Quote(t.value)))
+		}
+		fields = append(fields, reflect.StructField{
+			Name: goName,
+			Type: f.typ,
+			Tag:  reflect.StructTag(strings.Join(tags, " ")),
+		})
+	}
+
+	return &Options{
+		ptr:   reflect.New(reflect.StructOf(fields)),
+		names: names,
+	}, nil
+}
+
+// fieldName turns the input flag name into an exported Go identifier (eg., "log-level" into "LogLevel").
+func fieldName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '-' || r == '_' {
+			upper = true
+
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Options are options built from a schema.
+//
+// Pass them to Define and Unmarshal as any other options, then read their values by flag name.
+type Options struct {
+	ptr   reflect.Value
+	names map[string]string
+}
+
+var _ options.DynamicOptions = (*Options)(nil)
+
+func (o *Options) Attach(c *cobra.Command) {}
+
+// Target returns the pointer to the struct holding the values.
+func (o *Options) Target() interface{} {
+	return o.ptr.Interface()
+}
+
+// Get returns the value of the input flag, or nil when the schema does not have it.
+func (o *Options) Get(name string) interface{} {
+	goName, ok := o.names[name]
+	if !ok {
+		return nil
+	}
+
+	return o.ptr.Elem().FieldByName(goName).Interface()
+}
+
+// Map returns the values of all the flags, keyed by flag name.
+func (o *Options) Map() map[string]interface{} {
+	res := make(map[string]interface{}, len(o.names))
+	for name := range o.names {
+		res[name] = o.Get(name)
+	}
+
+	return res
+}
+
+// GetString returns the value of the input string flag.
+func (o *Options) GetString(name string) string {
+	v, _ := o.Get(name).(string)
+
+	return v
+}
+
+// GetBool returns the value of the input bool flag.
+func (o *Options) GetBool(name string) bool {
+	v, _ := o.Get(name).(bool)
+
+	return v
+}
+
+// GetInt returns the value of the input int flag.
+func (o *Options) GetInt(name string) int {
+	v, _ := o.Get(name).(int)
+
+	return v
+}
+
+// GetDuration returns the value of the input time.Duration flag.
+func (o *Options) GetDuration(name string) time.Duration {
+	v, _ := o.Get(name).(time.Duration)
+
+	return v
+}
+
+// GetStrings returns the value of the input string slice flag.
+func (o *Options) GetStrings(name string) []string {
+	v, _ := o.Get(name).([]string)
+
+	return v
+}
@@ -0,0 +1,126 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leodido/autoflags"
+	"github.com/leodido/autoflags/schema"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serverOptions struct {
+	Host     string        `flag:"host" default:"localhost" flagenv:"true" flaggroup:"Server"`
+	LogLevel string        `flag:"log-level" flagshort:"l" flagdescr:"the logging level"`
+	Timeout  time.Duration `flag:"timeout" default:"5s"`
+	Verbose  int           `flag:"verbose" type:"count" flagshort:"v"`
+	Tags     []string      `flag:"tags" flagsep:";"`
+}
+
+func (o *serverOptions) Attach(c *cobra.Command) {}
+
+func builtOptions(t *testing.T) *schema.Options {
+	opts, err := schema.New().
+		String("host").Default("localhost").Env().Group("Server").
+		String("log-level").Short("l").Descr("the logging level").
+		Duration("timeout").Default("5s").
+		Count("verbose").Short("v").
+		Strings("tags").Tag("flagsep", ";").
+		Build()
+	require.Nil(t, err)
+
+	return opts
+}
+
+func TestSchemaMatchesTags(t *testing.T) {
+	tagged := &cobra.Command{Use: "serve"}
+	require.Nil(t, autoflags.Define(tagged, &serverOptions{}))
+
+	built := &cobra.Command{Use: "serve"}
+	require.Nil(t, autoflags.Define(built, builtOptions(t)))
+
+	assert.Equal(t, tagged.UsageTemplate(), built.UsageTemplate())
+	assert.Equal(t, autoflags.NewManifest(tagged), autoflags.NewManifest(built))
+}
+
+func TestSchemaUnmarshal(t *testing.T) {
+	t.Setenv("HOST", "example.com")
+
+	c := &cobra.Command{Use: "serve"}
+	opts := builtOptions(t)
+	require.Nil(t, autoflags.Define(c, opts))
+	require.Nil(t, c.Flags().Parse([]string{"-vv", "--tags", "a,b;c", "-l", "debug"}))
+	require.Nil(t, autoflags.Unmarshal(c, opts))
+
+	assert.Equal(t, "example.com", opts.GetString("host"))
+	assert.Equal(t, "debug", opts.GetString("log-level"))
+	assert.Equal(t, 5*time.Second, opts.GetDuration("timeout"))
+	assert.Equal(t, 2, opts.GetInt("verbose"))
+	assert.Equal(t, []string{"a,b", "c"}, opts.GetStrings("tags"))
+	assert.Nil(t, opts.Get("missing"))
+}
+
+func TestSchemaInvalid(t *testing.T) {
+	_, err := schema.New().String("host").Int("host").Build()
+	assert.EqualError(t, err, "flag --host is defined more than once")
+
+	_, err = schema.New().String("log-level").String("log_level").Build()
+	assert.EqualError(t, err, "flag --log_level clashes with flag --log-level")
+
+	_, err = schema.New().String("server.host").Build()
+	assert.EqualError(t, err, `invalid flag name "server.host"`)
+}
+
+func TestSchemaFile(t *testing.T) {
+	s, err := schema.Parse([]byte(`
+flags:
+  - name: host
+    type: string
+    default: localhost
+    env: true
+    group: Server
+  - name: log-level
+    type: string
+    short: l
+    description: the logging level
+  - name: timeout
+    type: duration
+    default: 5s
+  - name: verbose
+    type: count
+    short: v
+  - name: tags
+    type: strings
+    tags:
+      flagsep: ";"
+`))
+	require.Nil(t, err)
+	fromFile, err := s.Build()
+	require.Nil(t, err)
+
+	tagged := &cobra.Command{Use: "serve"}
+	require.Nil(t, autoflags.Define(tagged, &serverOptions{}))
+	built := &cobra.Command{Use: "serve"}
+	require.Nil(t, autoflags.Define(built, fromFile))
+	assert.Equal(t, tagged.UsageTemplate(), built.UsageTemplate())
+	assert.Equal(t, autoflags.NewManifest(tagged), autoflags.NewManifest(built))
+
+	require.Nil(t, autoflags.Unmarshal(built, fromFile))
+	assert.Equal(t, map[string]interface{}{
+		"host":      "localhost",
+		"log-level": "",
+		"timeout":   5 * time.Second,
+		"verbose":   0,
+		"tags":      []string{},
+	}, fromFile.Map())
+}
+
+func TestSchemaFileInvalid(t *testing.T) {
+	_, err := schema.Parse([]byte(`{"flags": [{"name": "port", "type": "float"}]}`))
+	assert.EqualError(t, err, `unknown type "float" for flag --port`)
+
+	_, err = schema.Parse([]byte(`{"flags": [{"name": "port", "typo": "int"}]}`))
+	assert.ErrorContains(t, err, "couldn't parse the schema")
+}
@@ -0,0 +1,110 @@
+package autoflags
+
+import (
+	"reflect"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// scope holds the state autoflags tracks for a single command.
+type scope struct {
+	viper   *viper.Viper
+	skipped []SkippedField
+	// paths maps the lowercase struct paths to the flag names
+	paths map[string]string
+	// aliases maps the struct paths config files can use in place of the flag names to such names
+	aliases map[string]string
+	// withoutAliases lists the struct paths the Define call in progress does not alias (see WithoutAliases)
+	withoutAliases []string
+	// types maps the flag names to the types of their fields
+	types map[string]reflect.Type
+	// defaults maps the flag names to the defaults of their default tags, or of their virtual flags
+	defaults map[string]string
+	// origins maps flag names to the type of the options struct defining them
+	origins map[string]string
+	// order is the custom precedence order of the sources, from the highest to the lowest
+	order []Source
+	// values maps flag names to the values set in memory via WithValues
+	values map[string]interface{}
+	// refreshes maps the secret flags to the functions refreshing their values (see RegisterRefresh)
+	refreshes map[string]*refresher
+	// fieldOrders maps flag names to their own precedence order, when set via the flagprecedence tag
+	fieldOrders map[string][]Source
+	// strictDurations makes config files unable to set durations without units
+	strictDurations bool
+	// gates maps the flag groups to the names of the bool flags enabling them
+	gates map[string]string
+	// partial makes unmarshalling fall back to the defaults of the values failing to decode
+	partial bool
+	// saturate makes unmarshalling clamp the numbers overflowing their fields
+	saturate bool
+	// warnings collects the deprecations and the decoding failures tolerated while unmarshalling
+	warnings []string
+	// counts maps the count flags to the increments they got from the command line
+	counts map[string]*countState
+	// bases maps the count flags to the sources their command line increments add to
+	bases map[string]Source
+	// slices maps the slice flags with the append merge policy to their command line entries
+	slices map[string]*sliceState
+	// maps maps the map flags with the merge policy to their command line entries
+	maps map[string]*mapState
+	// env is the snapshot of the environment the last unmarshalling resolved the options with
+	env EnvSnapshot
+	// synopsis lists the required flags WithSynopsis put in the usage line of the command
+	synopsis string
+	// usageReported tells whether the usage hook got the flags of the command already
+	usageReported bool
+	// args lists the fields collecting the positional arguments
+	args []argsField
+	// options holds the options attached to the command, in definition order
+	options []options.Options
+	// hooks maps the names of the decode hooks only the command knows (eg., the ones of its flagtuple tags) to such hooks
+	hooks map[string]mapstructure.DecodeHookFunc
+}
+
+var (
+	scopes map[*cobra.Command]*scope = map[*cobra.Command]*scope{}
+)
+
+// getScope returns the scope of the input command, creating it when missing.
+func getScope(c *cobra.Command) *scope {
+	s, ok := scopes[c]
+	if !ok {
+		s = &scope{
+			viper:       viper.New(),
+			paths:       map[string]string{},
+			aliases:     map[string]string{},
+			types:       map[string]reflect.Type{},
+			defaults:    map[string]string{},
+			origins:     map[string]string{},
+			values:      map[string]interface{}{},
+			refreshes:   map[string]*refresher{},
+			fieldOrders: map[string][]Source{},
+			gates:       map[string]string{},
+			counts:      map[string]*countState{},
+			bases:       map[string]Source{},
+			slices:      map[string]*sliceState{},
+			maps:        map[string]*mapState{},
+			hooks:       map[string]mapstructure.DecodeHookFunc{},
+		}
+		scopes[c] = s
+	}
+
+	return s
+}
+
+func (s *scope) warn(msg string) {
+	for _, w := range s.warnings {
+		if w == msg {
+			return
+		}
+	}
+	s.warnings = append(s.warnings, msg)
+}
+
+func (s *scope) skip(path string, typename string, reason SkipReason) {
+	s.skipped = append(s.skipped, SkippedField{Path: path, Type: typename, Reason: reason})
+}
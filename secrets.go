@@ -0,0 +1,147 @@
+package autoflags
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// SecretsProvider resolves the references to secrets into their values.
+//
+// References are URIs whose scheme selects the provider (eg., "vault://kv/app#token", "ssm:///app/token").
+// Providers receive them whole, scheme included.
+// Implementations must be safe for concurrent use.
+//
+// No provider is built in: the clients of backends like Vault or AWS SSM are out of the scope of autoflags,
+// so applications wrap the ones they already use (see RegisterSecretsProvider).
+type SecretsProvider interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretsProviderFunc is an adapter to use ordinary functions as SecretsProvider.
+type SecretsProviderFunc func(ref string) (string, error)
+
+func (f SecretsProviderFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var secretsProviders = map[string]SecretsProvider{}
+
+// RegisterSecretsProvider makes Unmarshal resolve the values of the secret flags having the given scheme (eg., "vault") via the input provider.
+//
+// Secret flags are the ones tagged flagsecret:"true". Their values can then be references, from any source, rather than the secrets themselves.
+// Wrap the provider with NewCachedSecretsProvider to avoid hitting the backend at every resolution.
+func RegisterSecretsProvider(scheme string, p SecretsProvider) {
+	secretsProviders[strings.ToLower(scheme)] = p
+}
+
+// secretsProviderOf returns the provider registered for the scheme of the input value, if it is a reference.
+func secretsProviderOf(val string) (SecretsProvider, bool) {
+	scheme, _, ok := strings.Cut(val, "://")
+	if !ok {
+		return nil, false
+	}
+	p, ok := secretsProviders[strings.ToLower(scheme)]
+
+	return p, ok
+}
+
+// resolveSecrets replaces the references in the settings of the secret flags with the secrets they point to.
+func resolveSecrets(c *cobra.Command, settings map[string]interface{}) error {
+	if len(secretsProviders) == 0 {
+		return nil
+	}
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || !isSecretFlag(f) {
+			return
+		}
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			ref, ok := m[key].(string)
+			if !ok || err != nil {
+				return
+			}
+			p, ok := secretsProviderOf(ref)
+			if !ok {
+				return
+			}
+			secret, resolveErr := p.Resolve(ref)
+			if resolveErr != nil {
+				err = fmt.Errorf("couldn't resolve the secret of --%s: %w", f.Name, resolveErr)
+
+				return
+			}
+			m[key] = secret
+		})
+	})
+
+	return err
+}
+
+// cachedSecret is a secret along with the time it expires at.
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// secretFlight is a resolution in progress, that the concurrent resolutions of the same reference wait for.
+type secretFlight struct {
+	done  chan struct{}
+	value string
+	err   error
+}
+
+// cachedSecretsProvider is a SecretsProvider keeping the secrets it resolves for a while.
+//
+// Its mutex only guards the cache and the flights, so that slow backends do not hold up the resolutions of other references.
+type cachedSecretsProvider struct {
+	provider SecretsProvider
+	ttl      time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	secrets  map[string]cachedSecret
+	flights  map[string]*secretFlight
+}
+
+// NewCachedSecretsProvider wraps the input provider so that it resolves each reference at most once per TTL.
+//
+// Failures are not cached. The returned provider is safe for concurrent use,
+// with concurrent resolutions of the same reference hitting the wrapped provider once.
+func NewCachedSecretsProvider(p SecretsProvider, ttl time.Duration) SecretsProvider {
+	return &cachedSecretsProvider{provider: p, ttl: ttl, now: time.Now, secrets: map[string]cachedSecret{}, flights: map[string]*secretFlight{}}
+}
+
+func (p *cachedSecretsProvider) Resolve(ref string) (string, error) {
+	p.mu.Lock()
+	if s, ok := p.secrets[ref]; ok && p.now().Before(s.expires) {
+		p.mu.Unlock()
+
+		return s.value, nil
+	}
+	if flight, ok := p.flights[ref]; ok {
+		p.mu.Unlock()
+		<-flight.done
+
+		return flight.value, flight.err
+	}
+	flight := &secretFlight{done: make(chan struct{})}
+	p.flights[ref] = flight
+	p.mu.Unlock()
+
+	flight.value, flight.err = p.provider.Resolve(ref)
+	p.mu.Lock()
+	delete(p.flights, ref)
+	if flight.err != nil {
+		flight.value = ""
+	} else {
+		p.secrets[ref] = cachedSecret{value: flight.value, expires: p.now().Add(p.ttl)}
+	}
+	p.mu.Unlock()
+	close(flight.done)
+
+	return flight.value, flight.err
+}
@@ -0,0 +1,74 @@
+package autoflags
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/leodido/autoflags/autoflagstest"
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestSecretsProvider() {
+	fake := autoflagstest.NewSecretsProvider(map[string]string{"vault://kv/app#token": "s3cr3t"})
+	cached := NewCachedSecretsProvider(fake, time.Minute)
+	RegisterSecretsProvider("vault", cached)
+	suite.T().Cleanup(func() {
+		secretsProviders = map[string]SecretsProvider{}
+	})
+	suite.useConfigFile("config.yaml", "endpoint: vault://kv/app#token\npassword: plain\n")
+	suite.T().Setenv("TOKEN", "vault://kv/app#token")
+
+	c := &cobra.Command{Use: "secrets"}
+	opts := &secretRefOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("s3cr3t", opts.Token)
+	suite.Equal("plain", opts.Password)
+	suite.Equal("vault://kv/app#token", opts.Endpoint)
+
+	fake.Set("vault://kv/app#token", "rotated")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("s3cr3t", opts.Token)
+	suite.Equal(1, fake.Calls("vault://kv/app#token"))
+	cached.(*cachedSecretsProvider).now = func() time.Time { return time.Now().Add(time.Hour) }
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("rotated", opts.Token)
+	suite.Equal(2, fake.Calls("vault://kv/app#token"))
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--password", "vault://kv/app#missing"}))
+	suite.EqualError(Unmarshal(c, opts), "couldn't resolve the secret of --password: no secret vault://kv/app#missing")
+}
+
+func (suite *UnmarshalSuite) TestCachedSecretsProviderFlights() {
+	release := make(chan struct{})
+	var calls int32
+	slow := NewCachedSecretsProvider(SecretsProviderFunc(func(ref string) (string, error) {
+		if ref == "vault://slow" {
+			atomic.AddInt32(&calls, 1)
+			<-release
+		}
+
+		return ref + "!", nil
+	}), time.Minute)
+
+	// Concurrent resolutions of the same reference share the backend call
+	results := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			value, _ := slow.Resolve("vault://slow")
+			results <- value
+		}()
+	}
+	suite.Eventually(func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+
+	// The other references do not wait for it
+	value, err := slow.Resolve("vault://fast")
+	suite.Require().Nil(err)
+	suite.Equal("vault://fast!", value)
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		suite.Equal("vault://slow!", <-results)
+	}
+	suite.Equal(int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,185 @@
+package autoflags
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Settings reads the resolved values of the flags of a command, along with the sources they come from.
+//
+// It honors the sources and the precedence order Unmarshal does, without exposing the underlying viper instance.
+// Keys are either flag names or struct paths (eg., "server.port").
+// Unknown keys read as zero values coming from the defaults.
+type Settings struct {
+	c *cobra.Command
+	v *viper.Viper
+}
+
+// SettingsOf returns the settings of the input command.
+//
+// Call it once the flags are parsed and the config file is loaded, as for Unmarshal.
+func SettingsOf(c *cobra.Command) (*Settings, error) {
+	s, ok := scopes[c]
+	if !ok {
+		return nil, fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	if configSettings != nil {
+		if err := s.viper.MergeConfigMap(configSettings); err != nil {
+			return nil, err
+		}
+	}
+	// Read the settings resolved as of now, without changing the viper instance of the command
+	settings := s.viper.AllSettings()
+	resolve(c, settings)
+	mergeCounts(c, settings)
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, err
+	}
+
+	return &Settings{c: c, v: v}, nil
+}
+
+// flag returns the flag the input key names.
+func (st *Settings) flag(key string) (*pflag.Flag, bool) {
+	if f := st.c.Flags().Lookup(key); f != nil {
+		return f, true
+	}
+	if name, ok := getScope(st.c).paths[strings.ToLower(key)]; ok {
+		if f := st.c.Flags().Lookup(name); f != nil {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
+// Get returns the value of the input key, as viper holds it.
+func (st *Settings) Get(key string) interface{} {
+	f, ok := st.flag(key)
+	if !ok {
+		return nil
+	}
+
+	return st.v.Get(f.Name)
+}
+
+// GetString returns the value of the input key as a string.
+func (st *Settings) GetString(key string) string {
+	f, ok := st.flag(key)
+	if !ok {
+		return ""
+	}
+
+	return st.v.GetString(f.Name)
+}
+
+// GetBool returns the value of the input key as a bool.
+func (st *Settings) GetBool(key string) bool {
+	f, ok := st.flag(key)
+	if !ok {
+		return false
+	}
+
+	return st.v.GetBool(f.Name)
+}
+
+// GetInt returns the value of the input key as an int.
+func (st *Settings) GetInt(key string) int {
+	f, ok := st.flag(key)
+	if !ok {
+		return 0
+	}
+
+	return st.v.GetInt(f.Name)
+}
+
+// GetInt64 returns the value of the input key as an int64.
+func (st *Settings) GetInt64(key string) int64 {
+	f, ok := st.flag(key)
+	if !ok {
+		return 0
+	}
+
+	return st.v.GetInt64(f.Name)
+}
+
+// GetUint returns the value of the input key as an uint.
+func (st *Settings) GetUint(key string) uint {
+	f, ok := st.flag(key)
+	if !ok {
+		return 0
+	}
+
+	return st.v.GetUint(f.Name)
+}
+
+// GetFloat64 returns the value of the input key as a float64.
+func (st *Settings) GetFloat64(key string) float64 {
+	f, ok := st.flag(key)
+	if !ok {
+		return 0
+	}
+
+	return st.v.GetFloat64(f.Name)
+}
+
+// GetDuration returns the value of the input key as a time.Duration.
+func (st *Settings) GetDuration(key string) time.Duration {
+	f, ok := st.flag(key)
+	if !ok {
+		return 0
+	}
+
+	return st.v.GetDuration(f.Name)
+}
+
+// GetStringSlice returns the value of the input key as a slice of strings.
+func (st *Settings) GetStringSlice(key string) []string {
+	f, ok := st.flag(key)
+	if !ok {
+		return nil
+	}
+
+	return st.v.GetStringSlice(f.Name)
+}
+
+// GetStringMapString returns the value of the input key as a map of strings.
+func (st *Settings) GetStringMapString(key string) map[string]string {
+	f, ok := st.flag(key)
+	if !ok {
+		return nil
+	}
+
+	return st.v.GetStringMapString(f.Name)
+}
+
+// Source returns the source the value of the input key comes from.
+func (st *Settings) Source(key string) Source {
+	f, ok := st.flag(key)
+	if !ok {
+		return SourceDefault
+	}
+
+	return winner(lookupSources(st.c, f), getScope(st.c).precedenceOf(f.Name))
+}
+
+// Origin describes where the value of the input key comes from (eg., "env MYAPP_TIMEOUT", "config /etc/app/config.yaml:12:3").
+func (st *Settings) Origin(key string) string {
+	f, ok := st.flag(key)
+	if !ok {
+		return SourceDefault.String()
+	}
+
+	return origin(st.c, f)
+}
+
+// IsSet tells whether the value of the input key comes from a source other than the defaults.
+func (st *Settings) IsSet(key string) bool {
+	return st.Source(key) != SourceDefault
+}
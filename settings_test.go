@@ -0,0 +1,32 @@
+package autoflags
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestSettings() {
+	file := suite.useConfigFile("config.yaml", "timeout: 1m30s\nhosts: [a, b]\n")
+	suite.T().Setenv("TOKEN", "s3cr3t")
+	c := &cobra.Command{Use: "settings"}
+	suite.Require().Nil(Define(c, &dryRunOptions{}))
+	suite.Require().Nil(c.Flags().Parse([]string{"--name", "app"}))
+
+	_, err := SettingsOf(&cobra.Command{Use: "none"})
+	suite.EqualError(err, "couldn't find a scope for none")
+	settings, err := SettingsOf(c)
+	suite.Require().Nil(err)
+	suite.Equal("app", settings.GetString("name"))
+	suite.Equal(SourceFlag, settings.Source("name"))
+	suite.Equal("s3cr3t", settings.GetString("Token"))
+	suite.Equal("env TOKEN", settings.Origin("token"))
+	suite.Equal(90*time.Second, settings.GetDuration("timeout"))
+	suite.Equal([]string{"a", "b"}, settings.GetStringSlice("hosts"))
+	suite.Equal("config "+file+":2:1", settings.Origin("hosts"))
+	suite.Equal(8080, settings.GetInt("port"))
+	suite.False(settings.IsSet("port"))
+	suite.True(settings.IsSet("timeout"))
+	suite.Equal("", settings.GetString("unknown"))
+	suite.Equal(SourceDefault, settings.Source("unknown"))
+}
@@ -0,0 +1,196 @@
+package autoflags
+
+import (
+	"fmt"
+
+	"github.com/leodido/autoflags/setup"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// DefaultConfigFlag is the name of the flag Setup adds when the config options do not set one.
+const DefaultConfigFlag = "config"
+
+// Setup prepares the input root command with everything the input options select, in the order the pieces depend on.
+//
+// The config file loads first, before anything reads the options, so that the dry-run of the debugging flags sees its values.
+// The usage messages regenerate last, once every flag exists.
+// The flag names are checked against each other and against the existing flags before adding any.
+//
+// The config file loads, and the config file toggles the commands, from the persistent pre-run hook of the root command,
+// and from the ones of the subcommands having their own, that cobra runs instead unless cobra.EnableTraverseRunHooks is set.
+// Call it once every command has been defined, after setting their persistent pre-run hooks.
+// The root command can be a virtual one (see MarkVirtualRoot).
+func Setup(rootC *cobra.Command, opts setup.Options) error {
+	if !isRoot(rootC) {
+		return fmt.Errorf("Setup must be called on the root command")
+	}
+
+	// Cross-check everything before changing the command
+	claimed := map[string]string{}
+	claim := func(name, what string) error {
+		if other, ok := claimed[name]; ok {
+			return fmt.Errorf("the %s flag and the %s flag are both named --%s", other, what, name)
+		}
+		claimed[name] = what
+		if rootC.PersistentFlags().Lookup(name) != nil || rootC.Flags().Lookup(name) != nil {
+			return fmt.Errorf("flag --%s is already defined", name)
+		}
+
+		return nil
+	}
+	configFlag := ""
+	if cfg := opts.Config; cfg != nil {
+		if cfg.Project && cfg.App == "" {
+			return fmt.Errorf("the project config file needs the application name")
+		}
+		configFlag = cfg.FlagName
+		if configFlag == "" {
+			configFlag = DefaultConfigFlag
+		}
+		if err := claim(configFlag, "config"); err != nil {
+			return err
+		}
+		if cfg.Contexts {
+			if err := claim(DefaultContextFlag, "context"); err != nil {
+				return err
+			}
+		}
+	}
+	dryRunFlag := ""
+	if opts.Debug != nil {
+		dryRunFlag = opts.Debug.DryRunFlag
+		if dryRunFlag == "" {
+			dryRunFlag = DefaultDryRunFlag
+		}
+		if err := claim(dryRunFlag, "dry-run"); err != nil {
+			return err
+		}
+	}
+	if opts.Plumbing {
+		if err := claim(DefaultHelpAllFlag, "help-all"); err != nil {
+			return err
+		}
+	}
+	if opts.Version != "" {
+		if rootC.Version != "" && rootC.Version != opts.Version {
+			return fmt.Errorf("the root command already has version %s", rootC.Version)
+		}
+		if err := claim("version", "version"); err != nil {
+			return err
+		}
+	}
+
+	if cfg := opts.Config; cfg != nil {
+		rootC.PersistentFlags().String(configFlag, "", "the config file to use")
+		if cfg.Contexts {
+			if err := SetupContexts(rootC); err != nil {
+				return err
+			}
+		}
+		if cfg.App != "" {
+			viper.SetConfigName("config")
+			UseSearchPaths(cfg.App)
+		}
+		file := rootC.PersistentFlags().Lookup(configFlag)
+		wrapPersistentPreRun(rootC, func(c *cobra.Command, args []string) error {
+			if file.Changed {
+				viper.SetConfigFile(file.Value.String())
+			}
+			if err := readInConfig(); err != nil {
+				if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+					return fmt.Errorf("couldn't load the config file %s: %w", MaskPath(viper.ConfigFileUsed()), err)
+				}
+			}
+			if cfg.Project {
+				if _, err := UseProjectConfig(cfg.App); err != nil {
+					return err
+				}
+			}
+			if cfg.Commands {
+				return ToggleCommands(rootC)
+			}
+
+			return nil
+		})
+	}
+	if opts.Debug != nil {
+		if err := SetupDebug(rootC, DebugOptions{DryRunFlag: dryRunFlag, Hooks: opts.Debug.Hooks}); err != nil {
+			return err
+		}
+	}
+	if opts.Plumbing {
+		plumbing := []string{}
+		if cfg := opts.Config; cfg != nil {
+			plumbing = append(plumbing, configFlag)
+			if cfg.Contexts {
+				plumbing = append(plumbing, DefaultContextFlag)
+			}
+		}
+		if opts.Debug != nil {
+			plumbing = append(plumbing, dryRunFlag)
+		}
+		if err := MarkPlumbing(rootC, plumbing...); err != nil {
+			return err
+		}
+		if err := SetupPlumbing(rootC); err != nil {
+			return err
+		}
+	}
+	if opts.Version != "" {
+		rootC.Version = opts.Version
+		rootC.InitDefaultVersionFlag()
+	}
+	if opts.Usage {
+		for _, c := range walkTree(rootC) {
+			setUsage(c)
+		}
+	}
+
+	return nil
+}
+
+// wrapPersistentPreRun makes the input root command, and every command below it having its own persistent pre-run hook,
+// run the input hook after that one, so that the hook runs whichever persistent pre-run hook cobra picks.
+//
+// Virtual roots without their own hook run the one of their nearest ancestor having it, that cobra would run otherwise.
+// When cobra.EnableTraverseRunHooks is set, only the root command runs the input hook, since cobra runs the hooks of every parent.
+func wrapPersistentPreRun(rootC *cobra.Command, hook func(*cobra.Command, []string) error) {
+	for _, c := range walkTree(rootC) {
+		if c != rootC && c.PersistentPreRunE == nil && c.PersistentPreRun == nil {
+			continue
+		}
+		wrapOwnPersistentPreRun(rootC, c, hook)
+	}
+}
+
+// wrapOwnPersistentPreRun makes the input command run the input hook after its own persistent pre-run one, if any.
+func wrapOwnPersistentPreRun(rootC, c *cobra.Command, hook func(*cobra.Command, []string) error) {
+	ownPreRun := c.PersistentPreRunE
+	ownPreRunFn := c.PersistentPreRun
+	c.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		preRun, preRunFn := ownPreRun, ownPreRunFn
+		if c == rootC && preRun == nil && preRunFn == nil && !cobra.EnableTraverseRunHooks {
+			for p := rootC.Parent(); p != nil; p = p.Parent() {
+				if p.PersistentPreRunE != nil || p.PersistentPreRun != nil {
+					preRun, preRunFn = p.PersistentPreRunE, p.PersistentPreRun
+
+					break
+				}
+			}
+		}
+		if preRun != nil {
+			if err := preRun(cmd, args); err != nil {
+				return err
+			}
+		} else if preRunFn != nil {
+			preRunFn(cmd, args)
+		}
+		if c != rootC && cobra.EnableTraverseRunHooks {
+			return nil
+		}
+
+		return hook(cmd, args)
+	}
+	c.PersistentPreRun = nil
+}
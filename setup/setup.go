@@ -0,0 +1,43 @@
+// Package setup holds the options of autoflags.Setup, that prepares a root command in one call.
+package setup
+
+import "github.com/mitchellh/mapstructure"
+
+// Options selects what autoflags.Setup adds to a root command.
+//
+// The zero value adds nothing.
+type Options struct {
+	// Config makes the command load its config file before running
+	Config *Config
+	// Debug adds the debugging flags
+	Debug *Debug
+	// Usage regenerates the usage messages of the whole command tree, so that they include the flags added by Setup
+	Usage bool
+	// Version is the version of the application, printed by the --version flag
+	Version string
+	// Plumbing hides the config and debugging flags Setup adds into the Plumbing group, that --help-all shows (see autoflags.SetupPlumbing)
+	Plumbing bool
+}
+
+// Config customizes how the config file is found and loaded.
+type Config struct {
+	// App is the application name, naming the config search paths and the project config file (eg., .myapp.yaml)
+	App string
+	// FlagName is the name of the flag setting the path of the config file (defaults to "config")
+	FlagName string
+	// Project merges the project config file found walking up to the repository root over the other one
+	Project bool
+	// Contexts adds the --context flag selecting a named context of the config file
+	Contexts bool
+	// Commands hides or disables the subcommands the config file lists (see autoflags.ToggleCommands),
+	// once it loads, so that the help shown by --help still lists the hidden ones
+	Commands bool
+}
+
+// Debug customizes the debugging flags.
+type Debug struct {
+	// DryRunFlag is the name of the flag printing the resolved invocation instead of running the command
+	DryRunFlag string
+	// Hooks are the decode hooks the dry run unmarshals the options with, that should be the ones the commands use
+	Hooks []mapstructure.DecodeHookFunc
+}
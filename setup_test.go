@@ -0,0 +1,75 @@
+package autoflags
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leodido/autoflags/setup"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (suite *UnmarshalSuite) TestSetup() {
+	file := filepath.Join(suite.T().TempDir(), "app.yaml")
+	suite.Require().Nil(os.WriteFile(file, []byte("timeout: 1m30s\n"), 0o600))
+	suite.T().Cleanup(func() {
+		viper.Reset()
+		configSettings = nil
+		contextFlag = nil
+	})
+
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "serve", Run: func(c *cobra.Command, args []string) {}}
+	root.AddCommand(sub)
+	suite.Require().Nil(Define(sub, &dryRunOptions{}))
+	// A subcommand having its own persistent pre-run hook, that cobra runs instead of the one of the root
+	preRuns := 0
+	watchOpts := &dryRunOptions{}
+	watch := &cobra.Command{Use: "watch", PersistentPreRunE: func(c *cobra.Command, args []string) error {
+		preRuns++
+
+		return nil
+	}, RunE: func(c *cobra.Command, args []string) error {
+		return Unmarshal(c, watchOpts)
+	}}
+	root.AddCommand(watch)
+	suite.Require().Nil(Define(watch, watchOpts))
+	suite.EqualError(Setup(root, setup.Options{Config: &setup.Config{FlagName: "dry-run-config"}, Debug: &setup.Debug{}}), "the config flag and the dry-run flag are both named --dry-run-config")
+	suite.EqualError(Setup(root, setup.Options{Config: &setup.Config{Project: true}}), "the project config file needs the application name")
+	suite.EqualError(Setup(sub, setup.Options{}), "Setup must be called on the root command")
+	suite.Nil(root.PersistentFlags().Lookup("dry-run-config"), "failed setups change nothing")
+
+	suite.Require().Nil(Setup(root, setup.Options{
+		Config:  &setup.Config{Contexts: true},
+		Debug:   &setup.Debug{},
+		Usage:   true,
+		Version: "1.2.3",
+	}))
+	suite.EqualError(Setup(root, setup.Options{Debug: &setup.Debug{}}), "flag --dry-run-config is already defined")
+	suite.Contains(root.UsageString(), "--config string")
+	suite.Contains(root.UsageString(), "--version")
+
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetArgs([]string{"serve", "--config", file, "--dry-run-config"})
+	suite.Require().Nil(root.Execute())
+	suite.Equal("app serve --timeout=1m30s\n", out.String())
+
+	// The config file loads under the own hooks of the subcommands, with or without cobra traversing them
+	for _, traverse := range []bool{false, true} {
+		cobra.EnableTraverseRunHooks = traverse
+		configSettings, preRuns, watchOpts.Timeout = nil, 0, 0
+		root.SetArgs([]string{"watch", "--config", file})
+		suite.Require().Nil(root.Execute())
+		suite.Equal(1, preRuns)
+		suite.Equal(90*time.Second, watchOpts.Timeout)
+	}
+	cobra.EnableTraverseRunHooks = false
+
+	out.Reset()
+	root.SetArgs([]string{"--version"})
+	suite.Require().Nil(root.Execute())
+	suite.Equal("app version 1.2.3\n", out.String())
+}
@@ -0,0 +1,427 @@
+package autoflags
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagSeparatorAnnotation = "___flagsep"
+	FlagTrimAnnotation      = "___flagtrim"
+	FlagDropEmptyAnnotation = "___flagdropempty"
+	FlagUniqueAnnotation    = "___flagunique"
+	FlagMergeAnnotation     = "___flagmerge"
+)
+
+// Merge policies of the flagmerge tag.
+const (
+	// MergeReplace makes the command line values replace the ones from the other sources, which is the default
+	MergeReplace = "replace"
+	// MergeAppend makes the command line values of slices add to the ones from the environment or the config file
+	MergeAppend = "append"
+	// MergeKeys makes the command line entries of maps override the ones from the environment or the config file key by key
+	MergeKeys = "merge"
+)
+
+// stringSliceValue is a string slice flag splitting its values on a custom separator.
+//
+// Unlike the pflag string slices, it does not parse its values as CSV,
+// so they can contain commas (eg., `flagsep:";"` for SQL DSNs).
+// An empty separator disables the splitting (ie., `flagsplit:"false"`), so each value is an entry.
+type stringSliceValue struct {
+	ref     *[]string
+	sep     string
+	changed bool
+}
+
+var _ pflag.Value = (*stringSliceValue)(nil)
+
+func newStringSliceValue(ref *[]string, sep string) *stringSliceValue {
+	return &stringSliceValue{ref: ref, sep: sep}
+}
+
+func (v *stringSliceValue) Set(input string) error {
+	// The first occurrence on the command line replaces the defaults
+	if !v.changed {
+		*v.ref = []string{}
+		v.changed = true
+	}
+	if v.sep == "" {
+		*v.ref = append(*v.ref, input)
+	} else {
+		*v.ref = append(*v.ref, strings.Split(input, v.sep)...)
+	}
+
+	return nil
+}
+
+func (v *stringSliceValue) GetSlice() []string {
+	return append([]string{}, *v.ref...)
+}
+
+func (v *stringSliceValue) bind(field reflect.Value) pflag.Value {
+	return newStringSliceValue((*[]string)(unsafe.Pointer(field.UnsafeAddr())), v.sep)
+}
+
+// Type is the one of the pflag string slices, so that viper reads the entries of String as it reads theirs.
+func (v *stringSliceValue) Type() string {
+	return "stringSlice"
+}
+
+// String quotes the entries as CSV within brackets, as the pflag string slices do,
+// so that the entries holding commas, or the separator, read back as they are.
+func (v *stringSliceValue) String() string {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	_ = w.Write(*v.ref)
+	w.Flush()
+
+	return "[" + strings.TrimSuffix(buf.String(), "\n") + "]"
+}
+
+// uint64SliceValue is a []uint64 flag, which pflag lacks.
+type uint64SliceValue struct {
+	ref     *[]uint64
+	changed bool
+}
+
+var _ pflag.SliceValue = (*uint64SliceValue)(nil)
+
+func newUint64SliceValue(ref *[]uint64) *uint64SliceValue {
+	return &uint64SliceValue{ref: ref}
+}
+
+func (v *uint64SliceValue) Set(input string) error {
+	parsed, err := parseUint64s(strings.Split(input, ","))
+	if err != nil {
+		return err
+	}
+	// The first occurrence on the command line replaces the defaults
+	if !v.changed {
+		*v.ref = parsed
+		v.changed = true
+	} else {
+		*v.ref = append(*v.ref, parsed...)
+	}
+
+	return nil
+}
+
+func (v *uint64SliceValue) Append(input string) error {
+	parsed, err := parseUint64s([]string{input})
+	if err != nil {
+		return err
+	}
+	*v.ref = append(*v.ref, parsed...)
+
+	return nil
+}
+
+func (v *uint64SliceValue) Replace(inputs []string) error {
+	parsed, err := parseUint64s(inputs)
+	if err != nil {
+		return err
+	}
+	*v.ref = parsed
+
+	return nil
+}
+
+func (v *uint64SliceValue) GetSlice() []string {
+	res := make([]string, len(*v.ref))
+	for i, n := range *v.ref {
+		res[i] = strconv.FormatUint(n, 10)
+	}
+
+	return res
+}
+
+func (v *uint64SliceValue) bind(field reflect.Value) pflag.Value {
+	return newUint64SliceValue((*[]uint64)(unsafe.Pointer(field.UnsafeAddr())))
+}
+
+func (v *uint64SliceValue) Type() string {
+	return "uint64Slice"
+}
+
+func (v *uint64SliceValue) String() string {
+	return "[" + strings.Join(v.GetSlice(), ",") + "]"
+}
+
+func parseUint64s(inputs []string) ([]uint64, error) {
+	res := make([]uint64, 0, len(inputs))
+	for _, input := range inputs {
+		n, err := strconv.ParseUint(strings.TrimSpace(input), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+
+	return res, nil
+}
+
+// isDefinableSlice tells whether defineSlice defines a flag for the slices of the input element type.
+func isDefinableSlice(elem reflect.Type) bool {
+	switch elem {
+	case reflect.TypeOf(int(0)), reflect.TypeOf(int64(0)), reflect.TypeOf(uint(0)), reflect.TypeOf(uint64(0)), reflect.TypeOf(float64(0)), reflect.TypeOf(false):
+		return true
+	}
+
+	return false
+}
+
+// defineSlice defines the flag of the input slice field whose elements are numbers or bools.
+//
+// It returns false when the element type is not supported.
+func defineSlice(c *cobra.Command, field reflect.Value, name, short, descr string) bool {
+	ptr := unsafe.Pointer(field.UnsafeAddr())
+	switch field.Type().Elem() {
+	case reflect.TypeOf(int(0)):
+		c.Flags().IntSliceVarP((*[]int)(ptr), name, short, *(*[]int)(ptr), descr)
+	case reflect.TypeOf(int64(0)):
+		c.Flags().Int64SliceVarP((*[]int64)(ptr), name, short, *(*[]int64)(ptr), descr)
+	case reflect.TypeOf(uint(0)):
+		c.Flags().UintSliceVarP((*[]uint)(ptr), name, short, *(*[]uint)(ptr), descr)
+	case reflect.TypeOf(uint64(0)):
+		c.Flags().VarP(newUint64SliceValue((*[]uint64)(ptr)), name, short, descr)
+	case reflect.TypeOf(float64(0)):
+		c.Flags().Float64SliceVarP((*[]float64)(ptr), name, short, *(*[]float64)(ptr), descr)
+	case reflect.TypeOf(false):
+		c.Flags().BoolSliceVarP((*[]bool)(ptr), name, short, *(*[]bool)(ptr), descr)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// unwrapSlices replaces the command line values of the numeric, bool, and IP slice flags with their entries.
+//
+// Viper only knows how to read the string and int slice flags, and reads the other ones as their bracketed string form (eg., "[1,2]").
+func unwrapSlices(c *cobra.Command, settings map[string]interface{}) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		v, ok := f.Value.(pflag.SliceValue)
+		if !ok {
+			return
+		}
+		switch f.Value.Type() {
+		case "uintSlice", "uint64Slice", "int64Slice", "float64Slice", "boolSlice", "ipSlice":
+		default:
+			return
+		}
+		if !fromFlag(c, f) {
+			return
+		}
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			if _, ok := m[key].(string); ok {
+				m[key] = v.GetSlice()
+			}
+		})
+	})
+}
+
+// fromFlag tells whether the settings of the input flag hold the value of the flag itself,
+// because it is set on the command line and wins, or because no other source sets it.
+func fromFlag(c *cobra.Command, f *pflag.Flag) bool {
+	src := winner(lookupSources(c, f), getScope(c).precedenceOf(f.Name))
+
+	return src == SourceFlag || src == SourceDefault
+}
+
+// splitSlices splits the string values of the slice flags having a custom separator, or no splitting at all.
+//
+// It works on the settings to unmarshal, so that the values coming from the environment
+// or the config file get split the same way the command line ones do,
+// rather than on commas by the default string to slice decode hook.
+func splitSlices(c *cobra.Command, settings map[string]interface{}) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		seps, ok := f.Annotations[FlagSeparatorAnnotation]
+		if !ok || len(seps) == 0 {
+			return
+		}
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			// The values of the flag itself come as their entries already
+			if val, ok := m[key].(string); ok {
+				m[key] = splitSlice(val, seps[0])
+			}
+		})
+	})
+}
+
+// visitSettings calls fn with the map holding the settings of the input flag and its key there, for each of its keys.
+//
+// The keys of a flag are its name and, when aliased, its struct path.
+func visitSettings(c *cobra.Command, settings map[string]interface{}, name string, fn func(m map[string]interface{}, key string)) {
+	s := getScope(c)
+	keys := []string{name}
+	for path, n := range s.aliases {
+		if n == name {
+			keys = append(keys, path)
+		}
+	}
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+		m := settings
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := m[part].(map[string]interface{})
+			if !ok {
+				m = nil
+
+				break
+			}
+			m = next
+		}
+		if m == nil {
+			continue
+		}
+		if _, ok := m[parts[len(parts)-1]]; ok {
+			fn(m, parts[len(parts)-1])
+		}
+	}
+}
+
+// splitSlice splits the input string on the given separator.
+//
+// An empty separator makes the whole string a single entry.
+func splitSlice(input, sep string) []string {
+	if input == "" {
+		return []string{}
+	}
+	if sep == "" {
+		return []string{input}
+	}
+
+	return strings.Split(input, sep)
+}
+
+// cleanSlices trims the entries of the string slice fields, drops their empty ones, and deduplicates them, as requested by their flags.
+//
+// For example, " a, ,b" decodes to ["a", "b"] rather than [" a", " ", "b"] when trimming and dropping are requested.
+// Deduplicating keeps the first occurrence of each entry.
+func cleanSlices(c *cobra.Command, opts interface{}) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		_, trim := f.Annotations[FlagTrimAnnotation]
+		_, dropEmpty := f.Annotations[FlagDropEmptyAnnotation]
+		_, unique := f.Annotations[FlagUniqueAnnotation]
+		if !trim && !dropEmpty && !unique {
+			return
+		}
+		path, ok := s.flagPath(f.Name)
+		if !ok {
+			return
+		}
+		field, ok := fieldByPath(reflect.ValueOf(opts), path)
+		if !ok || field.Kind() != reflect.Slice || field.Type().Elem().Kind() != reflect.String || !field.CanSet() {
+			return
+		}
+		res := reflect.MakeSlice(field.Type(), 0, field.Len())
+		seen := map[string]bool{}
+		for i := 0; i < field.Len(); i++ {
+			entry := field.Index(i).String()
+			if trim {
+				entry = strings.TrimSpace(entry)
+			}
+			if dropEmpty && entry == "" {
+				continue
+			}
+			if unique {
+				if seen[entry] {
+					continue
+				}
+				seen[entry] = true
+			}
+			res = reflect.Append(res, reflect.ValueOf(entry).Convert(field.Type().Elem()))
+		}
+		field.Set(res)
+	})
+}
+
+// sliceState tracks the command line entries of a slice flag.
+//
+// The flag shares its memory with the options field, so its value changes on unmarshal.
+type sliceState struct {
+	entries []string
+	last    string
+}
+
+// appendSlices makes the command line entries of the slice flags with the append merge policy add to the entries from the environment or the config file.
+//
+// The command line entries go after the ones of the highest-precedence source among env and config.
+// When a custom precedence order ranks such a source above the command line, its entries win as they are.
+func appendSlices(c *cobra.Command, settings map[string]interface{}) {
+	s := getScope(c)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		policy := f.Annotations[FlagMergeAnnotation]
+		if len(policy) == 0 || policy[0] != MergeAppend || !f.Changed {
+			return
+		}
+		v, ok := f.Value.(pflag.SliceValue)
+		if !ok {
+			return
+		}
+		state, ok := s.slices[f.Name]
+		if !ok || f.Value.String() != state.last {
+			// Copy the entries, since the pflag slices return the memory of the field
+			state = &sliceState{entries: append([]string{}, v.GetSlice()...)}
+			s.slices[f.Name] = state
+		}
+
+		values := lookupSources(c, f)
+		order := s.precedenceOf(f.Name)
+		if winner(values, order) != SourceFlag {
+			return
+		}
+		delete(values, SourceFlag)
+		base := winner(values, order)
+		if base == SourceDefault {
+			return
+		}
+		sep := ","
+		if seps, ok := f.Annotations[FlagSeparatorAnnotation]; ok && len(seps) > 0 {
+			sep = seps[0]
+		}
+		entries := append(sliceEntries(values[base], sep), state.entries...)
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			m[key] = entries
+		})
+	})
+}
+
+// recordSlices stores the values the slice flags with the append merge policy got from the last unmarshal.
+func recordSlices(c *cobra.Command) {
+	s := getScope(c)
+	for name, state := range s.slices {
+		if f := c.Flags().Lookup(name); f != nil {
+			state.last = f.Value.String()
+		}
+	}
+}
+
+// sliceEntries converts the raw value of a source into slice entries.
+func sliceEntries(val interface{}, sep string) []string {
+	switch v := val.(type) {
+	case string:
+		return splitSlice(v, sep)
+	case []string:
+		return append([]string{}, v...)
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, entry := range v {
+			res = append(res, fmt.Sprint(entry))
+		}
+
+		return res
+	}
+
+	return []string{fmt.Sprint(val)}
+}
@@ -0,0 +1,212 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestSliceSeparator() {
+	c := &cobra.Command{Use: "sep"}
+	opts := &separatorOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("a,b;c", c.Flags().Lookup("names").DefValue)
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"a,b", "c"}, opts.Names)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--names", "x,y;z", "--names", "w", "--tags", "t1,t2"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"x,y", "z", "w"}, opts.Names)
+	suite.Equal([]string{"t1", "t2"}, opts.Tags)
+}
+
+func (suite *UnmarshalSuite) TestSliceSeparatorFromConfigAndEnv() {
+	suite.useConfigFile("config.yaml", "dsns: host=a,port=1;host=b\n")
+
+	c := &cobra.Command{Use: "sep"}
+	opts := &separatorOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"host=a,port=1", "host=b"}, opts.DB.DSNs)
+
+	suite.T().Setenv("TAGS", "t1,t2")
+	suite.T().Setenv("DSNS", "host=c,port=2;host=d")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"host=c,port=2", "host=d"}, opts.DB.DSNs)
+	suite.Equal([]string{"t1", "t2"}, opts.Tags)
+}
+
+func (suite *UnmarshalSuite) TestSliceNoSplit() {
+	suite.useConfigFile("config.yaml", "names:\n  - Doe, John\n  - Roe, Jane\n")
+
+	c := &cobra.Command{Use: "nosplit"}
+	opts := &noSplitOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"Doe, John", "Roe, Jane"}, opts.Names)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--names", "Poe, Edgar", "--names", "Woe, Ann"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"Poe, Edgar", "Woe, Ann"}, opts.Names)
+	suite.Equal("stringSlice", c.Flags().Lookup("names").Value.Type())
+	suite.Equal(`["Poe, Edgar","Woe, Ann"]`, c.Flags().Lookup("names").Value.String())
+
+	// Entries read back as they are, whatever they hold
+	c = &cobra.Command{Use: "nosplit"}
+	opts = &noSplitOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--names", "first\nsecond", "--names", `say "hi"`}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"first\nsecond", `say "hi"`}, opts.Names)
+
+	suite.T().Setenv("NAMES", "Doe, John")
+	c = &cobra.Command{Use: "nosplit"}
+	opts = &noSplitOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"Doe, John"}, opts.Names)
+}
+
+func (suite *UnmarshalSuite) TestSliceNoSplitConflict() {
+	c := &cobra.Command{Use: "nosplit"}
+	suite.ErrorContains(Define(c, &conflictingSplitOptions{}), "invalid flagsplit tag on names: it conflicts with flagsep")
+}
+
+func (suite *UnmarshalSuite) TestSliceCleanup() {
+	suite.T().Setenv("TAGS", ",a,, b ,")
+	suite.T().Setenv("NAMES", ",a,, b ,")
+
+	c := &cobra.Command{Use: "cleanup"}
+	opts := &cleanupOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"a", "b"}, opts.Tags)
+	suite.Equal([]string{"", "a", "", " b ", ""}, opts.Names)
+}
+
+func (suite *UnmarshalSuite) TestSliceCleanupOptions() {
+	suite.useConfigFile("config.yaml", "names:\n  - ' a '\n  - ''\n")
+
+	c := &cobra.Command{Use: "cleanup"}
+	opts := &noSplitOptions{}
+	suite.Require().Nil(Define(c, opts, WithTrimSlices(), WithDropEmptySlices()))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"a"}, opts.Names)
+}
+
+func (suite *UnmarshalSuite) TestSliceAppend() {
+	suite.useConfigFile("config.yaml", "hosts:\n  - a\n  - b\n")
+
+	c := &cobra.Command{Use: "append"}
+	opts := &appendOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--hosts", "b,c", "--tags", "x"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"a", "b", "c"}, opts.Hosts)
+	suite.Equal([]string{"x"}, opts.Tags)
+	// Unmarshalling again does not append the entries twice
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"a", "b", "c"}, opts.Hosts)
+
+	suite.T().Setenv("HOSTS", "d;e")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]string{"d;e", "b", "c"}, opts.Hosts)
+}
+
+func (suite *UnmarshalSuite) TestSliceAppendInvalid() {
+	c := &cobra.Command{Use: "append"}
+	suite.ErrorContains(Define(c, &invalidMergeOptions{}), `invalid flagmerge tag on hosts: unknown policy "extend" for slices`)
+	c = &cobra.Command{Use: "append"}
+	suite.ErrorContains(Define(c, &invalidMergeTypeOptions{}), "invalid flagmerge tag on host: only slices and maps can be merged")
+}
+
+func (suite *UnmarshalSuite) TestNumericSlices() {
+	c := &cobra.Command{Use: "slices"}
+	opts := &numericSlicesOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{
+		"--int64s", "-1,2", "--uints", "1,2", "--big", "18446744073709551615", "--big", "3", "--ratios", "0.5", "--toggles", "true,false",
+	}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]int{1, 2}, opts.Ints)
+	suite.Equal([]int64{-1, 2}, opts.Int64s)
+	suite.Equal([]uint{1, 2}, opts.Uints)
+	suite.Equal([]uint64{18446744073709551615, 3}, opts.Big)
+	suite.Equal([]float64{0.5}, opts.Ratios)
+	suite.Equal([]bool{true, false}, opts.Toggles)
+	suite.Equal("[18446744073709551615,3]", c.Flags().Lookup("big").Value.String())
+	suite.ErrorContains(c.Flags().Set("big", "-1"), "invalid syntax")
+
+	suite.useConfigFile("config.yaml", "toggles: [true]\nbig: [7]\nints: [3]\n")
+	suite.T().Setenv("RATIOS", "1.5,2")
+	suite.T().Setenv("UINTS", "4")
+	c = &cobra.Command{Use: "slices"}
+	opts = &numericSlicesOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]int{3}, opts.Ints)
+	suite.Equal([]uint{4}, opts.Uints)
+	suite.Equal([]uint64{7}, opts.Big)
+	suite.Equal([]float64{1.5, 2}, opts.Ratios)
+	suite.Equal([]bool{true}, opts.Toggles)
+
+	suite.T().Setenv("UINTS", "-4")
+	suite.ErrorContains(Unmarshal(c, opts), `invalid value "-4" for env UINTS (flag --uints)`)
+}
+
+type numericSlicesOptions struct {
+	fixture
+
+	Ints    []int `default:"1,2"`
+	Int64s  []int64
+	Uints   []uint `flagenv:"true"`
+	Big     []uint64
+	Ratios  []float64 `flagenv:"true"`
+	Toggles []bool
+}
+
+type appendOptions struct {
+	fixture
+
+	Hosts []string `flagmerge:"append" flagunique:"true" flagenv:"true"`
+	Tags  []string `flagmerge:"replace"`
+}
+
+type invalidMergeOptions struct {
+	fixture
+
+	Hosts []string `flagmerge:"extend"`
+}
+
+type invalidMergeTypeOptions struct {
+	fixture
+
+	Host string `flagmerge:"append"`
+}
+
+type cleanupOptions struct {
+	fixture
+
+	Tags  []string `flagenv:"true" flagtrim:"true" flagdropempty:"true"`
+	Names []string `flagenv:"true"`
+}
+
+type noSplitOptions struct {
+	fixture
+
+	Names []string `flagsplit:"false" flagenv:"true"`
+}
+
+type conflictingSplitOptions struct {
+	fixture
+
+	Names []string `flagsplit:"false" flagsep:";"`
+}
+
+type separatorOptions struct {
+	fixture
+
+	Names []string `flagsep:";" default:"a,b;c"`
+	Tags  []string `flagenv:"true"`
+	DB    struct {
+		DSNs []string `flag:"dsns" flagsep:";" flagenv:"true"`
+	}
+}
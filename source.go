@@ -0,0 +1,326 @@
+package autoflags
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Source identifies where the value of a flag comes from.
+type Source int
+
+const (
+	SourceDefault Source = iota
+	SourceConfig
+	SourceEnv
+	SourceFlag
+	// SourcePassthrough marks the fields collecting the command line arguments as written (see the flagargs tag)
+	SourcePassthrough
+	// SourceValues marks the values set in memory via WithValues
+	SourceValues
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	case SourcePassthrough:
+		return "passthrough"
+	case SourceValues:
+		return "values"
+	default:
+		return "default"
+	}
+}
+
+// defaultPrecedence is the default precedence order, from the highest to the lowest.
+var defaultPrecedence = []Source{SourceValues, SourceFlag, SourceEnv, SourceConfig}
+
+// viperPrecedence is the precedence order viper applies by itself, from the highest to the lowest.
+var viperPrecedence = []Source{SourceFlag, SourceEnv, SourceConfig}
+
+// precedence returns the active precedence order of the command, from the highest to the lowest.
+func (s *scope) precedence() []Source {
+	if len(s.order) == 0 {
+		return defaultPrecedence
+	}
+
+	return s.order
+}
+
+// precedenceOf returns the active precedence order for the input flag.
+func (s *scope) precedenceOf(name string) []Source {
+	if order, ok := s.fieldOrders[name]; ok {
+		return order
+	}
+
+	return s.precedence()
+}
+
+// parsePrecedence parses the value of a flagprecedence tag (eg., "config>env>cli").
+func parsePrecedence(tag string) ([]Source, error) {
+	sources := []Source{}
+	for _, part := range strings.Split(tag, ">") {
+		switch strings.TrimSpace(strings.ToLower(part)) {
+		case "cli", "flag":
+			sources = append(sources, SourceFlag)
+		case "env":
+			sources = append(sources, SourceEnv)
+		case "config":
+			sources = append(sources, SourceConfig)
+		case "values":
+			sources = append(sources, SourceValues)
+		default:
+			return nil, fmt.Errorf("unknown source %q", part)
+		}
+	}
+
+	return completePrecedence(sources)
+}
+
+// completePrecedence validates the input order and appends the sources it misses.
+//
+// The in-memory values keep the highest precedence unless listed.
+func completePrecedence(sources []Source) ([]Source, error) {
+	res := []Source{}
+	seen := map[Source]bool{}
+	for _, src := range sources {
+		if src != SourceFlag && src != SourceEnv && src != SourceConfig && src != SourceValues {
+			return nil, fmt.Errorf("source %s cannot be reordered", src)
+		}
+		if seen[src] {
+			return nil, fmt.Errorf("source %s is listed more than once", src)
+		}
+		seen[src] = true
+		res = append(res, src)
+	}
+	for _, src := range viperPrecedence {
+		if !seen[src] {
+			res = append(res, src)
+		}
+	}
+	if !seen[SourceValues] {
+		res = append([]Source{SourceValues}, res...)
+	}
+
+	return res, nil
+}
+
+// lookupSources returns the values the input flag has in each source setting it.
+func lookupSources(c *cobra.Command, f *pflag.Flag) map[Source]interface{} {
+	res := map[Source]interface{}{}
+
+	if f.Changed {
+		tmp := viper.New()
+		_ = tmp.BindPFlag(f.Name, f)
+		res[SourceFlag] = tmp.Get(f.Name)
+	}
+	for _, env := range boundEnvs(f) {
+		if val, ok := os.LookupEnv(env); ok && val != "" {
+			res[SourceEnv] = val
+
+			break
+		}
+	}
+	if _, val, ok := lookupFlagConfig(c, f); ok {
+		res[SourceConfig] = val
+	}
+	if val, ok := getScope(c).values[f.Name]; ok {
+		res[SourceValues] = val
+	}
+
+	return res
+}
+
+// lookupFlagConfig returns the key and the value the loaded config file has for the input flag.
+//
+// The config key is either the flag name or the struct path of its field.
+func lookupFlagConfig(c *cobra.Command, f *pflag.Flag) (string, interface{}, bool) {
+	return lookupFlagSetting(c, f, configSettings)
+}
+
+// lookupFlagSetting returns the key and the value the input settings have for the input flag, as lookupFlagConfig does.
+func lookupFlagSetting(c *cobra.Command, f *pflag.Flag, settings map[string]interface{}) (string, interface{}, bool) {
+	s := getScope(c)
+	typ, ok := s.types[f.Name]
+	maps := ok && typ.Kind() == reflect.Map
+	if val, ok := lookupSetting(settings, f.Name, maps); ok {
+		return f.Name, val, true
+	}
+	for path, name := range s.aliases {
+		if name != f.Name {
+			continue
+		}
+		if val, ok := lookupSetting(settings, path, maps); ok {
+			return path, val, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// origin describes the source the input flag takes its value from (eg., "env MYAPP_TIMEOUT", "config /etc/app/config.yaml:12:3").
+//
+// Config sources include the line and the column of the key, when the config file format allows to find them.
+func origin(c *cobra.Command, f *pflag.Flag) string {
+	switch winner(lookupSources(c, f), getScope(c).precedenceOf(f.Name)) {
+	case SourceFlag:
+		return SourceFlag.String()
+	case SourceValues:
+		return SourceValues.String()
+	case SourceEnv:
+		for _, env := range boundEnvs(f) {
+			if val, ok := os.LookupEnv(env); ok && val != "" {
+				return fmt.Sprintf("env %s", env)
+			}
+		}
+
+		return SourceEnv.String()
+	case SourceConfig:
+		file := viper.ConfigFileUsed()
+		key, _, ok := lookupFlagConfig(c, f)
+		if projectFile != "" {
+			if projectKey, _, found := lookupFlagSetting(c, f, projectSettings); found {
+				file, key = projectFile, projectKey
+			}
+		}
+		if file == "" {
+			return SourceConfig.String()
+		}
+		if ok {
+			if line, col, ok := configPosition(file, key); ok {
+				return fmt.Sprintf("config %s:%d:%d", MaskPath(file), line, col)
+			}
+		}
+
+		return fmt.Sprintf("config %s", MaskPath(file))
+	}
+
+	return SourceDefault.String()
+}
+
+// providedValue returns the value of the input flag as the user wrote it in the winning env or config source.
+//
+// It returns the input formatted value otherwise, since the command line parsing does not keep the original text.
+func providedValue(c *cobra.Command, f *pflag.Flag, formatted string) string {
+	values := lookupSources(c, f)
+	src := winner(values, getScope(c).precedenceOf(f.Name))
+	if src != SourceEnv && src != SourceConfig {
+		return formatted
+	}
+	if text, ok := values[src].(string); ok && text != "" {
+		return text
+	}
+
+	return formatted
+}
+
+// winner returns the source whose value wins according to the input precedence.
+func winner(values map[Source]interface{}, order []Source) Source {
+	for _, src := range order {
+		if _, ok := values[src]; ok {
+			return src
+		}
+	}
+
+	return SourceDefault
+}
+
+// resolve makes the input settings of the command honor its precedence order, where it differs from the one of viper.
+//
+// It writes the winners into the settings of each unmarshalling, rather than into viper, so that they never go stale.
+func resolve(c *cobra.Command, settings map[string]interface{}) {
+	s := getScope(c)
+	if len(s.order) == 0 && len(s.fieldOrders) == 0 && len(s.values) == 0 {
+		return
+	}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		values := lookupSources(c, f)
+		src := winner(values, s.precedenceOf(f.Name))
+		if src == SourceDefault || src == winner(values, viperPrecedence) {
+			return
+		}
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			m[key] = values[src]
+		})
+	})
+}
+
+// resolvedValue returns the value the input flag takes from the source winning according to the precedence order, or its default.
+func resolvedValue(c *cobra.Command, f *pflag.Flag) interface{} {
+	s := getScope(c)
+	values := lookupSources(c, f)
+	if src := winner(values, s.precedenceOf(f.Name)); src != SourceDefault {
+		return values[src]
+	}
+
+	return s.viper.Get(f.Name)
+}
+
+// Provenance returns the source each flag of the command takes its value from, according to the active precedence order.
+//
+// The fields collecting the command line arguments (see the flagargs tag) are keyed by their struct paths,
+// with SourcePassthrough when they get any argument.
+//
+// Count flags incremented on the command line report the flag source, even when the increments add to an env or config value.
+// NewAuditRecord reports such a value as the base.
+func Provenance(c *cobra.Command) (map[string]Source, error) {
+	s, ok := scopes[c]
+	if !ok {
+		return nil, fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	res := map[string]Source{}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		res[f.Name] = winner(lookupSources(c, f), s.precedenceOf(f.Name))
+	})
+	for _, field := range s.args {
+		res[field.path] = SourceDefault
+		if len(commandArgs(c, field.mode)) > 0 {
+			res[field.path] = SourcePassthrough
+		}
+	}
+
+	return res, nil
+}
+
+// WasSet tells whether any source sets the input flag, by flag name or struct path, along with the source its value comes from.
+//
+// Unlike pflag's Changed, it also considers the environment, the config file, and the in-memory values, honoring the precedence order.
+// The fields collecting the command line arguments (see the flagargs tag) are set when they get any argument.
+func WasSet(c *cobra.Command, name string) (bool, Source) {
+	s, ok := scopes[c]
+	if !ok {
+		return false, SourceDefault
+	}
+	for _, field := range s.args {
+		if field.path == strings.ToLower(name) {
+			if len(commandArgs(c, field.mode)) > 0 {
+				return true, SourcePassthrough
+			}
+
+			return false, SourceDefault
+		}
+	}
+	f := c.Flags().Lookup(name)
+	if f == nil {
+		flagName, ok := s.paths[strings.ToLower(name)]
+		if !ok {
+			return false, SourceDefault
+		}
+		if f = c.Flags().Lookup(flagName); f == nil {
+			return false, SourceDefault
+		}
+	}
+	src := winner(lookupSources(c, f), s.precedenceOf(f.Name))
+
+	return src != SourceDefault, src
+}
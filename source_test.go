@@ -0,0 +1,109 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestPrecedence() {
+	suite.useConfigFile("config.yaml", "server:\n  port: 1\n")
+	suite.T().Setenv("SERVER_PORT", "2")
+
+	cases := []struct {
+		desc     string
+		args     []string
+		order    []Source
+		expected int
+		source   Source
+	}{
+		{"env over config by default", nil, nil, 2, SourceEnv},
+		{"flag wins by default", []string{"--server.port", "3"}, nil, 3, SourceFlag},
+		{"env over flag", []string{"--server.port", "3"}, []Source{SourceEnv}, 2, SourceEnv},
+		{"config over everything", []string{"--server.port", "3"}, []Source{SourceConfig, SourceFlag}, 1, SourceConfig},
+	}
+
+	for _, tc := range cases {
+		suite.Run(tc.desc, func() {
+			c := &cobra.Command{Use: "precedence"}
+			opts := &portOptions{}
+			suite.Require().Nil(Define(c, opts, WithPrecedence(tc.order...)))
+			suite.Require().Nil(c.Flags().Parse(tc.args))
+			suite.Require().Nil(Unmarshal(c, opts))
+			suite.Equal(tc.expected, opts.Server.Port)
+
+			provenance, err := Provenance(c)
+			suite.Require().Nil(err)
+			suite.Equal(tc.source, provenance["server.port"])
+		})
+	}
+
+	err := Define(&cobra.Command{}, &portOptions{}, WithPrecedence(SourceEnv, SourceEnv))
+	suite.EqualError(err, "source env is listed more than once")
+
+	// The winners do not stick across unmarshallings
+	c := &cobra.Command{Use: "stale"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts, WithPrecedence(SourceConfig, SourceEnv)))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(1, opts.Server.Port)
+	configSettings = nil
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(2, opts.Server.Port)
+	settings, err := SettingsOf(c)
+	suite.Require().Nil(err)
+	suite.Equal(2, settings.GetInt("server.port"))
+}
+
+func (suite *UnmarshalSuite) TestWasSet() {
+	suite.useConfigFile("config.yaml", "server:\n  port: 1\n")
+
+	c := &cobra.Command{Use: "wasset"}
+	opts := &contextValidatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	set, src := WasSet(c, "server.port")
+	suite.True(set)
+	suite.Equal(SourceConfig, src)
+	suite.False(c.Flags().Changed("server.port"))
+	set, src = WasSet(c, "force")
+	suite.False(set)
+	suite.Equal(SourceDefault, src)
+
+	suite.T().Setenv("SERVER_PORT", "2")
+	_, src = WasSet(c, "server.port")
+	suite.Equal(SourceEnv, src)
+	suite.Require().Nil(c.Flags().Parse([]string{"--force"}))
+	_, src = WasSet(c, "Force")
+	suite.Equal(SourceFlag, src)
+
+	set, _ = WasSet(c, "unknown")
+	suite.False(set)
+	set, _ = WasSet(&cobra.Command{Use: "undefined"}, "force")
+	suite.False(set)
+}
+
+func (suite *UnmarshalSuite) TestFieldPrecedence() {
+	suite.useConfigFile("config.yaml", "enforced: from-config\nfree: from-config\n")
+
+	c := &cobra.Command{Use: "policy"}
+	opts := &policyOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--enforced", "from-cli", "--free", "from-cli"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal("from-config", opts.Enforced)
+	suite.Equal("from-cli", opts.Free)
+
+	err := Define(&cobra.Command{}, &invalidPolicyOptions{})
+	suite.EqualError(err, `invalid flagprecedence tag on enforced: unknown source "nope"`)
+}
+
+type policyOptions struct {
+	fixture
+
+	Enforced string `flagprecedence:"config>env>cli"`
+	Free     string
+}
+
+type invalidPolicyOptions struct {
+	fixture
+
+	Enforced string `flagprecedence:"config>nope"`
+}
@@ -0,0 +1,16 @@
+// Package structcli is the former identity of the autoflags module.
+//
+// It forwards every exported identifier to github.com/leodido/autoflags, with types as aliases,
+// so that projects importing either module path build and interoperate.
+// Variables are copies of the autoflags ones: change the autoflags behavior via its setters (eg., SetEnvPrefix).
+//
+// Each release requires the autoflags version it forwards to.
+// Within the autoflags repository, the go.work file next to the module builds it against the sources instead.
+//
+// Deprecated: import github.com/leodido/autoflags instead.
+package structcli
+
+//go:generate go run ./internal/forward .. github.com/leodido/autoflags structcli autoflags.go
+//go:generate go run ./internal/forward ../options github.com/leodido/autoflags/options options options/options.go
+//go:generate go run ./internal/forward ../values github.com/leodido/autoflags/values values values/values.go
+//go:generate go run ./internal/forward ../autoflagstest github.com/leodido/autoflags/autoflagstest structclitest structclitest/structclitest.go
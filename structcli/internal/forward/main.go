@@ -0,0 +1,222 @@
+// Command forward generates the declarations forwarding the exported API of a package to another package.
+//
+// Constants and variables become copies, types become aliases, and functions become wrappers.
+//
+//	forward <source dir> <source import path> <package name> <output file>
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) != 5 {
+		fmt.Fprintln(os.Stderr, "usage: forward <source dir> <source import path> <package name> <output file>")
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Args[2], os.Args[3], os.Args[4]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+type generator struct {
+	fset *token.FileSet
+	// qualifier is the name the generated code refers to the source package with
+	qualifier string
+	// imports maps the package names the source files use to their import paths
+	imports map[string]string
+	used    map[string]bool
+}
+
+func run(dir, importPath, pkg, out string) error {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return err
+	}
+	files := []*ast.File{}
+	names := []string{}
+	for _, p := range pkgs {
+		for name := range p.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, p.Files[name])
+		}
+	}
+
+	g := &generator{
+		fset:      fset,
+		qualifier: path.Base(importPath),
+		imports:   map[string]string{},
+		used:      map[string]bool{importPath: true},
+	}
+	decls := map[string]string{}
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			name := path.Base(p)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			g.imports[name] = p
+		}
+	}
+	for _, f := range files {
+		for _, d := range f.Decls {
+			switch x := d.(type) {
+			case *ast.FuncDecl:
+				if x.Recv == nil && x.Name.IsExported() {
+					decls[x.Name.Name] = g.function(x)
+				}
+			case *ast.GenDecl:
+				for _, s := range x.Specs {
+					switch y := s.(type) {
+					case *ast.TypeSpec:
+						if y.Name.IsExported() {
+							decls[y.Name.Name] = fmt.Sprintf("type %s = %s.%s", y.Name.Name, g.qualifier, y.Name.Name)
+						}
+					case *ast.ValueSpec:
+						for _, n := range y.Names {
+							if n.IsExported() {
+								decls[n.Name] = fmt.Sprintf("%s %s = %s.%s", x.Tok, n.Name, g.qualifier, n.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(decls))
+	for key := range decls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// Standard library imports go first, as goimports groups them
+	std, others := []string{}, []string{}
+	for p := range g.used {
+		if strings.Contains(p, ".") {
+			others = append(others, strconv.Quote(p))
+		} else {
+			std = append(std, strconv.Quote(p))
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	imports := strings.Join(others, "\n")
+	if len(std) > 0 {
+		imports = strings.Join(std, "\n") + "\n\n" + imports
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by forward. DO NOT EDIT.\n\npackage %s\n\nimport (\n%s\n)\n", pkg, imports)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n%s\n", decls[key])
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("couldn't format the generated code: %w\n%s", err, b.String())
+	}
+
+	return os.WriteFile(out, src, 0o644)
+}
+
+// function returns the wrapper of the input function.
+func (g *generator) function(fn *ast.FuncDecl) string {
+	typeParams, typeArgs := "", ""
+	if fn.Type.TypeParams != nil {
+		params, args := g.fields(fn.Type.TypeParams, "T")
+		typeParams = "[" + strings.Join(params, ", ") + "]"
+		typeArgs = "[" + strings.Join(args, ", ") + "]"
+	}
+	params, args := g.fields(fn.Type.Params, "p")
+	call := fmt.Sprintf("%s.%s%s(%s)", g.qualifier, fn.Name.Name, typeArgs, strings.Join(args, ", "))
+	results := ""
+	if fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
+		res := []string{}
+		for _, field := range fn.Type.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				res = append(res, g.expr(field.Type))
+			}
+		}
+		results = " " + strings.Join(res, ", ")
+		if len(res) > 1 {
+			results = " (" + strings.Join(res, ", ") + ")"
+		}
+		call = "return " + call
+	}
+
+	return fmt.Sprintf("// %s forwards to %s.%s.\nfunc %s%s(%s)%s {\n%s\n}", fn.Name.Name, g.qualifier, fn.Name.Name, fn.Name.Name, typeParams, strings.Join(params, ", "), results, call)
+}
+
+// fields returns the declarations and the names of the input parameters, naming the unnamed ones with the given prefix.
+func (g *generator) fields(list *ast.FieldList, prefix string) ([]string, []string) {
+	params, args := []string{}, []string{}
+	i := 0
+	for _, field := range list.List {
+		typ := g.expr(field.Type)
+		names := []string{}
+		for _, n := range field.Names {
+			if n.Name == "_" {
+				names = append(names, fmt.Sprintf("%s%d", prefix, i))
+			} else {
+				names = append(names, n.Name)
+			}
+			i++
+		}
+		if len(names) == 0 {
+			names = append(names, fmt.Sprintf("%s%d", prefix, i))
+			i++
+		}
+		for _, n := range names {
+			params = append(params, n+" "+typ)
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				n += "..."
+			}
+			args = append(args, n)
+		}
+	}
+
+	return params, args
+}
+
+// expr prints the input type expression, tracking the imports it needs.
+func (g *generator) expr(e ast.Expr) string {
+	ast.Inspect(e, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				if p, ok := g.imports[id.Name]; ok {
+					g.used[p] = true
+				}
+			}
+
+			return false
+		}
+
+		return true
+	})
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, g.fset, e)
+
+	return b.String()
+}
@@ -0,0 +1,4 @@
+// Package options forwards to github.com/leodido/autoflags/options.
+//
+// Deprecated: import github.com/leodido/autoflags/options instead.
+package options
@@ -0,0 +1,33 @@
+package structcli_test
+
+import (
+	"testing"
+
+	"github.com/leodido/autoflags"
+	"github.com/leodido/structcli"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serveOptions struct {
+	Port int `flagenv:"true" default:"8080"`
+}
+
+func (o *serveOptions) Attach(c *cobra.Command) {}
+
+func TestForwarding(t *testing.T) {
+	t.Setenv("PORT", "9090")
+
+	c := &cobra.Command{Use: "serve"}
+	opts := &serveOptions{}
+	require.Nil(t, structcli.Define(c, opts, structcli.WithStrictTypes()))
+	require.Nil(t, autoflags.Unmarshal(c, opts))
+	assert.Equal(t, 9090, opts.Port)
+
+	// Values of either module identity are interchangeable
+	var src autoflags.Source = structcli.SourceEnv
+	provenance, err := structcli.Provenance(c)
+	require.Nil(t, err)
+	assert.Equal(t, src, provenance["port"])
+}
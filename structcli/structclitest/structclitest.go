@@ -0,0 +1,21 @@
+// Code generated by forward. DO NOT EDIT.
+
+package structclitest
+
+import (
+	"github.com/leodido/autoflags/autoflagstest"
+)
+
+type Keyring = autoflagstest.Keyring
+
+// NewKeyring forwards to autoflagstest.NewKeyring.
+func NewKeyring(secrets map[string]string) *Keyring {
+	return autoflagstest.NewKeyring(secrets)
+}
+
+// NewSecretsProvider forwards to autoflagstest.NewSecretsProvider.
+func NewSecretsProvider(secrets map[string]string) *SecretsProvider {
+	return autoflagstest.NewSecretsProvider(secrets)
+}
+
+type SecretsProvider = autoflagstest.SecretsProvider
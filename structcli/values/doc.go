@@ -0,0 +1,4 @@
+// Package values forwards to github.com/leodido/autoflags/values.
+//
+// Deprecated: import github.com/leodido/autoflags/values instead.
+package values
@@ -0,0 +1,49 @@
+// Code generated by forward. DO NOT EDIT.
+
+package values
+
+import (
+	"github.com/leodido/autoflags/values"
+)
+
+const Auto = values.Auto
+
+const Byte = values.Byte
+
+type ByteSize = values.ByteSize
+
+const GB = values.GB
+
+const GiB = values.GiB
+
+const KB = values.KB
+
+const KiB = values.KiB
+
+const MB = values.MB
+
+const MiB = values.MiB
+
+const Off = values.Off
+
+const On = values.On
+
+const PB = values.PB
+
+// ParseByteSize forwards to values.ParseByteSize.
+func ParseByteSize(str string) (ByteSize, error) {
+	return values.ParseByteSize(str)
+}
+
+// ParseTriState forwards to values.ParseTriState.
+func ParseTriState(str string) (TriState, error) {
+	return values.ParseTriState(str)
+}
+
+const PiB = values.PiB
+
+const TB = values.TB
+
+const TiB = values.TiB
+
+type TriState = values.TriState
@@ -0,0 +1,148 @@
+package autoflags
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagTelemetryAnnotation = "___flagtelemetry"
+)
+
+// TelemetryAttribute is a key-value pair describing a resolved option.
+//
+// Values are either string, bool, int64, float64, or []string so that they map 1:1 to OpenTelemetry attributes.
+type TelemetryAttribute struct {
+	Key   string
+	Value interface{}
+}
+
+func isTelemetry(f reflect.StructField) bool {
+	telemetry, _ := strconv.ParseBool(f.Tag.Get("flagtelemetry"))
+
+	return telemetry
+}
+
+// TelemetryAttributes returns the resolved values of the flags tagged with flagtelemetry, keyed by prefix plus flag name.
+//
+// Secret flags are never included.
+// The values come from the fields of the options, which hold their defaults until Unmarshal decodes into them.
+func TelemetryAttributes(c *cobra.Command, keyPrefix string) ([]TelemetryAttribute, error) {
+	if _, ok := scopes[c]; !ok {
+		return nil, fmt.Errorf("couldn't find a scope for %s", c.Name())
+	}
+	res := []TelemetryAttribute{}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if _, ok := f.Annotations[FlagTelemetryAnnotation]; !ok || isSecretFlag(f) {
+			return
+		}
+		field, ok := fieldValue(c, f.Name)
+		if !ok {
+			return
+		}
+		res = append(res, TelemetryAttribute{Key: keyPrefix + f.Name, Value: telemetryValue(field)})
+	})
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res, nil
+}
+
+func telemetryValue(val reflect.Value) interface{} {
+	switch v := configValue(val).(type) {
+	case string, bool, int64, float64:
+		return v
+	case []string:
+		return append([]string{}, v...)
+	}
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(val.Uint())
+	case reflect.Float32, reflect.Float64:
+		return val.Float()
+	}
+
+	return fmt.Sprintf("%v", val.Interface())
+}
+
+// Fingerprint returns a stable hash of the resolved values of the input command.
+//
+// It covers every flag but the secret ones, by name, so that equal configurations have equal fingerprints
+// whatever source their values come from, while the fingerprint reveals none of them.
+// It returns an empty string when the command was never defined, and the fingerprint of the defaults before Unmarshal runs.
+func Fingerprint(c *cobra.Command) string {
+	if _, ok := scopes[c]; !ok {
+		return ""
+	}
+	h := sha256.New()
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if isSecretFlag(f) {
+			return
+		}
+		field, ok := fieldValue(c, f.Name)
+		if !ok {
+			return
+		}
+		value, err := json.Marshal(flagValue(f, field))
+		if err != nil {
+			value = []byte(fmt.Sprintf("%v", field.Interface()))
+		}
+		fmt.Fprintf(h, "%s=%s\n", f.Name, value)
+	})
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FlagUsage tells which flags the user set on the command line for a command run.
+type FlagUsage struct {
+	// Command is the path of the command (eg., "app serve")
+	Command string
+	// Flags lists the names of the flags set on the command line, sorted
+	Flags []string
+	// Values holds the values of the flags among them tagged with flagtelemetry, secret ones excluded
+	Values map[string]interface{}
+}
+
+var usageHook func(FlagUsage)
+
+// SetUsageHook sets the callback learning which flags users set, eg., to find the options nobody uses before deprecating them.
+//
+// Unmarshal calls it once for each command, after the parsing.
+// A nil hook disables it.
+func SetUsageHook(hook func(FlagUsage)) {
+	usageHook = hook
+}
+
+// reportUsage calls the usage hook, if any, the first time the input command unmarshals.
+func reportUsage(c *cobra.Command) {
+	s := getScope(c)
+	if usageHook == nil || s.usageReported {
+		return
+	}
+	s.usageReported = true
+	res := FlagUsage{Command: c.CommandPath(), Flags: []string{}, Values: map[string]interface{}{}}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		res.Flags = append(res.Flags, f.Name)
+		if _, ok := f.Annotations[FlagTelemetryAnnotation]; !ok || isSecretFlag(f) {
+			return
+		}
+		if field, ok := fieldValue(c, f.Name); ok {
+			res.Values[f.Name] = telemetryValue(field)
+		}
+	})
+	usageHook(res)
+}
@@ -0,0 +1,42 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestTelemetryAttributes() {
+	c := &cobra.Command{Use: "telemetry"}
+	opts := &telemetryOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--workers", "4", "--token", "x", "--timeout", "1s"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+
+	attrs, err := TelemetryAttributes(c, "app.")
+	suite.Require().Nil(err)
+	suite.Equal([]TelemetryAttribute{
+		{Key: "app.timeout", Value: "1s"},
+		{Key: "app.workers", Value: int64(4)},
+	}, attrs)
+}
+
+func (suite *UnmarshalSuite) TestFingerprint() {
+	fingerprint := func(args ...string) string {
+		c := &cobra.Command{Use: "telemetry"}
+		opts := &telemetryOptions{}
+		suite.Require().Nil(Define(c, opts))
+		suite.Require().Nil(c.Flags().Parse(args))
+		suite.Require().Nil(Unmarshal(c, opts))
+
+		return Fingerprint(c)
+	}
+
+	base := fingerprint("--workers", "4")
+	suite.Len(base, 64)
+	suite.Equal(base, fingerprint("--workers", "4"))
+	suite.Equal(base, fingerprint("--workers", "4", "--token", "x"))
+	suite.NotEqual(base, fingerprint("--workers", "5"))
+
+	suite.useConfigFile("config.yaml", "workers: 4\n")
+	suite.Equal(base, fingerprint())
+	suite.Empty(Fingerprint(&cobra.Command{Use: "undefined"}))
+}
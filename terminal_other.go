@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package autoflags
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// isTerminal tells whether the input file descriptor is a terminal, which is never the case where no termios is available.
+func isTerminal(fd int) bool {
+	return false
+}
+
+// readPassword reads a line from the input terminal without echoing it.
+func readPassword(fd int) (string, error) {
+	return "", fmt.Errorf("no terminal available on %s", runtime.GOOS)
+}
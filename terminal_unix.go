@@ -0,0 +1,47 @@
+//go:build linux || darwin
+
+package autoflags
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// isTerminal tells whether the input file descriptor is a terminal.
+func isTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+
+	return err == nil
+}
+
+// readPassword reads a line from the input terminal without echoing it.
+func readPassword(fd int) (string, error) {
+	state, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return "", err
+	}
+	silent := *state
+	silent.Lflag &^= unix.ECHO
+	silent.Lflag |= unix.ICANON | unix.ISIG
+	silent.Iflag |= unix.ICRNL
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &silent); err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = unix.IoctlSetTermios(fd, ioctlSetTermios, state)
+	}()
+
+	line := []byte{}
+	buf := make([]byte, 1)
+	for {
+		n, err := unix.Read(fd, buf)
+		if err != nil {
+			return "", err
+		}
+		if n == 0 || buf[0] == '\n' {
+			break
+		}
+		line = append(line, buf[0])
+	}
+
+	return string(line), nil
+}
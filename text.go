@@ -0,0 +1,85 @@
+package autoflags
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// textValue is a flag for the types implementing encoding.TextUnmarshaler.
+//
+// It renders the value via encoding.TextMarshaler, when implemented, or fmt otherwise.
+type textValue struct {
+	ref encoding.TextUnmarshaler
+	typ string
+}
+
+var _ pflag.Value = (*textValue)(nil)
+
+func (v *textValue) Set(input string) error {
+	return v.ref.UnmarshalText([]byte(input))
+}
+
+func (v *textValue) bind(field reflect.Value) pflag.Value {
+	return &textValue{ref: field.Addr().Interface().(encoding.TextUnmarshaler), typ: v.typ}
+}
+
+func (v *textValue) Type() string {
+	return v.typ
+}
+
+func (v *textValue) String() string {
+	if m, ok := v.ref.(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	return fmt.Sprint(reflect.ValueOf(v.ref).Elem().Interface())
+}
+
+// isText tells whether the input type, or its pointer, implements encoding.TextUnmarshaler.
+func isText(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// defineText defines a flag for the input field when its type parses itself via encoding.TextUnmarshaler.
+func defineText(c *cobra.Command, field reflect.Value, name, short, descr string) bool {
+	if !isText(field.Type()) {
+		return false
+	}
+	typ := strings.ToLower(field.Type().Name())
+	if typ == "" {
+		typ = "string"
+	}
+	c.Flags().VarP(&textValue{ref: field.Addr().Interface().(encoding.TextUnmarshaler), typ: typ}, name, short, descr)
+	_ = c.Flags().SetAnnotation(name, FlagDecodeHookAnnotation, []string{"StringToTextUnmarshalerHookFunc"})
+
+	return true
+}
+
+// StringToTextUnmarshalerHookFunc decodes strings into the types implementing encoding.TextUnmarshaler, via UnmarshalText.
+//
+// The empty string decodes to the zero value, as the default of the flags having no default.
+func StringToTextUnmarshalerHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || !isText(t) {
+			return data, nil
+		}
+		res := reflect.New(t)
+		if text := reflect.ValueOf(data).String(); text != "" {
+			if err := res.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+				return nil, err
+			}
+		}
+
+		return res.Elem().Interface(), nil
+	}
+}
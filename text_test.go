@@ -0,0 +1,51 @@
+package autoflags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type hexColor string
+
+func (h *hexColor) UnmarshalText(text []byte) error {
+	if !strings.HasPrefix(string(text), "#") {
+		return fmt.Errorf("invalid color %q", text)
+	}
+	*h = hexColor(text)
+
+	return nil
+}
+
+type textOptions struct {
+	fixture
+
+	API    version `default:"v1.0"`
+	Min    version `flagenv:"true"`
+	Accent hexColor
+}
+
+func (suite *UnmarshalSuite) TestTextUnmarshalers() {
+	suite.useConfigFile("config.yaml", "accent: \"#ff0000\"\n")
+	suite.T().Setenv("MIN", "v0.9")
+
+	c := &cobra.Command{Use: "text"}
+	opts := &textOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("version", c.Flags().Lookup("api").Value.Type())
+	suite.Contains(c.UsageString(), "--accent hexcolor")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(version{1, 0}, opts.API)
+	suite.Equal(version{0, 9}, opts.Min)
+	suite.Equal(hexColor("#ff0000"), opts.Accent)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--api", "v2.1"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(version{2, 1}, opts.API)
+	suite.Equal("v2.1", c.Flags().Lookup("api").Value.String())
+	suite.EqualError(c.Flags().Parse([]string{"--accent", "red"}), `invalid argument "red" for "--accent" flag: invalid color "red"`)
+
+	suite.T().Setenv("MIN", "latest")
+	suite.ErrorContains(Unmarshal(c, opts), `invalid value "latest" for env MIN (flag --min): invalid version "latest"`)
+}
@@ -0,0 +1,208 @@
+package autoflags
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagLayoutAnnotation = "___flaglayout"
+	FlagZoneAnnotation   = "___flagzone"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeValue is a time.Time flag parsed with a layout, and optionally in a time zone, which pflag lacks.
+type timeValue struct {
+	ref    *time.Time
+	layout string
+	loc    *time.Location
+}
+
+var _ pflag.Value = (*timeValue)(nil)
+
+func newTimeValue(ref *time.Time, layout string, loc *time.Location) *timeValue {
+	return &timeValue{ref: ref, layout: layout, loc: loc}
+}
+
+func (v *timeValue) Set(input string) error {
+	t, err := parseTime(input, v.layout, v.loc)
+	if err != nil {
+		return err
+	}
+	*v.ref = t
+
+	return nil
+}
+
+func (v *timeValue) bind(field reflect.Value) pflag.Value {
+	return newTimeValue(field.Addr().Interface().(*time.Time), v.layout, v.loc)
+}
+
+func (v *timeValue) Type() string {
+	return "time"
+}
+
+func (v *timeValue) String() string {
+	return formatTime(*v.ref, v.layout, v.loc)
+}
+
+// layoutTime is a setting of a time.Time flag, along with the layout and the time zone of the flag to parse it with.
+//
+// The decode hooks apply by type rather than by field, so the settings carry the layout of their flag to the decoding.
+type layoutTime struct {
+	value  string
+	layout string
+	loc    *time.Location
+}
+
+// defineTime defines a flag for the input time.Time field, parsing it with the layout of its flaglayout tag (RFC3339 by default).
+//
+// The flagzone tag (eg., "Europe/Rome", "UTC", or "Local") sets the time zone of the inputs lacking an offset,
+// and the one the values and the default render in, for the usage message too.
+func defineTime(c *cobra.Command, f reflect.StructField, ref *time.Time, path, name, short, descr string) error {
+	layout := f.Tag.Get("flaglayout")
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	zone := f.Tag.Get("flagzone")
+	var loc *time.Location
+	if zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return fmt.Errorf("invalid flagzone tag on %s: unknown time zone %q", path, zone)
+		}
+	}
+	c.Flags().VarP(newTimeValue(ref, layout, loc), name, short, descr)
+	_ = c.Flags().SetAnnotation(name, FlagLayoutAnnotation, []string{layout})
+	if zone != "" {
+		_ = c.Flags().SetAnnotation(name, FlagZoneAnnotation, []string{zone})
+	}
+	_ = c.Flags().SetAnnotation(name, FlagDecodeHookAnnotation, []string{"StringToTimeHookFunc"})
+
+	return nil
+}
+
+// defineTimeDefault renders the default value of the input time.Time flag in its layout and time zone, for the usage message.
+//
+// It also gives the default to the flag value, since pflag only shows the defaults of the values it does not know when they are set.
+func defineTimeDefault(c *cobra.Command, name string) {
+	flag := c.Flags().Lookup(name)
+	layout, ok := timeLayout(flag)
+	if !ok || flag.DefValue == "" {
+		return
+	}
+	loc := timeZone(flag)
+	if t, err := parseTime(flag.DefValue, layout, loc); err == nil {
+		flag.DefValue = formatTime(t, layout, loc)
+		_ = flag.Value.Set(flag.DefValue)
+	}
+}
+
+// timeLayout returns the layout the input flag parses and renders its time in, if any.
+func timeLayout(f *pflag.Flag) (string, bool) {
+	if layout := f.Annotations[FlagLayoutAnnotation]; len(layout) > 0 {
+		return layout[0], true
+	}
+
+	return "", false
+}
+
+// timeZone returns the time zone the input flag parses and renders its time in, or nil when it has none.
+func timeZone(f *pflag.Flag) *time.Location {
+	if zone := f.Annotations[FlagZoneAnnotation]; len(zone) > 0 {
+		if loc, err := time.LoadLocation(zone[0]); err == nil {
+			return loc
+		}
+	}
+
+	return nil
+}
+
+// layoutTimes pairs the string settings of the time.Time flags with the layouts and the time zones of such flags, for StringToTimeHookFunc.
+func layoutTimes(c *cobra.Command, settings map[string]interface{}) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		layout, ok := timeLayout(f)
+		if !ok {
+			return
+		}
+		loc := timeZone(f)
+		visitSettings(c, settings, f.Name, func(m map[string]interface{}, key string) {
+			if val, ok := m[key].(string); ok {
+				m[key] = layoutTime{value: val, layout: layout, loc: loc}
+			}
+		})
+	})
+}
+
+// parseTime parses the input string with the given layout, the empty string being the zero time.
+//
+// A non-nil time zone is the one of the inputs lacking an offset, and the one the result is in.
+func parseTime(input, layout string, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, nil
+	}
+	var t time.Time
+	var err error
+	if loc != nil {
+		t, err = time.ParseInLocation(layout, input, loc)
+		t = t.In(loc)
+	} else {
+		t, err = time.Parse(layout, input)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: must have layout %s", input, layout)
+	}
+
+	return t, nil
+}
+
+// formatTime renders the input time with the given layout, in the given time zone if not nil, the zero time being the empty string.
+func formatTime(t time.Time, layout string, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+
+	return t.Format(layout)
+}
+
+// StringToTimeHookFunc decodes strings, and unix timestamps (in seconds), into time.Time.
+//
+// Strings are parsed with the layout of their flag, if any, or as RFC3339 otherwise.
+// Config files can thus set time.Time fields either way (eg., "2024-03-01" with layout 2006-01-02, or 1709251200).
+func StringToTimeHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if t != timeType {
+			return data, nil
+		}
+		if val, ok := data.(layoutTime); ok {
+			return parseTime(val.value, val.layout, val.loc)
+		}
+		v := reflect.ValueOf(data)
+		switch f.Kind() {
+		case reflect.String:
+			return parseTime(v.String(), time.RFC3339, nil)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return time.Unix(v.Int(), 0).UTC(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return time.Unix(int64(v.Uint()), 0).UTC(), nil
+		case reflect.Float32, reflect.Float64:
+			sec, frac := math.Modf(v.Float())
+
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		}
+
+		return data, nil
+	}
+}
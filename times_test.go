@@ -0,0 +1,91 @@
+package autoflags
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type timeOptions struct {
+	fixture
+
+	Since   time.Time `flaglayout:"2006-01-02" flagenv:"true"`
+	Until   time.Time `flagdescr:"the end"`
+	Created time.Time `default:"2024-01-02T03:04:05Z"`
+}
+
+type invalidLayoutOptions struct {
+	fixture
+
+	Day string `flaglayout:"2006-01-02"`
+}
+
+func (suite *UnmarshalSuite) TestTimes() {
+	suite.T().Setenv("SINCE", "2024-03-01")
+	suite.useConfigFile("config.yaml", "until: 1709251200\n")
+
+	c := &cobra.Command{Use: "times"}
+	opts := &timeOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("time", c.Flags().Lookup("since").Value.Type())
+	suite.Contains(c.UsageString(), "--until time")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), opts.Since)
+	suite.Equal(time.Unix(1709251200, 0).UTC(), opts.Until)
+	suite.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), opts.Created)
+	field, ok := fieldValue(c, "since")
+	suite.Require().True(ok)
+	suite.Equal("2024-03-01", flagValue(c.Flags().Lookup("since"), field))
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--since", "2024-04-01", "--until", "2024-05-01T10:00:00+02:00"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), opts.Since)
+	suite.True(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC).Equal(opts.Until))
+
+	suite.EqualError(c.Flags().Parse([]string{"--since", "01/04/2024"}), `invalid argument "01/04/2024" for "--since" flag: invalid time "01/04/2024": must have layout 2006-01-02`)
+	suite.EqualError(Define(&cobra.Command{Use: "invalid"}, &invalidLayoutOptions{}), "invalid flaglayout tag on day: only time.Time fields can have it")
+}
+
+type zonedOptions struct {
+	fixture
+
+	At time.Time `flaglayout:"2006-01-02 15:04" flagzone:"Europe/Rome" default:"2024-01-02 10:00"`
+}
+
+type invalidZoneOptions struct {
+	fixture
+
+	At time.Time `flagzone:"Mars/Olympus"`
+}
+
+func (suite *UnmarshalSuite) TestTimeZones() {
+	rome, err := time.LoadLocation("Europe/Rome")
+	suite.Require().Nil(err)
+
+	c := &cobra.Command{Use: "zones"}
+	opts := &zonedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Contains(c.UsageString(), "--at time    (default 2024-01-02 10:00)")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.True(time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC).Equal(opts.At))
+	suite.Equal(rome, opts.At.Location())
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--at", "2024-07-01 12:00"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.True(time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC).Equal(opts.At))
+	line, err := Invocation(c, nil)
+	suite.Require().Nil(err)
+	suite.Equal("zones --at='2024-07-01 12:00'", line)
+
+	// The values from elsewhere render in the time zone of the flag too
+	suite.useConfigFile("config.yaml", "at: 1719828000\n")
+	c = &cobra.Command{Use: "zones"}
+	opts = &zonedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	field, ok := fieldValue(c, "at")
+	suite.Require().True(ok)
+	suite.Equal("2024-07-01 12:00", flagValue(c.Flags().Lookup("at"), field))
+
+	suite.EqualError(Define(&cobra.Command{Use: "invalid"}, &invalidZoneOptions{}), `invalid flagzone tag on at: unknown time zone "Mars/Olympus"`)
+}
@@ -0,0 +1,75 @@
+package autoflags
+
+import (
+	"fmt"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+)
+
+// VirtualRootAnnotation marks the commands acting as the root of the subtree they head.
+const VirtualRootAnnotation = "___virtualroot"
+
+// MarkVirtualRoot makes the input command act as a root command, for the command trees embedded inside other CLIs.
+//
+// The functions requiring a root command (eg., Setup, SetupDebug) accept it, so that the config and debugging flags live on its subtree.
+// The commands looking for their root (eg., the ones by NewEnvBlockCmd and NewConfigValidateCmd) stop at it.
+func MarkVirtualRoot(c *cobra.Command) {
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
+	}
+	c.Annotations[VirtualRootAnnotation] = "true"
+}
+
+// isRoot tells whether the input command is a root command, or a virtual one.
+func isRoot(c *cobra.Command) bool {
+	return !c.HasParent() || c.Annotations[VirtualRootAnnotation] == "true"
+}
+
+// rootOf returns the nearest virtual root among the input command and its ancestors, or the root command.
+func rootOf(c *cobra.Command) *cobra.Command {
+	for ; !isRoot(c); c = c.Parent() {
+	}
+
+	return c
+}
+
+// AttachTree walks the command tree starting at root and calls Define for every command having options registered.
+//
+// Commands are visited parent first, so that parents get their flags before their children.
+// It errors, without defining anything, when a registered command is not part of the tree.
+func AttachTree(root *cobra.Command, registry map[*cobra.Command]options.Options, defineOpts ...DefineOption) error {
+	tree := walkTree(root)
+
+	inTree := map[*cobra.Command]bool{}
+	for _, c := range tree {
+		inTree[c] = true
+	}
+	for c := range registry {
+		if !inTree[c] {
+			return fmt.Errorf("command %s is not part of the %s command tree", c.Name(), root.Name())
+		}
+	}
+
+	for _, c := range tree {
+		o, ok := registry[c]
+		if !ok {
+			continue
+		}
+		if err := Define(c, o, defineOpts...); err != nil {
+			return fmt.Errorf("couldn't attach options to %s: %w", c.CommandPath(), err)
+		}
+	}
+
+	return nil
+}
+
+// walkTree returns the commands of the tree rooted at the input command, parents first.
+func walkTree(c *cobra.Command) []*cobra.Command {
+	res := []*cobra.Command{c}
+	for _, sub := range c.Commands() {
+		res = append(res, walkTree(sub)...)
+	}
+
+	return res
+}
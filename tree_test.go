@@ -0,0 +1,32 @@
+package autoflags
+
+import (
+	"bytes"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestVirtualRoot() {
+	hostRan := false
+	host := &cobra.Command{Use: "host", PersistentPreRun: func(c *cobra.Command, args []string) {
+		hostRan = true
+	}}
+	tool := &cobra.Command{Use: "tool"}
+	sub := &cobra.Command{Use: "serve", Run: func(c *cobra.Command, args []string) {}}
+	host.AddCommand(tool)
+	tool.AddCommand(sub)
+	suite.Require().Nil(Define(sub, &dryRunOptions{}))
+	suite.EqualError(SetupDebug(tool, DebugOptions{}), "SetupDebug must be called on the root command")
+
+	MarkVirtualRoot(tool)
+	suite.Equal(tool, rootOf(sub))
+	suite.Require().Nil(SetupDebug(tool, DebugOptions{}))
+	suite.Nil(host.PersistentFlags().Lookup(DefaultDryRunFlag))
+
+	out := &bytes.Buffer{}
+	host.SetOut(out)
+	host.SetArgs([]string{"tool", "serve", "--dry-run-config", "--verbose"})
+	suite.Require().Nil(host.Execute())
+	suite.True(hostRan, "the hook of the host still runs")
+	suite.Equal("host tool serve --verbose\n", out.String())
+}
@@ -0,0 +1,182 @@
+package autoflags
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
+)
+
+// tuple describes how to parse a key-value string into a struct, as per the flagtuple tag.
+//
+// For example, `flagtuple:"Name=Value"` parses "x-api-key=secret" into the Name and the Value fields of the struct.
+type tuple struct {
+	typ   reflect.Type
+	key   string
+	sep   string
+	value string
+}
+
+// parseTuple parses the flagtuple tag for the input struct type.
+func parseTuple(tag string, typ reflect.Type) (*tuple, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("only slices of structs can be tuples")
+	}
+	isIdent := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+	}
+	start := strings.IndexFunc(tag, func(r rune) bool { return !isIdent(r) })
+	if start <= 0 {
+		return nil, fmt.Errorf("missing separator in %q", tag)
+	}
+	end := strings.IndexFunc(tag[start:], isIdent)
+	if end < 0 {
+		return nil, fmt.Errorf("missing value field in %q", tag)
+	}
+	res := &tuple{typ: typ, key: tag[:start], sep: tag[start : start+end], value: tag[start+end:]}
+	for _, name := range []string{res.key, res.value} {
+		if _, ok := typ.FieldByName(name); !ok {
+			return nil, fmt.Errorf("%s has no field %s", typ.String(), name)
+		}
+	}
+
+	return res, nil
+}
+
+// name identifies the tuple in the decode hooks registry.
+func (t *tuple) name() string {
+	return fmt.Sprintf("StringToTupleHookFunc[%s:%s%s%s]", t.typ.String(), t.key, t.sep, t.value)
+}
+
+// parse parses a single key-value string into a new struct value.
+func (t *tuple) parse(input string) (reflect.Value, error) {
+	key, val, ok := strings.Cut(input, t.sep)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("invalid value %q: expected format %s%s%s", input, strings.ToLower(t.key), t.sep, strings.ToLower(t.value))
+	}
+	out := reflect.New(t.typ)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           out.Interface(),
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if err := decoder.Decode(map[string]interface{}{t.key: key, t.value: val}); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid value %q: %w", input, err)
+	}
+
+	return out.Elem(), nil
+}
+
+// parseList parses a comma-separated list of key-value strings into a new slice.
+//
+// Entries containing commas must be quoted, as in CSV.
+func (t *tuple) parseList(input string) (reflect.Value, error) {
+	res := reflect.MakeSlice(reflect.SliceOf(t.typ), 0, 0)
+	if strings.TrimSpace(input) == "" {
+		return res, nil
+	}
+	entries, err := csv.NewReader(strings.NewReader(input)).Read()
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid value %q: %w", input, err)
+	}
+	for _, entry := range entries {
+		elem, err := t.parse(entry)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		res = reflect.Append(res, elem)
+	}
+
+	return res, nil
+}
+
+// format turns the input struct value into its key-value string.
+func (t *tuple) format(elem reflect.Value) string {
+	return fmt.Sprint(elem.FieldByName(t.key).Interface()) + t.sep + fmt.Sprint(elem.FieldByName(t.value).Interface())
+}
+
+// hook decodes key-value strings into the tuple structs, and comma-separated lists of them into slices.
+//
+// It covers command line flags, environment variables, and config arrays of strings.
+// Config arrays of maps decode as any other struct.
+func (t *tuple) hook() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		switch {
+		case to == t.typ:
+			res, err := t.parse(reflect.ValueOf(data).String())
+			if err != nil {
+				return nil, err
+			}
+
+			return res.Interface(), nil
+		case to.Kind() == reflect.Slice && to.Elem() == t.typ:
+			res, err := t.parseList(reflect.ValueOf(data).String())
+			if err != nil {
+				return nil, err
+			}
+
+			return res.Interface(), nil
+		}
+
+		return data, nil
+	}
+}
+
+// tupleSliceValue is a repeatable flag appending a struct to a slice for each key-value string it gets.
+type tupleSliceValue struct {
+	ref     reflect.Value
+	tuple   *tuple
+	changed bool
+}
+
+var _ pflag.Value = (*tupleSliceValue)(nil)
+
+func newTupleSliceValue(ref reflect.Value, t *tuple) *tupleSliceValue {
+	return &tupleSliceValue{ref: ref, tuple: t}
+}
+
+func (v *tupleSliceValue) Set(input string) error {
+	elem, err := v.tuple.parse(input)
+	if err != nil {
+		return err
+	}
+	// The first occurrence on the command line replaces the defaults
+	if !v.changed {
+		v.ref.Set(reflect.MakeSlice(v.ref.Type(), 0, 0))
+		v.changed = true
+	}
+	v.ref.Set(reflect.Append(v.ref, elem))
+
+	return nil
+}
+
+func (v *tupleSliceValue) bind(field reflect.Value) pflag.Value {
+	return newTupleSliceValue(field, v.tuple)
+}
+
+func (v *tupleSliceValue) Type() string {
+	return strings.ToLower(v.tuple.key) + v.tuple.sep + strings.ToLower(v.tuple.value)
+}
+
+func (v *tupleSliceValue) String() string {
+	entries := make([]string, v.ref.Len())
+	for i := 0; i < v.ref.Len(); i++ {
+		entries[i] = v.tuple.format(v.ref.Index(i))
+	}
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+	_ = w.Write(entries)
+	w.Flush()
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,64 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestTupleFlags() {
+	c := &cobra.Command{Use: "proxy"}
+	opts := &proxyOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("name=value", c.Flags().Lookup("headers").Value.Type())
+	suite.Require().Nil(c.Flags().Parse([]string{"--headers", "accept=a,b", "--headers", "x-retries=3"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]header{{Name: "accept", Value: "a,b"}, {Name: "x-retries", Value: "3"}}, opts.Headers)
+	suite.Equal(`"accept=a,b",x-retries=3`, c.Flags().Lookup("headers").Value.String())
+	suite.ErrorContains(c.Flags().Parse([]string{"--headers", "accept"}), `invalid value "accept": expected format name=value`)
+}
+
+func (suite *UnmarshalSuite) TestTupleFlagsFromConfigAndEnv() {
+	suite.useConfigFile("config.yaml", "headers:\n  - accept=json\n  - name: x-retries\n    value: 3\n")
+
+	c := &cobra.Command{Use: "proxy"}
+	opts := &proxyOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]header{{Name: "accept", Value: "json"}, {Name: "x-retries", Value: "3"}}, opts.Headers)
+
+	suite.T().Setenv("HEADERS", `accept=json,"x-list=a,b"`)
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]header{{Name: "accept", Value: "json"}, {Name: "x-list", Value: "a,b"}}, opts.Headers)
+
+	// The tuple decode hooks belong to the commands, blueprint clones included, rather than to the registry
+	name := c.Flags().Lookup("headers").Annotations[FlagDecodeHookAnnotation][0]
+	_, ok := decodeHookRegistry[name]
+	suite.False(ok)
+	blueprint, err := NewBlueprint(&proxyOptions{})
+	suite.Require().Nil(err)
+	clone, cloneOpts := &cobra.Command{Use: "clone"}, &proxyOptions{}
+	suite.Require().Nil(blueprint.Define(clone, cloneOpts))
+	suite.Require().Nil(Unmarshal(clone, cloneOpts))
+	suite.Equal(opts.Headers, cloneOpts.Headers)
+}
+
+func (suite *UnmarshalSuite) TestTupleFlagsInvalidTag() {
+	c := &cobra.Command{Use: "proxy"}
+	suite.ErrorContains(Define(c, &invalidTupleOptions{}), "invalid flagtuple tag on headers: autoflags.header has no field Key")
+}
+
+type header struct {
+	Name  string
+	Value string
+}
+
+type proxyOptions struct {
+	fixture
+
+	Headers []header `flagtuple:"Name=Value" flagenv:"true"`
+}
+
+type invalidTupleOptions struct {
+	fixture
+
+	Headers []header `flagtuple:"Key=Value"`
+}
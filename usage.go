@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"golang.org/x/exp/maps"
 )
 
@@ -68,31 +69,151 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 `
 )
 
-// setUsage generates the flag usages of the flags local to the input command.
+// UsageFlag is a flag along with the metadata autoflags knows about it.
+type UsageFlag struct {
+	*pflag.Flag
+	// Group is the name of the group of the flag, empty for the flags without group
+	Group string
+	// Envs lists the environment variables bound to the flag
+	Envs []string
+	// Required tells whether the flag is mandatory
+	Required bool
+	// Secret tells whether the value of the flag is sensitive
+	Secret bool
+}
+
+// UsageGroup is a group of flags, as shown by the usage message.
+type UsageGroup struct {
+	// Name is the name of the group, empty for the local flags without group
+	Name string
+	// Gate is the name of the bool flag enabling the group, if any
+	Gate string
+	// Collapsed tells whether the group is disabled by default, so that only its gate is worth showing
+	Collapsed bool
+	// Flags lists the flags of the group, sorted by name
+	Flags []UsageFlag
+}
+
+// FlagSet returns a new flag set containing the flags of the group.
+func (g UsageGroup) FlagSet() *pflag.FlagSet {
+	res := pflag.NewFlagSet(g.Name, pflag.ContinueOnError)
+	for _, f := range g.Flags {
+		res.AddFlag(f.Flag)
+	}
+
+	return res
+}
+
+// Renderer renders the flags section of the usage message of a command.
 //
-// It also groups the flags by the FlagGroupAnnotation annotation.
-func setUsage(c *cobra.Command) {
-	groups := Groups(c)
+// The groups come with the local flags without group first, followed by the other groups sorted by name.
+// The rest of the usage message (ie., commands, global flags) stays the cobra one.
+type Renderer interface {
+	Render(c *cobra.Command, groups []UsageGroup) string
+}
 
+// RendererFunc is an adapter to use ordinary functions as renderers.
+type RendererFunc func(c *cobra.Command, groups []UsageGroup) string
+
+// Render calls r(c, groups).
+func (r RendererFunc) Render(c *cobra.Command, groups []UsageGroup) string {
+	return r(c, groups)
+}
+
+// DefaultRenderer renders each group under its own "<Name> Flags:" header, as pflag formats the flags.
+//
+// Collapsed groups only show their gate, in an "<Name> Flags (enable with --<gate>):" section.
+var DefaultRenderer Renderer = RendererFunc(func(c *cobra.Command, groups []UsageGroup) string {
 	usages := ""
+	for _, group := range groups {
+		if usages != "" {
+			usages += "\n"
+		}
+		switch {
+		case group.Name == "":
+			usages += "Flags:\n"
+		case group.Collapsed:
+			usages += fmt.Sprintf("%s Flags (enable with --%s):\n", group.Name, group.Gate)
+			// Show the gate only
+			for _, f := range group.Flags {
+				if f.Name == group.Gate {
+					usages += UsageGroup{Flags: []UsageFlag{f}}.FlagSet().FlagUsages()
+				}
+			}
+
+			continue
+		default:
+			usages += fmt.Sprintf("%s Flags:\n", group.Name)
+		}
+		usages += group.FlagSet().FlagUsages()
+	}
+
+	return strings.TrimSuffix(usages, "\n")
+})
+
+var renderer = DefaultRenderer
+
+// SetRenderer changes how the flags section of the usage messages looks like.
+//
+// It must be called before Define, that generates the usage messages.
+// A nil renderer restores DefaultRenderer.
+func SetRenderer(r Renderer) {
+	if r == nil {
+		r = DefaultRenderer
+	}
+	renderer = r
+}
+
+// usageGroups collects the groups of the flags local to the input command, along with their metadata.
+//
+// Hidden flags are left out, and so are the groups containing only hidden flags.
+func usageGroups(c *cobra.Command) []UsageGroup {
+	groups := Groups(c)
+	res := []UsageGroup{}
+	add := func(name string, flags *pflag.FlagSet) {
+		group := UsageGroup{Name: name}
+		if gate, ok := gateOf(c, name); ok && name != "" {
+			group.Gate = gate
+			if f := c.Flags().Lookup(gate); f != nil && f.DefValue != "true" {
+				group.Collapsed = true
+			}
+		}
+		flags.VisitAll(func(f *pflag.Flag) {
+			if f.Hidden {
+				return
+			}
+			_, required := f.Annotations[cobra.BashCompOneRequiredFlag]
+			group.Flags = append(group.Flags, UsageFlag{
+				Flag:     f,
+				Group:    name,
+				Envs:     f.Annotations[FlagEnvsAnnotation],
+				Required: required,
+				Secret:   isSecretFlag(f),
+			})
+		})
+		if len(group.Flags) > 0 {
+			res = append(res, group)
+		}
+	}
+
 	if lFlags, ok := groups[localGroupID]; ok {
-		usages += "Flags:\n"
-		usages += lFlags.FlagUsages()
+		add("", lFlags)
 		delete(groups, localGroupID)
 	}
-
 	groupKeys := maps.Keys(groups)
 	sort.Strings(groupKeys)
-
 	for _, group := range groupKeys {
-		flags := groups[group]
-		if usages != "" {
-			usages += "\n"
-		}
-		usages += fmt.Sprintf("%s Flags:\n", group)
-		usages += flags.FlagUsages()
+		add(group, groups[group])
 	}
-	usages = strings.TrimSuffix(usages, "\n")
+
+	return res
+}
+
+// setUsage generates the usage message of the input command, rendering its local flags with the current renderer.
+//
+// The flags are grouped by the FlagGroupAnnotation annotation.
+func setUsage(c *cobra.Command) {
+	usages := renderer.Render(c, usageGroups(c))
 
 	s := fmt.Sprintf(usageTemplate, usages)
 	if usages == "" {
@@ -0,0 +1,27 @@
+package autoflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestUsageHook() {
+	reports := []FlagUsage{}
+	SetUsageHook(func(u FlagUsage) {
+		reports = append(reports, u)
+	})
+	suite.T().Cleanup(func() {
+		SetUsageHook(nil)
+	})
+
+	c := &cobra.Command{Use: "telemetry"}
+	opts := &telemetryOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(c.Flags().Parse([]string{"--workers", "4", "--token", "x", "--other", "y"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal([]FlagUsage{{
+		Command: "telemetry",
+		Flags:   []string{"other", "token", "workers"},
+		Values:  map[string]interface{}{"workers": int64(4)},
+	}}, reports)
+}
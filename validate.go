@@ -0,0 +1,355 @@
+package autoflags
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// UnknownConfigKey is a key of the config file no flag reads.
+type UnknownConfigKey struct {
+	Key string `json:"key"`
+	// Suggestion is the closest key flags read, if any
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// MissingValue is a required flag no source sets.
+type MissingValue struct {
+	Command   string   `json:"command"`
+	Flag      string   `json:"flag"`
+	Envs      []string `json:"envs,omitempty"`
+	ConfigKey string   `json:"config_key"`
+}
+
+// AmbiguousConfigKey is a value the config file sets more than once, via the aliases of the same config key.
+type AmbiguousConfigKey struct {
+	Command string   `json:"command"`
+	Flag    string   `json:"flag"`
+	Keys    []string `json:"keys"`
+}
+
+// ConfigReport is the outcome of the validation of the config file against a command tree.
+type ConfigReport struct {
+	File    string             `json:"file,omitempty"`
+	Unknown []UnknownConfigKey `json:"unknown"`
+	Missing []MissingValue     `json:"missing"`
+	// Ambiguous lists the values set via more than one alias of their config keys, the first of which wins
+	Ambiguous []AmbiguousConfigKey `json:"ambiguous,omitempty"`
+}
+
+// OK tells whether the config file has no unknown keys, no ambiguous values, and no required value is missing.
+func (r *ConfigReport) OK() bool {
+	return len(r.Unknown) == 0 && len(r.Missing) == 0 && len(r.Ambiguous) == 0
+}
+
+// JSON encodes the report.
+func (r *ConfigReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *ConfigReport) String() string {
+	if r.OK() {
+		return "config is valid\n"
+	}
+	var b strings.Builder
+	for _, u := range r.Unknown {
+		fmt.Fprintf(&b, "unknown key %q", u.Key)
+		if u.Suggestion != "" {
+			fmt.Fprintf(&b, " (did you mean %q?)", u.Suggestion)
+		}
+		b.WriteString("\n")
+	}
+	for _, m := range r.Missing {
+		fmt.Fprintf(&b, "%s: missing required value for --%s (set the %q config key", m.Command, m.Flag, m.ConfigKey)
+		if len(m.Envs) > 0 {
+			fmt.Fprintf(&b, " or the %s env", strings.Join(m.Envs, ", "))
+		}
+		b.WriteString(")\n")
+	}
+	for _, a := range r.Ambiguous {
+		fmt.Fprintf(&b, "%s: --%s is set by more than one key (%s), %q wins\n", a.Command, a.Flag, strings.Join(a.Keys, ", "), a.Keys[0])
+	}
+
+	return b.String()
+}
+
+// ValidateConfig cross-references the loaded config file with the flags of the command tree rooted at the input command.
+//
+// It reports, in a single report:
+//   - the config keys no flag reads, along with the closest flag alias or struct path
+//   - the required flags no source (ie., flag, env, config) sets
+//   - the values set via more than one of the keys of their flag (see ConfigKeyAliases)
+//
+// Call it once every command has been defined and the config file loaded (eg., via UseConfig).
+func ValidateConfig(root *cobra.Command) *ConfigReport {
+	return validateConfig(root, configSettings, MaskPath(viper.ConfigFileUsed()), func(c *cobra.Command, f *pflag.Flag, provenance map[string]Source) bool {
+		return provenance[f.Name] != SourceDefault
+	})
+}
+
+// ValidateConfigContent validates the input config content against the command tree rooted at the input command, as ValidateConfig does.
+//
+// The format is any viper supports (eg., yaml, json, toml).
+// Only the content counts as a source: required flags it does not set are missing, whatever the flags or the environment of the process.
+func ValidateConfigContent(root *cobra.Command, content []byte, format string) (*ConfigReport, error) {
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("couldn't parse the config: %w", err)
+	}
+	settings, _, _ := splitContexts(v.AllSettings())
+
+	return validateConfig(root, settings, "", func(c *cobra.Command, f *pflag.Flag, _ map[string]Source) bool {
+		_, _, ok := lookupFlagSetting(c, f, settings)
+
+		return ok
+	}), nil
+}
+
+// validateConfig validates the input settings, using isSet to tell whether the required flags have a value.
+func validateConfig(root *cobra.Command, settings map[string]interface{}, file string, isSet func(*cobra.Command, *pflag.Flag, map[string]Source) bool) *ConfigReport {
+	res := &ConfigReport{
+		File:    file,
+		Unknown: []UnknownConfigKey{},
+		Missing: []MissingValue{},
+	}
+
+	known := map[string]bool{}
+	maps := map[string]bool{}
+	ambiguous := map[string]bool{}
+	for _, c := range walkTree(root) {
+		s, ok := scopes[c]
+		if !ok {
+			continue
+		}
+		for path, name := range s.paths {
+			if path != name && s.aliases[path] != name {
+				path = name
+			}
+			known[path] = true
+			known[name] = true
+			if typ, ok := s.types[name]; ok && typ.Kind() == reflect.Map {
+				maps[path] = true
+				maps[name] = true
+			}
+		}
+
+		provenance, _ := Provenance(c)
+		c.Flags().VisitAll(func(f *pflag.Flag) {
+			known[f.Name] = true
+			if keys := ambiguousKeys(c, f, settings); keys != nil && !ambiguous[strings.Join(keys, ",")] {
+				ambiguous[strings.Join(keys, ",")] = true
+				res.Ambiguous = append(res.Ambiguous, AmbiguousConfigKey{Command: c.CommandPath(), Flag: f.Name, Keys: keys})
+			}
+			if _, required := f.Annotations[cobra.BashCompOneRequiredFlag]; !required {
+				return
+			}
+			if isSet(c, f, provenance) {
+				return
+			}
+			key := f.Name
+			if path, ok := s.flagPath(f.Name); ok {
+				key = path
+			}
+			res.Missing = append(res.Missing, MissingValue{
+				Command:   c.CommandPath(),
+				Flag:      f.Name,
+				Envs:      boundEnvs(f),
+				ConfigKey: key,
+			})
+		})
+	}
+
+	candidates := make([]string, 0, len(known))
+	for key := range known {
+		candidates = append(candidates, key)
+	}
+	sort.Strings(candidates)
+	for _, key := range configKeys(settings, "", maps) {
+		if known[key] || key == HiddenCommandsKey || key == DisabledCommandsKey {
+			continue
+		}
+		res.Unknown = append(res.Unknown, UnknownConfigKey{Key: key, Suggestion: suggestKey(key, candidates)})
+	}
+
+	return res
+}
+
+// configKeys returns the dotted keys of the leaves of the input settings, sorted.
+//
+// The maps read by map flags are leaves.
+func configKeys(settings map[string]interface{}, prefix string, maps map[string]bool) []string {
+	res := []string{}
+	for key, val := range settings {
+		full := prefix + strings.ToLower(key)
+		if nested, ok := val.(map[string]interface{}); ok && !maps[full] {
+			res = append(res, configKeys(nested, full+".", maps)...)
+
+			continue
+		}
+		res = append(res, full)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// suggestKey returns the candidate closest to the input key, if close enough.
+//
+// Besides the whole key, it compares its last segment, so that nested keys match the flag aliases (eg., "server.port" suggests "port").
+func suggestKey(key string, candidates []string) string {
+	best, bestDistance := "", -1
+	parts := strings.Split(key, ".")
+	last := parts[len(parts)-1]
+	for _, candidate := range candidates {
+		d := levenshtein(key, candidate)
+		if dl := levenshtein(last, candidate); dl < d {
+			d = dl
+		}
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if bestDistance < 0 || bestDistance > len(last)/3+1 {
+		return ""
+	}
+
+	return best
+}
+
+// levenshtein returns the edit distance between the input strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+// NewConfigValidateCmd returns a "validate" command printing the ValidateConfig report of its root command.
+//
+// Add it under the config command of the application (eg., "app config validate").
+// It errors when the report is not OK.
+func NewConfigValidateCmd() *cobra.Command {
+	asJSON := false
+	c := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the config file against the available flags",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			report := ValidateConfig(rootOf(c))
+			if asJSON {
+				data, err := report.JSON()
+				if err != nil {
+					return err
+				}
+				c.Println(string(data))
+			} else {
+				c.Print(report.String())
+			}
+			if !report.OK() {
+				if len(report.Ambiguous) > 0 {
+					return fmt.Errorf("invalid config: %d unknown keys, %d missing values, %d ambiguous values", len(report.Unknown), len(report.Missing), len(report.Ambiguous))
+				}
+
+				return fmt.Errorf("invalid config: %d unknown keys, %d missing values", len(report.Unknown), len(report.Missing))
+			}
+
+			return nil
+		},
+	}
+	c.Flags().BoolVar(&asJSON, "json", false, "output the report in JSON form")
+
+	return c
+}
+
+// maxValidateBody is the size limit of the config documents the validation handler accepts.
+const maxValidateBody = 10 << 20
+
+// NewValidateHandler returns an HTTP handler serving "POST /validate" for the command tree rooted at the input command.
+//
+// The request body is the config document, whose format comes from the "format" query parameter
+// or from the content type (eg., application/json), defaulting to YAML.
+// The response is the JSON report of ValidateConfigContent, with status 200 when OK and 422 otherwise.
+// Unparsable documents get status 400 and a JSON object with the "error" key.
+func NewValidateHandler(root *cobra.Command) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+
+			return
+		}
+		content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValidateBody))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+
+			return
+		}
+		report, err := ValidateConfigContent(root, content, requestFormat(r))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+
+			return
+		}
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusUnprocessableEntity
+		}
+		writeJSON(w, status, report)
+	})
+
+	return mux
+}
+
+// requestFormat returns the config format of the input validation request.
+func requestFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return "json"
+	case "application/toml":
+		return "toml"
+	}
+
+	return "yaml"
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,91 @@
+package autoflags
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (suite *UnmarshalSuite) TestValidateConfig() {
+	suite.useConfigFile("config.yaml", "servr:\n  port: 80\nhosts: [a]\nlabels:\n  team: core\ntimeuot: 1s\nzzz: 1\n")
+
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "serve"}
+	root.AddCommand(sub)
+	suite.Require().Nil(Define(root, &labelsOptions{}))
+	suite.Require().Nil(Define(sub, &validateOptions{}))
+
+	report := ValidateConfig(root)
+	suite.False(report.OK())
+	suite.Equal([]UnknownConfigKey{
+		{Key: "servr.port", Suggestion: "server.port"},
+		{Key: "timeuot", Suggestion: "timeout"},
+		{Key: "zzz"},
+	}, report.Unknown)
+	suite.Equal([]MissingValue{{Command: "app serve", Flag: "token", Envs: []string{"TOKEN"}, ConfigKey: "token"}}, report.Missing)
+	suite.Contains(report.String(), `unknown key "timeuot" (did you mean "timeout"?)`)
+	suite.Contains(report.String(), `app serve: missing required value for --token (set the "token" config key or the TOKEN env)`)
+
+	validate := NewConfigValidateCmd()
+	root.AddCommand(validate)
+	out := &bytes.Buffer{}
+	root.SetOut(out)
+	root.SetErr(out)
+	root.SetArgs([]string{"validate", "--json"})
+	suite.ErrorContains(root.Execute(), "invalid config: 3 unknown keys, 1 missing values")
+	suite.Contains(out.String(), `{"key":"zzz"}`)
+}
+
+func (suite *UnmarshalSuite) TestValidateHandler() {
+	suite.T().Setenv("TOKEN", "from-env")
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "serve"}
+	root.AddCommand(sub)
+	suite.Require().Nil(Define(sub, &validateOptions{}))
+	server := httptest.NewServer(NewValidateHandler(root))
+	defer server.Close()
+
+	post := func(query, contentType, body string) (int, string) {
+		res, err := http.Post(server.URL+"/validate"+query, contentType, strings.NewReader(body))
+		suite.Require().Nil(err)
+		defer res.Body.Close()
+		data, err := io.ReadAll(res.Body)
+		suite.Require().Nil(err)
+
+		return res.StatusCode, string(data)
+	}
+
+	status, body := post("", "application/json", `{"token": "x", "timeout": "1s"}`)
+	suite.Equal(http.StatusOK, status)
+	suite.JSONEq(`{"unknown": [], "missing": []}`, body)
+
+	// The environment of the server does not count
+	status, body = post("?format=toml", "text/plain", "timeuot = \"1s\"\n")
+	suite.Equal(http.StatusUnprocessableEntity, status)
+	suite.JSONEq(`{"unknown": [{"key": "timeuot", "suggestion": "timeout"}], "missing": [{"command": "app serve", "flag": "token", "envs": ["TOKEN"], "config_key": "token"}]}`, body)
+
+	status, body = post("", "", "{")
+	suite.Equal(http.StatusBadRequest, status)
+	suite.Contains(body, "couldn't parse the config")
+
+	res, err := http.Get(server.URL + "/validate")
+	suite.Require().Nil(err)
+	res.Body.Close()
+	suite.Equal(http.StatusMethodNotAllowed, res.StatusCode)
+}
+
+type validateOptions struct {
+	fixture
+
+	Token   string `flagenv:"true" flagrequired:"true"`
+	Timeout time.Duration
+	Hosts   []string
+	Server  struct {
+		Port int
+	}
+}
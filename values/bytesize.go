@@ -0,0 +1,116 @@
+package values
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes that users can write in a human-readable form (eg., "512MiB", "10MB").
+//
+// It fits options like buffer sizes or upload limits.
+type ByteSize int64
+
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+)
+
+// byteUnits are the units ByteSize accepts, by their lowercase symbol.
+var byteUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"k":   KB,
+	"kb":  KB,
+	"m":   MB,
+	"mb":  MB,
+	"g":   GB,
+	"gb":  GB,
+	"t":   TB,
+	"tb":  TB,
+	"p":   PB,
+	"pb":  PB,
+	"kib": KiB,
+	"mib": MiB,
+	"gib": GiB,
+	"tib": TiB,
+	"pib": PiB,
+}
+
+// ParseByteSize parses the input string into a ByteSize.
+//
+// It accepts a number, possibly with a fractional part, followed by an optional unit (eg., "1.5GiB", "100 kb").
+// Units are case-insensitive: KB, MB, GB, TB, and PB are powers of 1000, while KiB, MiB, GiB, TiB, and PiB are powers of 1024.
+func ParseByteSize(str string) (ByteSize, error) {
+	input := strings.TrimSpace(str)
+	i := strings.IndexFunc(input, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(input)
+	}
+	n, err := strconv.ParseFloat(input[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: must be a number followed by an optional unit", str)
+	}
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(input[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", str, strings.TrimSpace(input[i:]))
+	}
+	size := n * float64(unit)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid byte size %q: out of range", str)
+	}
+
+	return ByteSize(size), nil
+}
+
+// String renders the size in the largest unit holding it exactly, preferring the binary units (eg., 512MiB, 10MB, 1500B).
+//
+// Zero renders as "0", so that the usage messages omit it as a default.
+func (s ByteSize) String() string {
+	if s == 0 {
+		return "0"
+	}
+	for _, u := range []struct {
+		size   ByteSize
+		symbol string
+	}{
+		{PiB, "PiB"}, {TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"},
+		{PB, "PB"}, {TB, "TB"}, {GB, "GB"}, {MB, "MB"}, {KB, "KB"},
+	} {
+		if s%u.size == 0 {
+			return fmt.Sprintf("%d%s", s/u.size, u.symbol)
+		}
+	}
+
+	return fmt.Sprintf("%dB", int64(s))
+}
+
+// Set implements pflag.Value.
+func (s *ByteSize) Set(str string) error {
+	v, err := ParseByteSize(str)
+	if err != nil {
+		return err
+	}
+	*s = v
+
+	return nil
+}
+
+// Type implements pflag.Value.
+func (s *ByteSize) Type() string {
+	return "bytesize"
+}
@@ -0,0 +1,70 @@
+package values
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TriState is a bool-like value that can also be left to automatic detection.
+//
+// It fits options like colors, TTY, or compression.
+// The zero value means Auto.
+type TriState string
+
+const (
+	Auto TriState = "auto"
+	On   TriState = "on"
+	Off  TriState = "off"
+)
+
+// ParseTriState parses the input string into a TriState.
+//
+// Besides "auto", "on", and "off", it accepts the usual bool forms (eg., "true", "no", "1").
+func ParseTriState(str string) (TriState, error) {
+	switch strings.ToLower(strings.TrimSpace(str)) {
+	case "", "auto":
+		return Auto, nil
+	case "on", "true", "yes", "1", "t", "y":
+		return On, nil
+	case "off", "false", "no", "0", "f", "n":
+		return Off, nil
+	}
+
+	return Auto, fmt.Errorf("invalid tristate %q: must be one of auto, on, off", str)
+}
+
+func (t TriState) String() string {
+	if t == "" {
+		return string(Auto)
+	}
+
+	return string(t)
+}
+
+// Set implements pflag.Value.
+func (t *TriState) Set(str string) error {
+	v, err := ParseTriState(str)
+	if err != nil {
+		return err
+	}
+	*t = v
+
+	return nil
+}
+
+// Type implements pflag.Value.
+func (t *TriState) Type() string {
+	return "tristate"
+}
+
+// Resolve turns the TriState into a bool, calling the input function to settle Auto.
+func (t TriState) Resolve(auto func() bool) bool {
+	switch t {
+	case On:
+		return true
+	case Off:
+		return false
+	}
+
+	return auto != nil && auto()
+}
@@ -1,7 +1,10 @@
 package autoflags
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 
 	"github.com/leodido/autoflags/options"
 	"github.com/mitchellh/mapstructure"
@@ -10,41 +13,158 @@ import (
 	"github.com/spf13/viper"
 )
 
-var (
-	vipers map[*cobra.Command]*viper.Viper = map[*cobra.Command]*viper.Viper{}
-)
-
+// Viper returns the viper instance backing the input command.
+//
+// Deprecated: use SettingsOf, that reads the same values along with their sources.
+// Changing the returned instance bypasses the precedence order and the provenance autoflags tracks.
 func Viper(c *cobra.Command) (*viper.Viper, error) {
-	res, ok := vipers[c]
+	s, ok := scopes[c]
 	if !ok {
 		return nil, fmt.Errorf("couldn't find a viper instance for %s", c.Name())
 	}
 
-	return res, nil
+	return s.viper, nil
+}
+
+// OptionsOf returns the options attached to the input command via Define, in definition order.
+//
+// It returns nil when Define was never called on the command.
+func OptionsOf(c *cobra.Command) []any {
+	s, ok := scopes[c]
+	if !ok {
+		return nil
+	}
+	res := make([]any, 0, len(s.options))
+	for _, opts := range s.options {
+		res = append(res, opts)
+	}
+
+	return res
 }
 
+// UnmarshalAll unmarshals the values of the command into every options struct attached to it via Define.
+//
+// Each options struct only picks up the keys matching its own fields.
+// Options attached by value rather than by reference are skipped since they cannot be written to.
+func UnmarshalAll(c *cobra.Command, hooks ...mapstructure.DecodeHookFunc) error {
+	s, ok := scopes[c]
+	if !ok {
+		return fmt.Errorf("couldn't find a viper instance for %s", c.Name())
+	}
+	for _, opts := range s.options {
+		if reflect.TypeOf(opts).Kind() != reflect.Ptr {
+			continue
+		}
+		if err := Unmarshal(c, opts, hooks...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unmarshalMu serializes the unmarshalling, which reads and writes the state of the scopes and the global viper.
+var unmarshalMu sync.Mutex
+
 // NOTE: See https://github.com/spf13/viper/pull/1715
 func Unmarshal(c *cobra.Command, opts options.Options, hooks ...mapstructure.DecodeHookFunc) error {
-	res, err := Viper(c)
-	if err != nil {
+	unmarshalMu.Lock()
+	defer unmarshalMu.Unlock()
+	s, ok := scopes[c]
+	if !ok {
+		return fmt.Errorf("couldn't find a viper instance for %s", c.Name())
+	}
+	res := s.viper
+
+	// Merge the settings of the config file, if any
+	if configSettings != nil {
+		if err := res.MergeConfigMap(configSettings); err != nil {
+			return err
+		}
+	}
+	checkLegacyEnv(c)
+	checkDeprecated(c)
+
+	if err := checkLockdown(c); err != nil {
+		return err
+	}
+
+	// Report invalid environment values before they surface as generic decoding errors
+	if errs := checkWinningEnv(c, hooks...); len(errs) > 0 && !getScope(c).partial {
+		return errors.Join(errs...)
+	}
+
+	if err := checkBareDurations(c); err != nil {
 		return err
 	}
 
 	// Look for decode hook annotation appending them to the list of hooks to use for unmarshalling
 	c.Flags().VisitAll(func(f *pflag.Flag) {
-		if decodeHooks, defineDecodeHooks := f.Annotations[FlagDecodeHookAnnotation]; defineDecodeHooks {
-			for _, decodeHook := range decodeHooks {
-				if decodeHookFunc, ok := decodeHookRegistry[decodeHook]; ok {
-					hooks = append(hooks, decodeHookFunc)
-				}
-			}
-		}
+		hooks = append(hooks, flagDecodeHooks(c, f)...)
+	})
+	hooks = append(hooks, NumberRangeHookFunc(s.saturate))
+
+	// Decode the settings as viper would, after splitting the slices having custom separators
+	settings := res.AllSettings()
+	// Honor the precedence order of the sources
+	resolve(c, settings)
+	mergeCounts(c, settings)
+	if err := resolveKeyring(c, settings); err != nil {
+		return err
+	}
+	if err := resolveSecrets(c, settings); err != nil {
+		return err
+	}
+	unwrapSlices(c, settings)
+	splitSlices(c, settings)
+	appendSlices(c, settings)
+	mergeMaps(c, settings)
+	layoutTimes(c, settings)
+	unaliasSettings(c, settings)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(withDefaultDecodeHooks(hooks)...),
+		WeaklyTypedInput: true,
+		Result:           target(opts),
 	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(settings); err != nil {
+		if !getScope(c).partial {
+			return decodeError(c, err)
+		}
+		// Decode again with the defaults in place of the failing values
+		if err := tolerateDecodeError(c, settings, err); err != nil {
+			return err
+		}
+		if err := decoder.Decode(settings); err != nil {
+			return decodeError(c, err)
+		}
+	}
+	cleanSlices(c, target(opts))
+	setArgs(c, opts)
+	if err := setVirtuals(c, opts); err != nil {
+		return err
+	}
+	recordCounts(c)
+	recordSlices(c)
+	recordMaps(c)
+	getScope(c).env = SnapshotEnv(c)
+	reportUsage(c)
 
-	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
-		hooks...,
-	))
-	if err := res.Unmarshal(opts, decodeHook); err != nil {
+	if err := checkGates(c); err != nil {
+		return err
+	}
+	if err := checkRelations(c); err != nil {
+		return err
+	}
+	if err := checkDurationBounds(c, target(opts)); err != nil {
+		return err
+	}
+	if err := checkChoices(c, target(opts)); err != nil {
+		return err
+	}
+	if err := checkPolicies(c, opts); err != nil {
 		return err
 	}
 
@@ -54,16 +174,15 @@ func Unmarshal(c *cobra.Command, opts options.Options, hooks ...mapstructure.Dec
 	}
 
 	// Automatically run options validation if feasible
+	var validationErrors []error
 	if o, ok := opts.(options.ValidatableOptions); ok {
-		if validationErrors := o.Validate(); validationErrors != nil {
-			ret := "invalid options" // FIXME: get name of the options
-			for _, e := range validationErrors {
-				ret += "\n       "
-				ret += e.Error()
-			}
-
-			return fmt.Errorf(ret)
-		}
+		validationErrors = append(validationErrors, o.Validate()...)
+	}
+	if o, ok := opts.(options.ContextValidatableOptions); ok {
+		validationErrors = append(validationErrors, o.ValidateContext(c.Context(), c)...)
+	}
+	if len(validationErrors) > 0 {
+		return newValidationError(c, opts, validationErrors)
 	}
 
 	// Automatically transform options if feasible
@@ -0,0 +1,213 @@
+package autoflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnmarshalSuite struct {
+	suite.Suite
+}
+
+func TestUnmarshalSuite(t *testing.T) {
+	suite.Run(t, new(UnmarshalSuite))
+}
+
+// fixture gives the options of the tests the Attach method they don't use.
+type fixture struct{}
+
+func (fixture) Attach(*cobra.Command) {}
+
+func (suite *UnmarshalSuite) TestValidationError() {
+	c := &cobra.Command{Use: "validate"}
+	opts := &validatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+
+	err := Unmarshal(c, opts)
+	var validationErr *ValidationError
+	suite.Require().ErrorAs(err, &validationErr)
+	suite.Equal("autoflags.validatedOptions", validationErr.Options)
+	suite.Require().Len(validationErr.Issues, 2)
+	suite.Equal("server.port", validationErr.Issues[0].Flag)
+	suite.Equal([]string{"SERVER_PORT"}, validationErr.Issues[0].Envs)
+	suite.Equal("", validationErr.Issues[1].Flag)
+	suite.EqualError(err, "invalid options for autoflags.validatedOptions\n       port must be positive (--server.port, env SERVER_PORT, config key server.port)\n       something else is wrong")
+}
+
+type contextValidatedOptions struct {
+	fixture
+
+	Server serverOptions
+	Force  bool
+}
+
+func (o *contextValidatedOptions) ValidateContext(ctx context.Context, c *cobra.Command) []error {
+	if c.Name() == "deploy" && !o.Force && !c.Flags().Changed("server.port") {
+		return []error{NewFieldError("server.port", errors.New("deploy needs an explicit port"))}
+	}
+
+	return nil
+}
+
+func (suite *UnmarshalSuite) TestValidationContext() {
+	c := &cobra.Command{Use: "deploy"}
+	opts := &contextValidatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.EqualError(Unmarshal(c, opts), "invalid options for autoflags.contextValidatedOptions\n       deploy needs an explicit port (--server.port, env SERVER_PORT, config key server.port)")
+
+	suite.Require().Nil(c.Flags().Parse([]string{"--server.port", "80"}))
+	suite.Nil(Unmarshal(c, opts))
+
+	c = &cobra.Command{Use: "plan"}
+	opts = &contextValidatedOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Nil(Unmarshal(c, opts))
+}
+
+func (suite *UnmarshalSuite) TestPartialSuccess() {
+	file := suite.useConfigFile("config.yaml", "timeout: soon\nretries: 2\nhosts: [a, b]\n")
+	suite.T().Setenv("RETRIES", "many")
+
+	c := &cobra.Command{Use: "partial"}
+	opts := &envOptions{Timeout: time.Minute}
+	suite.Require().Nil(Define(c, opts))
+	suite.ErrorContains(Unmarshal(c, opts), `invalid value "many" for env RETRIES`)
+
+	c = &cobra.Command{Use: "partial"}
+	opts = &envOptions{Timeout: time.Minute}
+	suite.Require().Nil(Define(c, opts, WithPartialSuccess()))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(time.Minute, opts.Timeout)
+	suite.Equal(0, opts.Retries)
+	suite.Equal([]string{"a", "b"}, opts.Hosts)
+
+	inspection, err := Inspect(c)
+	suite.Require().Nil(err)
+	suite.Require().Len(inspection.Warnings, 2)
+	suite.Contains(inspection.Warnings, `cannot parse 'Retries' as int: strconv.ParseInt: parsing "many": invalid syntax (from env RETRIES), using the default "0" instead`)
+	suite.Contains(inspection.Warnings, `error decoding 'Timeout': time: invalid duration "soon" (from config `+file+`:1:1), using the default "1m0s" instead`)
+}
+
+// useConfigFile makes the input content the loaded config file for the duration of the test.
+func (suite *UnmarshalSuite) useConfigFile(name, content string) string {
+	file := filepath.Join(suite.T().TempDir(), name)
+	suite.Require().Nil(os.WriteFile(file, []byte(content), 0o600))
+	viper.SetConfigFile(file)
+	found, _ := UseConfig(nil)
+	suite.Require().True(found)
+	suite.T().Cleanup(func() {
+		viper.Reset()
+		configSettings = nil
+	})
+
+	return file
+}
+
+func (suite *UnmarshalSuite) TestConfigOnly() {
+	suite.useConfigFile("config.yaml", "server:\n  port: 1\n")
+
+	c := &cobra.Command{Use: "config"}
+	opts := &portOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(1, opts.Server.Port)
+}
+
+type version struct {
+	Major, Minor int
+}
+
+func (v version) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("v%d.%d", v.Major, v.Minor)), nil
+}
+
+func (v *version) UnmarshalText(text []byte) error {
+	if _, err := fmt.Sscanf(string(text), "v%d.%d", &v.Major, &v.Minor); err != nil {
+		return fmt.Errorf("invalid version %q", text)
+	}
+
+	return nil
+}
+
+type secretRefOptions struct {
+	fixture
+
+	Token    string `flagsecret:"true" flagenv:"true"`
+	Password string `flagsecret:"true"`
+	Endpoint string
+}
+
+type labelsOptions struct {
+	fixture
+
+	Labels      map[string]string `flagcustom:"true" flagmerge:"merge" flagenv:"true"`
+	Annotations map[string]string `flagcustom:"true"`
+}
+
+func (o *labelsOptions) DefineLabels(c *cobra.Command, typename, name, short, descr string) {
+	c.Flags().StringToStringVarP(&o.Labels, name, short, nil, descr)
+}
+
+func (o *labelsOptions) DefineAnnotations(c *cobra.Command, typename, name, short, descr string) {
+	c.Flags().StringToStringVarP(&o.Annotations, name, short, nil, descr)
+}
+
+type dryRunOptions struct {
+	fixture
+
+	Token   string `flagenv:"true" flagsecret:"true"`
+	Name    string
+	Timeout time.Duration
+	Hosts   []string
+	Verbose bool
+	Port    int `default:"8080"`
+}
+
+type telemetryOptions struct {
+	fixture
+
+	Workers int           `flagtelemetry:"true"`
+	Timeout time.Duration `flagtelemetry:"true"`
+	Token   string        `flagtelemetry:"true" flagsecret:"true"`
+	Other   string
+}
+
+type envOptions struct {
+	fixture
+
+	Timeout time.Duration `flagenv:"true"`
+	Retries int           `flagenv:"true"`
+	Hosts   []string      `flagenv:"true"`
+}
+
+type portOptions struct {
+	fixture
+
+	Server serverOptions
+}
+
+type serverOptions struct {
+	Port int `flagenv:"true"`
+}
+
+type validatedOptions struct {
+	fixture
+
+	Server serverOptions
+}
+
+func (o *validatedOptions) Validate() []error {
+	return []error{
+		NewFieldError("server.port", errors.New("port must be positive")),
+		errors.New("something else is wrong"),
+	}
+}
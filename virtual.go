@@ -0,0 +1,66 @@
+package autoflags
+
+import (
+	"fmt"
+
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+)
+
+// defineVirtuals defines the string flags of the virtual options, if the input options are such.
+func defineVirtuals(c *cobra.Command, o options.Options) error {
+	vo, ok := o.(options.VirtualOptions)
+	if !ok {
+		return nil
+	}
+	s := getScope(c)
+	for _, v := range vo.VirtualFlags() {
+		if v.Name == "" || v.Set == nil {
+			return fmt.Errorf("virtual flags need a name and a setter")
+		}
+		if err := checkCollision(c, v.Name, v.Short); err != nil {
+			return err
+		}
+		c.Flags().StringP(v.Name, v.Short, v.Default, v.Usage)
+		if v.Default != "" {
+			s.viper.SetDefault(v.Name, v.Default)
+			s.defaults[v.Name] = v.Default
+		}
+		if v.Env {
+			envs := []string{prefix + envReplacer.Replace(v.Name)}
+			_ = c.Flags().SetAnnotation(v.Name, FlagEnvsAnnotation, envs)
+			if legacy := getLegacyEnvs(envs); len(legacy) > 0 {
+				_ = c.Flags().SetAnnotation(v.Name, FlagLegacyEnvsAnnotation, legacy)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setVirtuals hands the resolved values of the virtual flags to their setters.
+//
+// Setters are not called when no source sets their flags and they have no default.
+func setVirtuals(c *cobra.Command, opts options.Options) error {
+	vo, ok := opts.(options.VirtualOptions)
+	if !ok {
+		return nil
+	}
+	s := getScope(c)
+	for _, v := range vo.VirtualFlags() {
+		f := c.Flags().Lookup(v.Name)
+		if f == nil || v.Set == nil {
+			continue
+		}
+		if winner(lookupSources(c, f), s.precedenceOf(f.Name)) == SourceDefault && v.Default == "" {
+			continue
+		}
+		val := cast.ToString(resolvedValue(c, f))
+		if err := v.Set(val); err != nil {
+			return fmt.Errorf("invalid value %q for flag --%s (from %s): %w", val, v.Name, origin(c, f), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package autoflags
+
+import (
+	"github.com/leodido/autoflags/options"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+)
+
+type virtualOptions struct {
+	fixture
+
+	Port  int
+	level zapcore.Level
+	tags  string
+}
+
+func (o *virtualOptions) SetLogLevel(text string) error {
+	return o.level.UnmarshalText([]byte(text))
+}
+
+func (o *virtualOptions) VirtualFlags() []options.VirtualFlag {
+	return []options.VirtualFlag{
+		{Name: "log-level", Short: "l", Usage: "the log level", Default: "info", Env: true, Set: o.SetLogLevel},
+		{Name: "tags", Set: func(text string) error {
+			o.tags = text
+
+			return nil
+		}},
+	}
+}
+
+func (suite *UnmarshalSuite) TestVirtualFlags() {
+	suite.T().Setenv("LOG_LEVEL", "")
+	suite.useConfigFile("config.yaml", "log-level: warn\n")
+	c := &cobra.Command{Use: "virtual"}
+	opts := &virtualOptions{}
+	suite.Require().Nil(Define(c, opts))
+	suite.Equal("the log level", c.Flags().Lookup("log-level").Usage)
+	suite.Equal([]string{"LOG_LEVEL"}, c.Flags().Lookup("log-level").Annotations[FlagEnvsAnnotation])
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(zapcore.WarnLevel, opts.level)
+	suite.Empty(opts.tags)
+	suite.Empty(ValidateConfig(c).Unknown)
+
+	suite.T().Setenv("LOG_LEVEL", "error")
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(zapcore.ErrorLevel, opts.level)
+
+	suite.Require().Nil(c.Flags().Parse([]string{"-l", "verbose", "--tags", "a,b"}))
+	suite.EqualError(Unmarshal(c, opts), `invalid value "verbose" for flag --log-level (from flag): unrecognized level: "verbose"`)
+	suite.Require().Nil(c.Flags().Parse([]string{"-l", "debug"}))
+	suite.Require().Nil(Unmarshal(c, opts))
+	suite.Equal(zapcore.DebugLevel, opts.level)
+	suite.Equal("a,b", opts.tags)
+
+	suite.EqualError(Define(&cobra.Command{Use: "clash"}, &virtualClashOptions{}), "flag --log-level is already defined")
+}
+
+type virtualClashOptions struct {
+	fixture
+
+	LogLevel string `flag:"log-level"`
+}
+
+func (o *virtualClashOptions) VirtualFlags() []options.VirtualFlag {
+	return []options.VirtualFlag{{Name: "log-level", Set: func(string) error { return nil }}}
+}